@@ -0,0 +1,242 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// ResolveRuleAlias names an exact match on one of a link's aliases.
+const ResolveRuleAlias = "alias"
+
+// AuditActionAlias records an alias being added to or removed from a link.
+const AuditActionAlias = "alias"
+
+// linkAliasesColumn selects the comma-separated aliases of the current links row.
+const linkAliasesColumn = "(SELECT group_concat(alias, ',') FROM link_aliases WHERE link_aliases.link_id = links.id)"
+
+// AliasRequest is the payload of the add alias endpoint.
+type AliasRequest struct {
+	Alias string `json:"alias"`
+}
+
+// parseAliases splits the comma-separated aliases read by linkAliasesColumn.
+func parseAliases(value sql.NullString) []string {
+	if !value.Valid {
+		return nil
+	}
+	aliases := splitList(value.String)
+	sort.Strings(aliases)
+	return aliases
+}
+
+// validateAlias normalizes an alias path the way link paths are and checks
+// it, returning the path to store. Aliases follow the path rules, including
+// the server's numeric path setting, but resolve exactly, so they cannot be
+// templated.
+func (s *Server) validateAlias(alias string) (string, error) {
+	alias = strings.ToLower(normalizePath(alias))
+	if err := s.validatePath(alias); err != nil {
+		return "", err
+	}
+	if isTemplatedPath(alias) {
+		return "", fmt.Errorf("an alias cannot be templated")
+	}
+	return alias, nil
+}
+
+// checkAliasFree fails when path is already used as an alias. Links and
+// aliases share one path namespace, so neither may shadow the other.
+func checkAliasFree(tx *sql.Tx, path string) error {
+	var taken bool
+	if err := tx.QueryRow(`SELECT EXISTS(SELECT 1 FROM link_aliases WHERE alias = ?)`, path).Scan(&taken); err != nil {
+		return err
+	}
+	if taken {
+		return fmt.Errorf("an alias with path '%s' already exists", path)
+	}
+	return nil
+}
+
+// insertAlias points alias at a link as part of the given transaction.
+func insertAlias(tx *sql.Tx, linkID int64, alias string) error {
+	var taken bool
+	if err := tx.QueryRow(`SELECT EXISTS(SELECT 1 FROM links WHERE path = ?)`, alias).Scan(&taken); err != nil {
+		return err
+	}
+	if taken {
+		return fmt.Errorf("a link with path '%s' already exists", alias)
+	}
+	if err := checkAliasFree(tx, alias); err != nil {
+		return err
+	}
+	_, err := tx.Exec(`INSERT INTO link_aliases(alias, link_id) VALUES(?, ?)`, alias, linkID)
+	return err
+}
+
+// AddAlias makes alias resolve to the link with the given ID and records the
+// change in the audit log. The alias follows the link's URL, so editing the
+// link updates every alias with it.
+func (s *Store) AddAlias(ctx context.Context, id int64, alias string) error {
+	return s.changeAliases(ctx, id, func(tx *sql.Tx) error {
+		return insertAlias(tx, id, strings.ToLower(alias))
+	})
+}
+
+// RemoveAlias stops alias from resolving to the link with the given ID.
+func (s *Store) RemoveAlias(ctx context.Context, id int64, alias string) error {
+	alias = strings.ToLower(alias)
+	return s.changeAliases(ctx, id, func(tx *sql.Tx) error {
+		result, err := tx.Exec(`DELETE FROM link_aliases WHERE alias = ? AND link_id = ?`, alias, id)
+		if err != nil {
+			return err
+		}
+		if n, err := result.RowsAffected(); err != nil {
+			return err
+		} else if n == 0 {
+			return fmt.Errorf("alias '%s' not found", alias)
+		}
+		return nil
+	})
+}
+
+// changeAliases applies change to the aliases of a link, bumping its
+// updated_at so sync clients pick the link up again, and records the old and
+// new aliases in the audit log.
+func (s *Store) changeAliases(ctx context.Context, id int64, change func(tx *sql.Tx) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	prior, err := scanLink(tx.QueryRowContext(ctx, "SELECT "+linkColumns+" FROM links WHERE id = ?", id))
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("link with id %d not found", id)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := change(tx); err != nil {
+		return err
+	}
+	var aliases sql.NullString
+	if err := tx.QueryRowContext(ctx, "SELECT "+linkAliasesColumn+" FROM links WHERE id = ?", id).Scan(&aliases); err != nil {
+		return err
+	}
+
+	updateSQL := `UPDATE links SET updated_at = ` + sqliteNowMilli + ` WHERE id = ?`
+	if _, err := tx.ExecContext(ctx, updateSQL, id); err != nil {
+		return err
+	}
+	changes := map[string]FieldChange{"aliases": {Old: prior.Aliases, New: parseAliases(aliases)}}
+	if err := insertAuditEntry(tx, id, AuditActionAlias, changes); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetLinkByAlias retrieves the link an alias points at.
+func (s *Store) GetLinkByAlias(ctx context.Context, alias string) (*Link, error) {
+	query := "SELECT " + linkColumns + " FROM links WHERE id = (SELECT link_id FROM link_aliases WHERE alias = ?)"
+	link, err := scanLink(s.db.QueryRowContext(ctx, query, strings.ToLower(alias)))
+	if err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+// aliasErrorStatus maps alias errors to HTTP status codes.
+func aliasErrorStatus(err error) int {
+	switch {
+	case strings.Contains(err.Error(), "not found"):
+		return http.StatusNotFound
+	case strings.Contains(err.Error(), "already exists"):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// handleAddAlias adds an alias path to a link.
+// AddAlias godoc
+// @Summary      Add a link alias
+// @Description  Make another path redirect to the link's URL; the alias follows later edits of the link
+// @Tags         links
+// @Accept       json
+// @Produce      json
+// @Param        id     path   int           true   "Link ID"
+// @Param        alias  body   AliasRequest  true   "Alias path"
+// @Param        force  query  bool          false  "Allow a soft-reserved path"
+// @Success      201  {object}  Link
+// @Failure      400  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      409  {object}  ErrorResponse
+// @Failure      422  {object}  ErrorResponse
+// @Router       /links/{id}/aliases [post]
+func (s *Server) handleAddAlias(w http.ResponseWriter, r *http.Request, id int64) {
+	var req AliasRequest
+	if err := decodeJSONStrict(r.Body, &req); err != nil {
+		writeErrorJSON(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	alias, err := s.validateAlias(req.Alias)
+	if err != nil {
+		writeErrorJSON(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	if warning := s.softReservedWarning(alias); warning != "" && !isForced(r) {
+		writeErrorJSON(w, warning+"; retry with ?force=true to use it anyway", http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err := s.store.AddAlias(r.Context(), id, alias); err != nil {
+		status := aliasErrorStatus(err)
+		if status == http.StatusInternalServerError {
+			logRequestError(r, "API AddAlias error", err, "id", id)
+			writeErrorJSON(w, "Failed to add alias", status)
+			return
+		}
+		writeErrorJSON(w, err.Error(), status)
+		return
+	}
+
+	link, err := s.store.GetLinkByID(r.Context(), id)
+	if err != nil {
+		logRequestError(r, "API AddAlias reload error", err, "id", id)
+		writeErrorJSON(w, "Failed to retrieve link", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(link)
+}
+
+// handleRemoveAlias removes an alias path from a link.
+// RemoveAlias godoc
+// @Summary      Remove a link alias
+// @Description  Stop an alias path from redirecting to the link
+// @Tags         links
+// @Param        id     path  int     true  "Link ID"
+// @Param        alias  path  string  true  "Alias path"
+// @Success      204  "No Content"
+// @Failure      404  {object}  ErrorResponse
+// @Router       /links/{id}/aliases/{alias} [delete]
+func (s *Server) handleRemoveAlias(w http.ResponseWriter, r *http.Request, id int64, alias string) {
+	if err := s.store.RemoveAlias(r.Context(), id, alias); err != nil {
+		status := aliasErrorStatus(err)
+		if status == http.StatusInternalServerError {
+			logRequestError(r, "API RemoveAlias error", err, "id", id)
+			writeErrorJSON(w, "Failed to remove alias", status)
+			return
+		}
+		writeErrorJSON(w, err.Error(), status)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestAddAlias(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		query      string
+		numeric    bool
+		status     int
+		wantStored string
+	}{
+		{"plain", `{"alias":"handbook"}`, "", false, http.StatusCreated, "handbook"},
+		{"normalized", `{"alias":" /Team/Handbook/ "}`, "", false, http.StatusCreated, "team/handbook"},
+		{"trailing slash", `{"alias":"Foo/"}`, "", false, http.StatusCreated, "foo"},
+		{"numeric allowed", `{"alias":"123"}`, "", false, http.StatusCreated, "123"},
+		{"numeric disallowed", `{"alias":"123"}`, "", true, http.StatusUnprocessableEntity, ""},
+		{"templated", `{"alias":"wiki/{*}"}`, "", false, http.StatusUnprocessableEntity, ""},
+		{"invalid path", `{"alias":"a b"}`, "", false, http.StatusUnprocessableEntity, ""},
+		{"empty", `{"alias":""}`, "", false, http.StatusUnprocessableEntity, ""},
+		{"soft-reserved", `{"alias":"home"}`, "", false, http.StatusUnprocessableEntity, ""},
+		{"soft-reserved forced", `{"alias":"home"}`, "?force=true", false, http.StatusCreated, "home"},
+		{"taken by a link", `{"alias":"Docs"}`, "", false, http.StatusConflict, ""},
+		{"unknown field", `{"alias":"handbook","path":"x"}`, "", false, http.StatusBadRequest, ""},
+		{"malformed", `{"alias":`, "", false, http.StatusBadRequest, ""},
+		{"not an object", `"handbook"`, "", false, http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, handler := newTestServer(t, func(c *Config) {
+				c.DisallowNumericPaths = tt.numeric
+				c.SoftReserved = []string{"home"}
+			})
+			wiki := createLink(t, server, handler, Link{Path: "wiki", URL: "https://wiki.example.com"})
+			createLink(t, server, handler, Link{Path: "docs", URL: "https://docs.example.com"})
+
+			w := serveAs(t, handler, http.MethodPost, linkTarget(wiki.ID)+"/aliases"+tt.query, tt.body, credentials{})
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body.String())
+			}
+			link, err := server.store.GetLinkByID(context.Background(), wiki.ID)
+			if err != nil {
+				t.Fatalf("GetLinkByID: %v", err)
+			}
+			var want []string
+			if tt.wantStored != "" {
+				want = []string{tt.wantStored}
+			}
+			if fmt.Sprint(link.Aliases) != fmt.Sprint(want) {
+				t.Errorf("aliases = %q, want %q", link.Aliases, want)
+			}
+		})
+	}
+}
+
+func TestAddAliasUnknownLink(t *testing.T) {
+	_, handler := newTestServer(t, nil)
+	w := serveAs(t, handler, http.MethodPost, linkTarget(42)+"/aliases", `{"alias":"handbook"}`, credentials{})
+	if w.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want %d: %s", w.Code, http.StatusNotFound, w.Body.String())
+	}
+}
+
+func TestAliasResolution(t *testing.T) {
+	server, handler := newTestServer(t, nil)
+	wiki := createLink(t, server, handler, Link{Path: "wiki", URL: "https://wiki.example.com"})
+	if w := serveAs(t, handler, http.MethodPost, linkTarget(wiki.ID)+"/aliases", `{"alias":"Team/Handbook"}`, credentials{}); w.Code != http.StatusCreated {
+		t.Fatalf("adding alias: status = %d: %s", w.Code, w.Body.String())
+	}
+
+	assertRedirect := func(target string, status int, location string) {
+		t.Helper()
+		w := serve(t, handler, http.MethodGet, target, nil)
+		if w.Code != status || w.Header().Get("Location") != location {
+			t.Errorf("GET %s = %d %q, want %d %q", target, w.Code, w.Header().Get("Location"), status, location)
+		}
+	}
+	assertRedirect("/team/handbook", http.StatusFound, "https://wiki.example.com")
+	assertRedirect("/Team/Handbook", http.StatusFound, "https://wiki.example.com")
+
+	// The alias follows edits of the link
+	update := Link{Path: "wiki", URL: "https://wiki2.example.com"}
+	if w := serve(t, handler, http.MethodPut, linkTarget(wiki.ID), update); w.Code != http.StatusOK {
+		t.Fatalf("updating link: status = %d: %s", w.Code, w.Body.String())
+	}
+	assertRedirect("/team/handbook", http.StatusFound, "https://wiki2.example.com")
+
+	// A link cannot be created over the alias
+	if w := serve(t, handler, http.MethodPost, "/api/links", Link{Path: "team/handbook", URL: "https://other.example.com"}); w.Code != http.StatusConflict {
+		t.Errorf("creating a link over the alias: status = %d, want %d", w.Code, http.StatusConflict)
+	}
+
+	if w := serve(t, handler, http.MethodDelete, linkTarget(wiki.ID)+"/aliases/team/handbook", nil); w.Code != http.StatusNoContent {
+		t.Fatalf("removing alias: status = %d: %s", w.Code, w.Body.String())
+	}
+	if w := serve(t, handler, http.MethodGet, "/team/handbook", nil); w.Code == http.StatusFound {
+		t.Errorf("GET /team/handbook still redirects after the alias was removed")
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// allowedDomainPattern matches a normalized ALLOWED_DOMAINS entry: dot
+// separated labels of letters, digits and hyphens.
+var allowedDomainPattern = regexp.MustCompile(`^[a-z0-9-]+(\.[a-z0-9-]+)*$`)
+
+// normalizeDomains lowercases ALLOWED_DOMAINS entries and strips a leading
+// "*." or ".", so "*.Example.com" and "example.com" both allow example.com
+// and its subdomains.
+func normalizeDomains(domains []string) []string {
+	var normalized []string
+	for _, domain := range domains {
+		domain = strings.ToLower(domain)
+		domain = strings.TrimPrefix(domain, "*")
+		domain = strings.TrimPrefix(domain, ".")
+		normalized = append(normalized, domain)
+	}
+	return normalized
+}
+
+// checkAllowedDomain enforces ALLOWED_DOMAINS: the host of target must be one
+// of the domains or a subdomain of one. An empty list allows every host.
+func (s *Server) checkAllowedDomain(target string) error {
+	if len(s.config.AllowedDomains) == 0 {
+		return nil
+	}
+	u, err := url.Parse(target)
+	if err != nil {
+		return fmt.Errorf("invalid url")
+	}
+
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	for _, domain := range s.config.AllowedDomains {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return nil
+		}
+	}
+	return fmt.Errorf("url host '%s' is not in an allowed domain (%s)", host, strings.Join(s.config.AllowedDomains, ", "))
+}
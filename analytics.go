@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// Limits of GET /api/analytics/top.
+const (
+	defaultTopClickedLimit = 10
+	maxTopClickedLimit     = 100
+)
+
+// TopClickedResponse lists the most clicked links. TopClicks sums the clicks
+// of the listed links, TotalClicks those of every link.
+type TopClickedResponse struct {
+	Links       []Link `json:"links"`
+	TopClicks   int64  `json:"top_clicks"`
+	TotalClicks int64  `json:"total_clicks"`
+}
+
+// GetMostClickedLinks returns the links with the most clicks, ties broken by
+// path. Links that were never clicked are left out.
+func (s *Store) GetMostClickedLinks(ctx context.Context, limit int) ([]Link, error) {
+	query := "SELECT " + linkColumns + " FROM links WHERE clicks > 0 ORDER BY clicks DESC, path LIMIT ?"
+	rows, err := s.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	links := []Link{}
+	for rows.Next() {
+		link, err := scanLink(rows)
+		if err != nil {
+			return nil, err
+		}
+		links = append(links, link)
+	}
+	return links, rows.Err()
+}
+
+// TotalClicks returns the sum of the click counts of all links.
+func (s *Store) TotalClicks(ctx context.Context) (int64, error) {
+	var total int64
+	err := s.db.QueryRowContext(ctx, `SELECT COALESCE(SUM(clicks), 0) FROM links`).Scan(&total)
+	return total, err
+}
+
+// mostPopularPath returns the path of the most clicked link for the portal
+// header, or "" when no link has been clicked yet.
+func (s *Server) mostPopularPath(ctx context.Context) (string, error) {
+	links, err := s.store.GetMostClickedLinks(ctx, 1)
+	if err != nil || len(links) == 0 {
+		return "", err
+	}
+	return links[0].Path, nil
+}
+
+// handleGetTopClicked returns the most clicked links with click totals.
+// GetTopClicked godoc
+// @Summary      Most clicked links
+// @Description  Links ordered by click count, with the clicks of the listed links and of all links
+// @Tags         stats
+// @Produce      json
+// @Param        limit  query  int  false  "Maximum number of links (default 10, max 100)"
+// @Success      200  {object}  TopClickedResponse
+// @Failure      400  {object}  ErrorResponse
+// @Router       /analytics/top [get]
+func (s *Server) handleGetTopClicked(w http.ResponseWriter, r *http.Request) {
+	limit := defaultTopClickedLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > maxTopClickedLimit {
+			writeErrorJSON(w, fmt.Sprintf("limit must be a number between 1 and %d", maxTopClickedLimit), http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+
+	links, err := s.store.GetMostClickedLinks(r.Context(), limit)
+	if err != nil {
+		logRequestError(r, "API GetTopClicked error", err)
+		writeErrorJSON(w, "Failed to retrieve top links", http.StatusInternalServerError)
+		return
+	}
+	total, err := s.store.TotalClicks(r.Context())
+	if err != nil {
+		logRequestError(r, "API GetTopClicked error", err)
+		writeErrorJSON(w, "Failed to retrieve top links", http.StatusInternalServerError)
+		return
+	}
+
+	response := TopClickedResponse{Links: links, TotalClicks: total}
+	for _, link := range links {
+		response.TopClicks += link.Clicks
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Audit actions recorded in the link_audit table.
+const (
+	AuditActionUpdate        = "update"
+	AuditActionTransfer      = "transfer"
+	AuditActionIcon          = "icon"
+	AuditActionTagRename     = "tag_rename"
+	AuditActionExpiry        = "expiry"
+	AuditActionMoveGroup     = "move_group"
+	AuditActionNormalizePath = "normalize_path"
+	AuditActionToggle        = "toggle"
+)
+
+// FieldChange holds the old and new value of a single changed link field.
+type FieldChange struct {
+	Old interface{} `json:"old"`
+	New interface{} `json:"new"`
+}
+
+// AuditEntry describes one recorded change to a link.
+type AuditEntry struct {
+	ID        int64                  `json:"id"`
+	LinkID    int64                  `json:"link_id"`
+	Action    string                 `json:"action"`
+	Changes   map[string]FieldChange `json:"changes"`
+	CreatedAt time.Time              `json:"created_at"`
+}
+
+// diffLinks returns the fields that differ between two versions of a link,
+// keyed by their JSON field name.
+func diffLinks(prior, updated Link) map[string]FieldChange {
+	changes := make(map[string]FieldChange)
+	if prior.Path != updated.Path {
+		changes["path"] = FieldChange{Old: prior.Path, New: updated.Path}
+	}
+	if prior.URL != updated.URL {
+		changes["url"] = FieldChange{Old: prior.URL, New: updated.URL}
+	}
+	if prior.RateLimit != updated.RateLimit {
+		changes["rate_limit"] = FieldChange{Old: prior.RateLimit, New: updated.RateLimit}
+	}
+	if prior.Owner != updated.Owner {
+		changes["owner"] = FieldChange{Old: prior.Owner, New: updated.Owner}
+	}
+	if prior.Group != updated.Group {
+		changes["group"] = FieldChange{Old: prior.Group, New: updated.Group}
+	}
+	if prior.Description != updated.Description {
+		changes["description"] = FieldChange{Old: prior.Description, New: updated.Description}
+	}
+	if prior.Prefix != updated.Prefix {
+		changes["prefix"] = FieldChange{Old: prior.Prefix, New: updated.Prefix}
+	}
+	if !sameExpiry(prior.ExpiresAt, updated.ExpiresAt) {
+		changes["expires_at"] = FieldChange{Old: prior.ExpiresAt, New: updated.ExpiresAt}
+	}
+	if strings.Join(prior.Tags, ",") != strings.Join(updated.Tags, ",") {
+		changes["tags"] = FieldChange{Old: prior.Tags, New: updated.Tags}
+	}
+	return changes
+}
+
+// insertAuditEntry writes an audit entry as part of the given transaction.
+func insertAuditEntry(tx *sql.Tx, linkID int64, action string, changes map[string]FieldChange) error {
+	data, err := json.Marshal(changes)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit changes: %w", err)
+	}
+	_, err = tx.Exec(`INSERT INTO link_audit(link_id, action, changes) VALUES(?, ?, ?)`, linkID, action, string(data))
+	return err
+}
+
+// GetAudit retrieves a page of audit entries, newest first, together with
+// the total number of matching entries. A zero linkID selects all links.
+func (s *Store) GetAudit(ctx context.Context, linkID int64, limit, offset int) ([]AuditEntry, int, error) {
+	where := ""
+	var args []interface{}
+	if linkID != 0 {
+		where = " WHERE link_id = ?"
+		args = append(args, linkID)
+	}
+
+	var total int
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM link_audit`+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := `SELECT id, link_id, action, changes, created_at FROM link_audit` + where +
+		` ORDER BY created_at DESC, id DESC LIMIT ? OFFSET ?`
+	rows, err := s.db.QueryContext(ctx, query, append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	entries := []AuditEntry{}
+	for rows.Next() {
+		var entry AuditEntry
+		var changes string
+		if err := rows.Scan(&entry.ID, &entry.LinkID, &entry.Action, &changes, &entry.CreatedAt); err != nil {
+			return nil, 0, err
+		}
+		if err := json.Unmarshal([]byte(changes), &entry.Changes); err != nil {
+			return nil, 0, fmt.Errorf("failed to decode audit changes: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, total, rows.Err()
+}
+
+// auditPage reads the limit and offset query parameters of the audit
+// endpoints, applying the configured default and maximum page size.
+func (s *Server) auditPage(r *http.Request) (int, int, error) {
+	return parsePage(r, s.config.AuditPageSize, s.config.AuditMaxPageSize)
+}
+
+// writeAuditPage writes a page of audit entries with the total count in the
+// X-Total-Count header.
+func writeAuditPage(w http.ResponseWriter, entries []AuditEntry, total int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+	json.NewEncoder(w).Encode(entries)
+}
+
+// handleGetAudit returns a page of the audit log across all links.
+// GetAudit godoc
+// @Summary      Audit log
+// @Description  Changes to all links, newest first; the total is returned in X-Total-Count
+// @Tags         links
+// @Produce      json
+// @Param        limit   query  int  false  "Page size (default AUDIT_PAGE_SIZE, max AUDIT_MAX_PAGE_SIZE)"
+// @Param        offset  query  int  false  "Number of entries to skip"
+// @Success      200  {array}   AuditEntry
+// @Failure      400  {object}  ErrorResponse
+// @Router       /audit [get]
+func (s *Server) handleGetAudit(w http.ResponseWriter, r *http.Request) {
+	limit, offset, err := s.auditPage(r)
+	if err != nil {
+		writeErrorJSON(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	entries, total, err := s.store.GetAudit(r.Context(), 0, limit, offset)
+	if err != nil {
+		logRequestError(r, "API GetAudit error", err)
+		writeErrorJSON(w, "Failed to retrieve audit log", http.StatusInternalServerError)
+		return
+	}
+
+	writeAuditPage(w, entries, total)
+}
+
+// handleGetLinkHistory returns the audit history of a link.
+// GetLinkHistory godoc
+// @Summary      Link history
+// @Description  List recorded changes to a link with old and new values, newest first
+// @Tags         links
+// @Produce      json
+// @Param        id      path   int  true   "Link ID"
+// @Param        limit   query  int  false  "Page size (default AUDIT_PAGE_SIZE, max AUDIT_MAX_PAGE_SIZE)"
+// @Param        offset  query  int  false  "Number of entries to skip"
+// @Success      200  {array}   AuditEntry
+// @Failure      400  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Router       /links/{id}/history [get]
+func (s *Server) handleGetLinkHistory(w http.ResponseWriter, r *http.Request, id int64) {
+	limit, offset, err := s.auditPage(r)
+	if err != nil {
+		writeErrorJSON(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	entries, total, err := s.store.GetAudit(r.Context(), id, limit, offset)
+	if err != nil {
+		logRequestError(r, "API GetLinkHistory error", err, "id", id)
+		writeErrorJSON(w, "Failed to retrieve link history", http.StatusInternalServerError)
+		return
+	}
+
+	if total == 0 {
+		exists, err := s.store.LinkExists(r.Context(), id)
+		if err != nil {
+			logRequestError(r, "API GetLinkHistory existence check error", err, "id", id)
+			writeErrorJSON(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		if !exists {
+			writeErrorJSON(w, fmt.Sprintf("Link with id %d not found", id), http.StatusNotFound)
+			return
+		}
+	}
+
+	writeAuditPage(w, entries, total)
+}
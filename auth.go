@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	restful "github.com/emicklei/go-restful/v3"
+)
+
+// requireAdmin checks the request carries the configured admin bearer token,
+// writing an error response and returning false when it does not.
+func (s *Server) requireAdmin(w http.ResponseWriter, r *http.Request) bool {
+	if s.config.AdminToken == "" {
+		writeErrorJSON(w, "Admin endpoints are disabled; set ADMIN_TOKEN to enable them", http.StatusForbidden)
+		return false
+	}
+
+	if !s.hasAdminToken(r) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="go-links admin"`)
+		writeErrorJSON(w, "Admin authentication required", http.StatusUnauthorized)
+		return false
+	}
+	return true
+}
+
+// hasAdminToken reports whether the request carries the admin bearer token.
+func (s *Server) hasAdminToken(r *http.Request) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(token), []byte(s.config.AdminToken)) == 1
+}
+
+// createdByHeader names the user creating links. The server has no user
+// accounts, so it is set by clients or by an authenticating proxy in front of
+// the server.
+const createdByHeader = "X-Created-By"
+
+// maxCreatedByLength caps the stored creator name, in characters.
+const maxCreatedByLength = 100
+
+// authUserKey is the request context key holding the basic auth user.
+type authUserKey struct{}
+
+// requestCreator returns who is creating links with the request: the basic
+// auth user when auth is enabled, otherwise the X-Created-By header, or ""
+// when the request does not say.
+func requestCreator(r *http.Request) string {
+	if user, ok := r.Context().Value(authUserKey{}).(string); ok {
+		return user
+	}
+	creator := []rune(strings.TrimSpace(r.Header.Get(createdByHeader)))
+	if len(creator) > maxCreatedByLength {
+		creator = creator[:maxCreatedByLength]
+	}
+	return string(creator)
+}
+
+// isReadMethod reports whether requests with the method only read data.
+func isReadMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+// requireWriteAuth enforces API_PUBLIC_READ: reads pass, while writes need
+// the admin bearer token. It writes an error response and returns false when
+// a write is not authenticated.
+func (s *Server) requireWriteAuth(w http.ResponseWriter, r *http.Request) bool {
+	if !s.config.APIPublicRead || isReadMethod(r.Method) {
+		return true
+	}
+	if !s.hasAdminToken(r) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="go-links"`)
+		writeErrorJSON(w, "Authentication required to modify links", http.StatusUnauthorized)
+		return false
+	}
+	return true
+}
+
+// publicReadFilter applies requireWriteAuth to every route of the API web
+// service.
+func (s *Server) publicReadFilter(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
+	if !s.requireWriteAuth(resp.ResponseWriter, req.Request) {
+		return
+	}
+	chain.ProcessFilter(req, resp)
+}
+
+// isManagementPath reports whether a request path belongs to the portal, the
+// API or the Swagger UI rather than to a link redirect. It mirrors the
+// prefixes rootHandler routes to the portal.
+func isManagementPath(path string) bool {
+	return path == "/go" || strings.HasPrefix(path, "/go/links") || strings.HasPrefix(path, "/go/htmx") ||
+		strings.HasPrefix(path, "/api/") || path == "/swagger"
+}
+
+// basicAuth protects management paths with the configured basic auth
+// credentials, leaving redirects and health checks public. The admin bearer
+// token is accepted in place of the credentials, and with API_PUBLIC_READ API
+// reads stay open. Without AUTH_USER next is returned unchanged.
+func (s *Server) basicAuth(next http.Handler) http.Handler {
+	if s.config.AuthUser == "" {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !isManagementPath(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		isAPI := strings.HasPrefix(r.URL.Path, "/api/")
+		if s.config.AdminToken != "" && s.hasAdminToken(r) || isAPI && s.config.APIPublicRead && isReadMethod(r.Method) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		user, pass, ok := r.BasicAuth()
+		if !ok || subtle.ConstantTimeCompare([]byte(user), []byte(s.config.AuthUser)) != 1 ||
+			subtle.ConstantTimeCompare([]byte(pass), []byte(s.config.AuthPass)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Basic realm="go-links", charset="UTF-8"`)
+			if isAPI {
+				writeErrorJSON(w, "Authentication required", http.StatusUnauthorized)
+			} else {
+				http.Error(w, "Authentication required", http.StatusUnauthorized)
+			}
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), authUserKey{}, user)))
+	})
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// credentials authenticate a test request.
+type credentials struct {
+	bearer     string
+	user, pass string
+}
+
+func (c credentials) apply(r *http.Request) {
+	if c.bearer != "" {
+		r.Header.Set("Authorization", "Bearer "+c.bearer)
+	}
+	if c.user != "" {
+		r.SetBasicAuth(c.user, c.pass)
+	}
+}
+
+// serveAs sends a request with an optional JSON body and credentials.
+func serveAs(t *testing.T, handler http.Handler, method, target, body string, creds credentials) *httptest.ResponseRecorder {
+	t.Helper()
+	r := httptest.NewRequest(method, target, bytes.NewReader([]byte(body)))
+	if body != "" {
+		r.Header.Set("Content-Type", "application/json")
+	}
+	creds.apply(r)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
+
+func TestAdminEndpointAuth(t *testing.T) {
+	tests := []struct {
+		name   string
+		token  string
+		creds  credentials
+		status int
+	}{
+		{"disabled", "", credentials{}, http.StatusForbidden},
+		{"disabled with a token", "", credentials{bearer: "secret"}, http.StatusForbidden},
+		{"missing token", "secret", credentials{}, http.StatusUnauthorized},
+		{"wrong token", "secret", credentials{bearer: "guess"}, http.StatusUnauthorized},
+		{"admin token", "secret", credentials{bearer: "secret"}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, handler := newTestServer(t, func(c *Config) { c.AdminToken = tt.token })
+			w := serveAs(t, handler, http.MethodGet, "/api/config", "", tt.creds)
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.status, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestAPIPublicRead(t *testing.T) {
+	server, handler := newTestServer(t, func(c *Config) {
+		c.AdminToken = "secret"
+		c.APIPublicRead = true
+	})
+	wiki := createLinkAs(t, server, "wiki")
+	admin := credentials{bearer: "secret"}
+	newLink := `{"path":"docs","url":"https://docs.example.com"}`
+	update := `{"path":"wiki","url":"https://wiki2.example.com"}`
+
+	tests := []struct {
+		method, target, body string
+		creds                credentials
+		status               int
+	}{
+		{http.MethodGet, "/api/links", "", credentials{}, http.StatusOK},
+		{http.MethodGet, linkTarget(wiki.ID), "", credentials{}, http.StatusOK},
+		{http.MethodPost, "/api/links", newLink, credentials{}, http.StatusUnauthorized},
+		{http.MethodPost, "/api/links", newLink, credentials{bearer: "guess"}, http.StatusUnauthorized},
+		{http.MethodPut, linkTarget(wiki.ID), update, credentials{}, http.StatusUnauthorized},
+		{http.MethodDelete, linkTarget(wiki.ID), "", credentials{}, http.StatusUnauthorized},
+		{http.MethodPost, "/api/links", newLink, admin, http.StatusCreated},
+		{http.MethodPut, linkTarget(wiki.ID), update, admin, http.StatusOK},
+		{http.MethodDelete, linkTarget(wiki.ID), "", admin, http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		w := serveAs(t, handler, tt.method, tt.target, tt.body, tt.creds)
+		if w.Code != tt.status {
+			t.Errorf("%s %s (bearer %q) = %d, want %d: %s", tt.method, tt.target, tt.creds.bearer, w.Code, tt.status, w.Body.String())
+		}
+	}
+}
+
+func TestBasicAuth(t *testing.T) {
+	tests := []struct {
+		name       string
+		publicRead bool
+		method     string
+		target     string
+		creds      credentials
+		status     int
+	}{
+		{"redirect stays open", false, http.MethodGet, "/wiki", credentials{}, http.StatusFound},
+		{"portal", false, http.MethodGet, "/go", credentials{}, http.StatusUnauthorized},
+		{"portal with credentials", false, http.MethodGet, "/go", credentials{user: "alice", pass: "hunter2"}, http.StatusOK},
+		{"portal with wrong password", false, http.MethodGet, "/go", credentials{user: "alice", pass: "guess"}, http.StatusUnauthorized},
+		{"API", false, http.MethodGet, "/api/links", credentials{}, http.StatusUnauthorized},
+		{"API with credentials", false, http.MethodGet, "/api/links", credentials{user: "alice", pass: "hunter2"}, http.StatusOK},
+		{"API with admin token", false, http.MethodGet, "/api/links", credentials{bearer: "secret"}, http.StatusOK},
+		{"swagger", false, http.MethodGet, "/swagger", credentials{}, http.StatusUnauthorized},
+		{"public API read", true, http.MethodGet, "/api/links", credentials{}, http.StatusOK},
+		{"public read keeps the portal closed", true, http.MethodGet, "/go", credentials{}, http.StatusUnauthorized},
+		{"public read keeps writes closed", true, http.MethodDelete, "/api/links/1", credentials{}, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, handler := newTestServer(t, func(c *Config) {
+				c.AuthUser = "alice"
+				c.AuthPass = "hunter2"
+				c.AdminToken = "secret"
+				c.APIPublicRead = tt.publicRead
+			})
+			createLinkAs(t, server, "wiki")
+			w := serveAs(t, handler, tt.method, tt.target, "", tt.creds)
+			if w.Code != tt.status {
+				t.Errorf("%s %s = %d, want %d", tt.method, tt.target, w.Code, tt.status)
+			}
+		})
+	}
+}
+
+// createLinkAs stores a link directly, bypassing API authentication.
+func createLinkAs(t *testing.T, server *Server, path string) *Link {
+	t.Helper()
+	ctx := context.Background()
+	if err := server.store.CreateLink(ctx, Link{Path: path, URL: "https://" + path + ".example.com"}); err != nil {
+		t.Fatalf("CreateLink: %v", err)
+	}
+	link, err := server.store.GetLinkByPath(ctx, path)
+	if err != nil {
+		t.Fatalf("GetLinkByPath: %v", err)
+	}
+	return link
+}
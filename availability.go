@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// PathAvailability reports whether a path can be used for a new link and, if
+// not, why. Warning is set for soft-reserved paths, which are available but
+// need force.
+type PathAvailability struct {
+	Path      string `json:"path"`
+	Available bool   `json:"available"`
+	Reason    string `json:"reason,omitempty"`
+	Warning   string `json:"warning,omitempty"`
+}
+
+// checkPathAvailability applies the path rules of link creation to path and
+// looks it up among link paths and aliases.
+func (s *Server) checkPathAvailability(ctx context.Context, path string) (PathAvailability, error) {
+	// Paths are stored lowercased
+	result := PathAvailability{Path: strings.ToLower(normalizePath(path))}
+	if err := s.validatePath(path); err != nil {
+		result.Reason = err.Error()
+		return result, nil
+	}
+
+	_, err := s.store.GetLinkByPath(ctx, result.Path)
+	if err == nil {
+		result.Reason = fmt.Sprintf("a link with path '%s' already exists", result.Path)
+		return result, nil
+	}
+	if err != sql.ErrNoRows {
+		return result, err
+	}
+
+	_, err = s.store.GetLinkByAlias(ctx, result.Path)
+	if err == nil {
+		result.Reason = fmt.Sprintf("an alias with path '%s' already exists", result.Path)
+		return result, nil
+	}
+	if err != sql.ErrNoRows {
+		return result, err
+	}
+
+	result.Available = true
+	result.Warning = s.softReservedWarning(result.Path)
+	return result, nil
+}
+
+// handleCheckAvailability reports whether a path is free for a new link.
+// CheckAvailability godoc
+// @Summary      Check path availability
+// @Description  Whether a path is valid and unused by links and aliases; reason explains why not
+// @Tags         links
+// @Produce      json
+// @Param        path  query  string  true  "Link path"
+// @Success      200  {object}  PathAvailability
+// @Failure      400  {object}  ErrorResponse
+// @Router       /links/available [get]
+func (s *Server) handleCheckAvailability(w http.ResponseWriter, r *http.Request) {
+	path := r.URL.Query().Get("path")
+	if strings.TrimSpace(path) == "" {
+		writeErrorJSON(w, "Query parameter 'path' is required", http.StatusBadRequest)
+		return
+	}
+
+	result, err := s.checkPathAvailability(r.Context(), path)
+	if err != nil {
+		logRequestError(r, "API CheckAvailability error", err)
+		writeErrorJSON(w, "Failed to check path", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// htmxPathAvailability renders the inline availability note of the create
+// form's path input. An empty path renders nothing.
+func (s *Server) htmxPathAvailability(w http.ResponseWriter, r *http.Request) {
+	path := r.URL.Query().Get("path")
+	if strings.TrimSpace(path) == "" {
+		return
+	}
+
+	result, err := s.checkPathAvailability(r.Context(), path)
+	if err != nil {
+		logRequestError(r, "Error checking path availability", err)
+		http.Error(w, "Failed to check path", http.StatusInternalServerError)
+		return
+	}
+
+	if err := s.executeTemplate(w, "path-availability", result); err != nil {
+		logRequestError(r, "Template execution error", err)
+		http.Error(w, "Template rendering error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupFilePrefix and backupFileSuffix frame the timestamp in backup file names.
+const (
+	backupFilePrefix = "links-"
+	backupFileSuffix = ".db"
+)
+
+// backupMu serializes scheduled and on-demand backups.
+var backupMu sync.Mutex
+
+// BackupTo writes a consistent copy of the database to path without blocking
+// readers, using SQLite's VACUUM INTO. The target file must not exist.
+func (s *Store) BackupTo(ctx context.Context, path string) error {
+	if _, err := s.db.ExecContext(ctx, `VACUUM INTO ?`, path); err != nil {
+		return fmt.Errorf("failed to back up database to %s: %w", path, err)
+	}
+	return nil
+}
+
+// createBackup writes a timestamped backup into dir and prunes all but the
+// newest retain backups. It returns the path of the new backup.
+func createBackup(ctx context.Context, store LinkStore, dir string, retain int) (string, error) {
+	backupMu.Lock()
+	defer backupMu.Unlock()
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("cannot create backup directory '%s': %w", dir, err)
+	}
+
+	name := backupFilePrefix + time.Now().UTC().Format("20060102-150405") + backupFileSuffix
+	path := filepath.Join(dir, name)
+	if err := store.BackupTo(ctx, path); err != nil {
+		return "", err
+	}
+
+	if err := pruneBackups(dir, retain); err != nil {
+		slog.Warn("Could not prune old backups", "error", err)
+	}
+	return path, nil
+}
+
+// pruneBackups removes the oldest backups in dir beyond the retain count.
+func pruneBackups(dir string, retain int) error {
+	if retain <= 0 {
+		return nil
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	var backups []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() && strings.HasPrefix(name, backupFilePrefix) && strings.HasSuffix(name, backupFileSuffix) {
+			backups = append(backups, name)
+		}
+	}
+	// Timestamped names sort chronologically
+	sort.Strings(backups)
+
+	for len(backups) > retain {
+		if err := os.Remove(filepath.Join(dir, backups[0])); err != nil {
+			return err
+		}
+		backups = backups[1:]
+	}
+	return nil
+}
+
+// startBackupScheduler periodically backs up the database when both a backup
+// directory and an interval are configured. The scheduler stops when ctx is
+// cancelled; a backup already in progress is finished first.
+func startBackupScheduler(ctx context.Context, wg *sync.WaitGroup, store *Store, config *Config) {
+	if config.BackupDir == "" || config.BackupInterval <= 0 {
+		return
+	}
+
+	slog.Info("Scheduling database backups",
+		"dir", config.BackupDir, "interval", config.BackupInterval, "retain", config.BackupRetain)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(config.BackupInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			// A backup that has started runs to completion on shutdown
+			path, err := createBackup(context.WithoutCancel(ctx), store, config.BackupDir, config.BackupRetain)
+			if err != nil {
+				slog.Error("Scheduled backup failed", "error", err)
+				continue
+			}
+			slog.Info("Database backed up", "path", path)
+		}
+	}()
+}
+
+// BackupResponse reports the file written by an on-demand backup.
+type BackupResponse struct {
+	Path string `json:"path"`
+}
+
+// handleCreateBackup writes an on-demand database backup.
+// CreateBackup godoc
+// @Summary      Back up the database
+// @Description  Write a timestamped database copy into BACKUP_DIR (admin only)
+// @Tags         admin
+// @Produce      json
+// @Success      201  {object}  BackupResponse
+// @Failure      409  {object}  ErrorResponse
+// @Router       /maintenance/backup [post]
+func (s *Server) handleCreateBackup(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+	if s.config.BackupDir == "" {
+		writeErrorJSON(w, "Backups are disabled; set BACKUP_DIR to enable them", http.StatusConflict)
+		return
+	}
+
+	path, err := createBackup(r.Context(), s.store, s.config.BackupDir, s.config.BackupRetain)
+	if err != nil {
+		logRequestError(r, "API CreateBackup error", err)
+		writeErrorJSON(w, "Failed to back up database", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(BackupResponse{Path: path})
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Default portal branding, used when BRAND_* is not configured.
+const (
+	defaultBrandName  = "Go Links"
+	defaultBrandColor = "#0066cc"
+)
+
+// brandColorPattern matches a hex color such as "#0066cc" or "#06c".
+var brandColorPattern = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// BrandData customizes the portal for a deployment. Templates reference it
+// as .Brand.Name, .Brand.Color and .Brand.LogoURL.
+type BrandData struct {
+	Name    string
+	Color   string
+	LogoURL string
+}
+
+// validateBrand checks the configured branding values.
+func validateBrand(brand BrandData) error {
+	if strings.TrimSpace(brand.Name) == "" {
+		return fmt.Errorf("brand name cannot be empty")
+	}
+	if !brandColorPattern.MatchString(brand.Color) {
+		return fmt.Errorf("invalid brand color '%s': must be a hex color like #0066cc", brand.Color)
+	}
+	if brand.LogoURL != "" {
+		u, err := url.Parse(brand.LogoURL)
+		absolute := err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+		local := err == nil && u.Scheme == "" && u.Host == "" && strings.HasPrefix(u.Path, "/")
+		if !absolute && !local {
+			return fmt.Errorf("invalid brand logo URL '%s': must be an http(s) URL or an absolute path", brand.LogoURL)
+		}
+	}
+	return nil
+}
+
+// renderPortal executes a portal template with the configured branding.
+func (s *Server) renderPortal(w io.Writer, name string, data PortalData) error {
+	data.Brand = s.config.Brand
+	return s.executeTemplate(w, name, data)
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Outcomes of a single link in a bulk create. Bulk creates are all or
+// nothing: when one link fails, the links that would have been created are
+// reported as rolled back.
+const (
+	BulkStatusCreated    = "created"
+	BulkStatusInvalid    = "invalid"
+	BulkStatusConflict   = "conflict"
+	BulkStatusRolledBack = "rolled_back"
+)
+
+// BulkLinkResult is the outcome of one link of a bulk create. Index is the
+// position of the link in the request array, starting at 0.
+type BulkLinkResult struct {
+	Index  int    `json:"index"`
+	Path   string `json:"path"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// BulkCreateResponse reports the per-link outcomes of a bulk create.
+type BulkCreateResponse struct {
+	Created int              `json:"created"`
+	Results []BulkLinkResult `json:"results"`
+}
+
+// BulkCreateError is returned by CreateLinksBulk when at least one link could
+// not be inserted. Errors holds one entry per link, nil for the links that
+// were inserted before the transaction was rolled back.
+type BulkCreateError struct {
+	Errors []error
+}
+
+func (e *BulkCreateError) Error() string {
+	failed := 0
+	for _, err := range e.Errors {
+		if err != nil {
+			failed++
+		}
+	}
+	return fmt.Sprintf("%d of %d links could not be created", failed, len(e.Errors))
+}
+
+// CreateLinksBulk inserts links in a single transaction. Every link is tried,
+// each under its own savepoint, so all conflicts are found in one pass; when
+// any link fails the whole transaction is rolled back and a *BulkCreateError
+// describes the failures.
+func (s *Store) CreateLinksBulk(ctx context.Context, links []Link) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	rowErrors := make([]error, len(links))
+	failed := false
+	for i, link := range links {
+		if _, err := tx.ExecContext(ctx, `SAVEPOINT bulk_row`); err != nil {
+			return err
+		}
+		if rowErrors[i] = s.insertLink(tx, link, false); rowErrors[i] != nil {
+			failed = true
+			if _, err := tx.ExecContext(ctx, `ROLLBACK TO bulk_row`); err != nil {
+				return err
+			}
+		}
+		if _, err := tx.ExecContext(ctx, `RELEASE bulk_row`); err != nil {
+			return err
+		}
+	}
+
+	if failed {
+		return &BulkCreateError{Errors: rowErrors}
+	}
+	return tx.Commit()
+}
+
+// handleBulkCreateLinks creates every link of a JSON array or none of them.
+// Links are validated first; if any is invalid nothing is inserted and the
+// response is 422. A duplicate path, in the database or earlier in the array,
+// rolls back the whole batch with 409. Either way the results say which links
+// failed and why.
+// BulkCreateLinks godoc
+// @Summary      Create links in bulk
+// @Description  Create all links of a JSON array in one transaction, or none of them when any link is invalid or its path is taken
+// @Tags         links
+// @Accept       json
+// @Produce      json
+// @Param        force  query  boolean  false  "Allow soft-reserved paths"
+// @Param        links  body   []Link   true   "Links to create"
+// @Success      201  {object}  BulkCreateResponse
+// @Failure      400  {object}  ErrorResponse
+// @Failure      409  {object}  BulkCreateResponse
+// @Failure      422  {object}  BulkCreateResponse
+// @Router       /links/bulk [post]
+func (s *Server) handleBulkCreateLinks(w http.ResponseWriter, r *http.Request) {
+	if !s.allowCreate(w, r) {
+		return
+	}
+	var links []Link
+	if err := json.NewDecoder(r.Body).Decode(&links); err != nil {
+		writeErrorJSON(w, "Invalid request body: expected a JSON array of links", http.StatusBadRequest)
+		return
+	}
+	if len(links) == 0 {
+		writeErrorJSON(w, "At least one link is required", http.StatusBadRequest)
+		return
+	}
+	if len(links) > maxImportRows {
+		writeErrorJSON(w, fmt.Sprintf("At most %d links can be created at once", maxImportRows), http.StatusBadRequest)
+		return
+	}
+
+	response := BulkCreateResponse{Results: make([]BulkLinkResult, len(links))}
+	invalid := false
+	for i := range links {
+		link := &links[i]
+		normalizeLink(link)
+		link.CreatedBy = requestCreator(r)
+		response.Results[i] = BulkLinkResult{Index: i, Path: link.Path}
+
+		err := s.validateLink(r.Context(), *link)
+		if err == nil {
+			if warning := s.softReservedWarning(link.Path); warning != "" && !isForced(r) {
+				err = fmt.Errorf("%s; retry with ?force=true to use it anyway", warning)
+			}
+		}
+		if err == nil {
+			err = s.runCreateHook(r.Context(), *link)
+		}
+		if err != nil {
+			invalid = true
+			response.Results[i].Status = BulkStatusInvalid
+			response.Results[i].Error = err.Error()
+		}
+	}
+	if invalid {
+		for i := range response.Results {
+			if response.Results[i].Status == "" {
+				response.Results[i].Status = BulkStatusRolledBack
+			}
+		}
+		writeBulkResponse(w, http.StatusUnprocessableEntity, response)
+		return
+	}
+
+	err := s.store.CreateLinksBulk(r.Context(), links)
+	var bulkErr *BulkCreateError
+	switch {
+	case err == nil:
+		for i := range response.Results {
+			response.Results[i].Status = BulkStatusCreated
+		}
+		response.Created = len(links)
+		for _, link := range links {
+			s.notifyCreated(r.Context(), link.Path)
+		}
+		writeBulkResponse(w, http.StatusCreated, response)
+	case errors.As(err, &bulkErr):
+		for i, rowErr := range bulkErr.Errors {
+			switch {
+			case rowErr == nil:
+				response.Results[i].Status = BulkStatusRolledBack
+			case strings.Contains(rowErr.Error(), "already exists"):
+				response.Results[i].Status = BulkStatusConflict
+				response.Results[i].Error = rowErr.Error()
+			default:
+				logRequestError(r, "API BulkCreateLinks error", rowErr)
+				writeErrorJSON(w, "Failed to create links", http.StatusInternalServerError)
+				return
+			}
+		}
+		writeBulkResponse(w, http.StatusConflict, response)
+	default:
+		logRequestError(r, "API BulkCreateLinks error", err)
+		writeErrorJSON(w, "Failed to create links", http.StatusInternalServerError)
+	}
+}
+
+// writeBulkResponse writes the outcome of a bulk create with the given status.
+func writeBulkResponse(w http.ResponseWriter, status int, response BulkCreateResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
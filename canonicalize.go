@@ -0,0 +1,66 @@
+package main
+
+import (
+	"strings"
+)
+
+// splitTarget splits an absolute target URL into its lowercased scheme, its
+// userinfo (including the trailing "@"), its lowercased host and port, and
+// the remaining path, query and fragment. ok is false when raw has no scheme.
+func splitTarget(raw string) (scheme, userinfo, host, tail string, ok bool) {
+	schemeEnd := strings.Index(raw, "://")
+	if schemeEnd <= 0 {
+		return "", "", "", "", false
+	}
+	scheme = strings.ToLower(raw[:schemeEnd])
+	rest := raw[schemeEnd+3:]
+
+	// The authority runs up to the first path, query or fragment delimiter
+	authorityEnd := strings.IndexAny(rest, "/?#")
+	if authorityEnd == -1 {
+		authorityEnd = len(rest)
+	}
+	authority, tail := rest[:authorityEnd], rest[authorityEnd:]
+
+	// Keep any userinfo untouched; only the host part is case-insensitive
+	if at := strings.LastIndex(authority, "@"); at != -1 {
+		userinfo, authority = authority[:at+1], authority[at+1:]
+	}
+	return scheme, userinfo, strings.ToLower(authority), tail, true
+}
+
+// lowercaseTargetHost lowercases only the scheme and host of a target URL,
+// leaving the case-sensitive path, query and fragment untouched, so
+// "HTTPS://Example.com/Path" becomes "https://example.com/Path".
+func lowercaseTargetHost(raw string) string {
+	scheme, userinfo, host, tail, ok := splitTarget(raw)
+	if !ok {
+		return raw
+	}
+	return scheme + "://" + userinfo + host + tail
+}
+
+// canonicalizeURL normalizes the authority of a target URL so equivalent
+// targets are stored identically. The scheme and host are lowercased, default
+// ports (:80 for http, :443 for https) are dropped and a bare root path "/" is
+// removed. The path, query and fragment are otherwise preserved exactly.
+func canonicalizeURL(raw string) string {
+	scheme, userinfo, host, tail, ok := splitTarget(raw)
+	if !ok {
+		return raw
+	}
+
+	switch {
+	case scheme == "http" && strings.HasSuffix(host, ":80"):
+		host = strings.TrimSuffix(host, ":80")
+	case scheme == "https" && strings.HasSuffix(host, ":443"):
+		host = strings.TrimSuffix(host, ":443")
+	}
+
+	// Drop the trailing slash of a root path ("/", "/?q", "/#frag")
+	if tail == "/" || strings.HasPrefix(tail, "/?") || strings.HasPrefix(tail, "/#") {
+		tail = tail[1:]
+	}
+
+	return scheme + "://" + userinfo + host + tail
+}
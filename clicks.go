@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// clickQueueSize is how many click events may wait for the database before
+// new ones are dropped.
+const clickQueueSize = 1024
+
+// maxClickHeaderLength caps the stored referrer and User-Agent.
+const maxClickHeaderLength = 512
+
+// ClickEvent is a single redirect through a link, with the request's referrer
+// and User-Agent.
+type ClickEvent struct {
+	ID        int64     `json:"id"`
+	LinkID    int64     `json:"link_id"`
+	Referrer  string    `json:"referrer,omitempty"`
+	UserAgent string    `json:"user_agent,omitempty"`
+	ClickedAt time.Time `json:"clicked_at"`
+}
+
+// RecordClick stores a click event for the given link and, in the same
+// transaction, counts it in the link's clicks and sets its last access time.
+// The counter is the source of truth for click totals; the events keep the
+// timestamps for windowed counts and charts. A busy database is retried.
+func (s *Store) RecordClick(ctx context.Context, linkID int64, referrer, ua string) error {
+	return s.withRetry(ctx, func() error {
+		tx, err := s.db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		insertSQL := `INSERT INTO click_events(link_id, referrer, user_agent, clicked_at) VALUES(?, ?, ?, ` + sqliteNowMilli + `)`
+		if _, err := tx.ExecContext(ctx, insertSQL, linkID, truncate(referrer, maxClickHeaderLength), truncate(ua, maxClickHeaderLength)); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE links SET clicks = clicks + 1, last_accessed_at = `+sqliteNowMilli+` WHERE id = ?`, linkID); err != nil {
+			return err
+		}
+		return tx.Commit()
+	})
+}
+
+// moveVisitsToClickEvents copies the visits recorded before click events
+// existed into click_events, then drops link_visits. Visits of a link from
+// after its first click event are in click_events already.
+func moveVisitsToClickEvents(tx *sql.Tx) error {
+	var exists bool
+	query := `SELECT EXISTS(SELECT 1 FROM sqlite_master WHERE type = 'table' AND name = 'link_visits')`
+	if err := tx.QueryRow(query).Scan(&exists); err != nil || !exists {
+		return err
+	}
+
+	copySQL := `INSERT INTO click_events(link_id, clicked_at)
+		SELECT v.link_id, strftime('%Y-%m-%d %H:%M:%f', v.visited_at) FROM link_visits v
+		WHERE strftime('%Y-%m-%d %H:%M:%f', v.visited_at) < COALESCE(
+			(SELECT MIN(c.clicked_at) FROM click_events c WHERE c.link_id = v.link_id), '9999')`
+	if _, err := tx.Exec(copySQL); err != nil {
+		return err
+	}
+	_, err := tx.Exec(`DROP TABLE link_visits`)
+	return err
+}
+
+// GetClickEvents returns the click events of a link at or after since,
+// oldest first.
+func (s *Store) GetClickEvents(ctx context.Context, linkID int64, since time.Time) ([]ClickEvent, error) {
+	query := `SELECT id, link_id, referrer, user_agent, clicked_at FROM click_events WHERE link_id = ? AND clicked_at >= ? ORDER BY clicked_at, id`
+	rows, err := s.db.QueryContext(ctx, query, linkID, since.UTC().Format(sqliteMilliTimeFormat))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []ClickEvent{}
+	for rows.Next() {
+		var event ClickEvent
+		if err := rows.Scan(&event.ID, &event.LinkID, &event.Referrer, &event.UserAgent, &event.ClickedAt); err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+// truncate shortens s to at most n bytes.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
+
+// clickRecorder writes click events from a buffered queue in the background,
+// so redirects never wait for the database.
+type clickRecorder struct {
+	store  LinkStore
+	events chan ClickEvent
+}
+
+// newClickRecorder returns a recorder with a queue of the given size. Events
+// are only written once start runs the worker.
+func newClickRecorder(store LinkStore, size int) *clickRecorder {
+	return &clickRecorder{store: store, events: make(chan ClickEvent, size)}
+}
+
+// Record queues a click event without blocking. When the queue is full the
+// event is dropped and a warning logged.
+func (c *clickRecorder) Record(event ClickEvent) {
+	select {
+	case c.events <- event:
+	default:
+		slog.Warn("Click event queue full, dropping event", "id", event.LinkID)
+	}
+}
+
+// start runs the worker until ctx is cancelled. Events still queued then are
+// written before the worker reports done, so none are lost on shutdown.
+func (c *clickRecorder) start(ctx context.Context, wg *sync.WaitGroup) {
+	// Writes must not fail just because shutdown has begun
+	writeCtx := context.WithoutCancel(ctx)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case event := <-c.events:
+				c.write(writeCtx, event)
+			case <-ctx.Done():
+				for {
+					select {
+					case event := <-c.events:
+						c.write(writeCtx, event)
+					default:
+						return
+					}
+				}
+			}
+		}
+	}()
+}
+
+// write stores one click event, logging failures.
+func (c *clickRecorder) write(ctx context.Context, event ClickEvent) {
+	if err := c.store.RecordClick(ctx, event.LinkID, event.Referrer, event.UserAgent); err != nil {
+		slog.Error("Error recording click", "id", event.LinkID, "error", err)
+	}
+}
+
+// handleGetClickEvents returns the click events of a link since a timestamp.
+// GetClickEvents godoc
+// @Summary      Link click events
+// @Description  Individual redirects through a link at or after "since", oldest first, with referrer and User-Agent
+// @Tags         stats
+// @Produce      json
+// @Param        id     path   int     true  "Link ID"
+// @Param        since  query  string  true  "RFC 3339 timestamp"
+// @Success      200  {array}   ClickEvent
+// @Failure      400  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Router       /links/{id}/clicks [get]
+func (s *Server) handleGetClickEvents(w http.ResponseWriter, r *http.Request, id int64) {
+	sinceParam := r.URL.Query().Get("since")
+	if sinceParam == "" {
+		writeErrorJSON(w, "since is required", http.StatusBadRequest)
+		return
+	}
+	since, err := time.Parse(time.RFC3339, sinceParam)
+	if err != nil {
+		writeErrorJSON(w, "since must be an RFC 3339 timestamp", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := s.store.GetLinkByID(r.Context(), id); err != nil {
+		if err == sql.ErrNoRows {
+			writeErrorJSON(w, fmt.Sprintf("Link with id %d not found", id), http.StatusNotFound)
+			return
+		}
+		logRequestError(r, "API GetClickEvents error", err, "id", id)
+		writeErrorJSON(w, "Failed to retrieve link", http.StatusInternalServerError)
+		return
+	}
+
+	events, err := s.store.GetClickEvents(r.Context(), id, since)
+	if err != nil {
+		logRequestError(r, "API GetClickEvents error", err, "id", id)
+		writeErrorJSON(w, "Failed to retrieve click events", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(events)
+}
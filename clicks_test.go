@@ -0,0 +1,249 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestClickRecorderFlushesOnShutdown(t *testing.T) {
+	server, handler := newTestServer(t, nil)
+	link := createLink(t, server, handler, Link{Path: "docs", URL: "https://docs.example.com"})
+
+	// Redirect before the worker runs, so every click is still queued when
+	// shutdown begins
+	const redirects = 25
+	for i := 0; i < redirects; i++ {
+		if w := serve(t, handler, http.MethodGet, "/docs", nil); w.Code != http.StatusFound {
+			t.Fatalf("redirect %d: status = %d", i, w.Code)
+		}
+	}
+
+	flushClicks(server)
+
+	events, err := server.store.GetClickEvents(context.Background(), link.ID, time.Time{})
+	if err != nil {
+		t.Fatalf("GetClickEvents: %v", err)
+	}
+	if len(events) != redirects {
+		t.Errorf("%d click events written, want %d", len(events), redirects)
+	}
+	if queued := len(server.clicks.events); queued != 0 {
+		t.Errorf("%d click events left in the queue", queued)
+	}
+}
+
+func TestClickRecorderDropsWhenFull(t *testing.T) {
+	server, _ := newTestServer(t, nil)
+	recorder := newClickRecorder(server.store, 2)
+	for i := 0; i < 5; i++ {
+		recorder.Record(ClickEvent{LinkID: 1})
+	}
+	if queued := len(recorder.events); queued != 2 {
+		t.Errorf("%d click events queued, want 2", queued)
+	}
+}
+
+// flushClicks runs the click worker of server as at shutdown, writing every
+// queued click event before it returns.
+func flushClicks(server *Server) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var workers sync.WaitGroup
+	server.clicks.start(ctx, &workers)
+	workers.Wait()
+}
+
+func TestClickCountsAgree(t *testing.T) {
+	server, handler := newTestServer(t, nil)
+	docs := createLink(t, server, handler, Link{Path: "docs", URL: "https://docs.example.com"})
+	wiki := createLink(t, server, handler, Link{Path: "wiki", URL: "https://wiki.example.com"})
+	unused := createLink(t, server, handler, Link{Path: "unused", URL: "https://unused.example.com"})
+
+	// The preview page is not a click
+	redirects := map[string]int{"/docs": 3, "/wiki": 1, "/docs?preview=1": 1}
+	for path, n := range redirects {
+		for i := 0; i < n; i++ {
+			serve(t, handler, http.MethodGet, path, nil)
+		}
+	}
+	flushClicks(server)
+
+	ctx := context.Background()
+	want := map[int64]int64{docs.ID: 3, wiki.ID: 1, unused.ID: 0}
+	for id, clicks := range want {
+		link, err := server.store.GetLinkByID(ctx, id)
+		if err != nil {
+			t.Fatalf("GetLinkByID(%d): %v", id, err)
+		}
+		if link.Clicks != clicks {
+			t.Errorf("link %s: clicks = %d, want %d", link.Path, link.Clicks, clicks)
+		}
+		events, err := server.store.GetClickEvents(ctx, id, time.Time{})
+		if err != nil {
+			t.Fatalf("GetClickEvents(%d): %v", id, err)
+		}
+		if int64(len(events)) != clicks {
+			t.Errorf("link %s: %d click events, want %d", link.Path, len(events), clicks)
+		}
+	}
+
+	counts, err := server.store.GetVisitCounts(ctx, nil)
+	if err != nil {
+		t.Fatalf("GetVisitCounts: %v", err)
+	}
+	if !reflect.DeepEqual(counts, want) {
+		t.Errorf("GetVisitCounts = %v, want %v", counts, want)
+	}
+
+	top, err := server.store.GetTopLinks(ctx, 5)
+	if err != nil {
+		t.Fatalf("GetTopLinks: %v", err)
+	}
+	if len(top) != 2 || top[0].Path != "docs" || top[0].Visits != 3 || top[1].Path != "wiki" || top[1].Visits != 1 {
+		t.Errorf("GetTopLinks = %+v, want docs (3) then wiki (1)", top)
+	}
+
+	today, err := server.store.CountVisitsSince(ctx, time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("CountVisitsSince: %v", err)
+	}
+	if today != 4 {
+		t.Errorf("CountVisitsSince = %d, want 4", today)
+	}
+
+	neverUsed, err := server.store.GetNeverUsedLinks(ctx, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("GetNeverUsedLinks: %v", err)
+	}
+	if len(neverUsed) != 1 || neverUsed[0].ID != unused.ID {
+		t.Errorf("GetNeverUsedLinks = %v, want only %q", neverUsed, "unused")
+	}
+}
+
+func TestClickSetsLastAccess(t *testing.T) {
+	server, handler := newTestServer(t, nil)
+	link := createLink(t, server, handler, Link{Path: "docs", URL: "https://docs.example.com"})
+	if link.LastAccessedAt != nil {
+		t.Fatalf("new link has last_accessed_at %v", link.LastAccessedAt)
+	}
+
+	before := time.Now().UTC().Add(-time.Second)
+	serve(t, handler, http.MethodGet, "/docs", nil)
+	flushClicks(server)
+
+	reloaded, err := server.store.GetLinkByID(context.Background(), link.ID)
+	if err != nil {
+		t.Fatalf("GetLinkByID: %v", err)
+	}
+	if reloaded.LastAccessedAt == nil || reloaded.LastAccessedAt.Before(before) {
+		t.Errorf("last_accessed_at = %v, want at or after %v", reloaded.LastAccessedAt, before)
+	}
+}
+
+func TestTestRedirectRecordsNoClick(t *testing.T) {
+	server, handler := newTestServer(t, nil)
+	link := createLink(t, server, handler, Link{Path: "docs", URL: "https://docs.example.com"})
+
+	if w := serve(t, handler, http.MethodGet, linkTarget(link.ID)+"/test-redirect", nil); w.Header().Get("Location") != link.URL {
+		t.Fatalf("test-redirect: status = %d, Location = %q", w.Code, w.Header().Get("Location"))
+	}
+	flushClicks(server)
+
+	reloaded, err := server.store.GetLinkByID(context.Background(), link.ID)
+	if err != nil {
+		t.Fatalf("GetLinkByID: %v", err)
+	}
+	if reloaded.Clicks != 0 || reloaded.LastAccessedAt != nil {
+		t.Errorf("clicks = %d, last_accessed_at = %v after a test redirect; want neither", reloaded.Clicks, reloaded.LastAccessedAt)
+	}
+}
+
+func TestPreviewRecordsNoClick(t *testing.T) {
+	tests := []struct {
+		name           string
+		previewDefault bool
+		query          string
+		wantClicks     int64
+	}{
+		{"preview requested", false, "?preview=1", 0},
+		{"preview by default", true, "", 0},
+		{"preview declined", true, "?preview=0", 1},
+		{"plain redirect", false, "", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, handler := newTestServer(t, func(c *Config) { c.PreviewDefault = tt.previewDefault })
+			link := createLink(t, server, handler, Link{Path: "docs", URL: "https://docs.example.com"})
+
+			serve(t, handler, http.MethodGet, "/docs"+tt.query, nil)
+			flushClicks(server)
+
+			reloaded, err := server.store.GetLinkByID(context.Background(), link.ID)
+			if err != nil {
+				t.Fatalf("GetLinkByID: %v", err)
+			}
+			if reloaded.Clicks != tt.wantClicks {
+				t.Errorf("clicks = %d, want %d", reloaded.Clicks, tt.wantClicks)
+			}
+		})
+	}
+}
+
+func TestMigrationMovesVisitsToClickEvents(t *testing.T) {
+	config := testConfig(t)
+	store, err := NewStore(config)
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	ctx := context.Background()
+	if err := store.CreateLink(ctx, Link{Path: "docs", URL: "https://docs.example.com"}); err != nil {
+		t.Fatalf("CreateLink: %v", err)
+	}
+	link, err := store.GetLinkByPath(ctx, "docs")
+	if err != nil {
+		t.Fatalf("GetLinkByPath: %v", err)
+	}
+
+	// Recreate the table as it was before migration 30: two visits from
+	// before click events existed and one that has a click event already
+	setup := []string{
+		`CREATE TABLE link_visits ("id" INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT, "link_id" INTEGER NOT NULL, "visited_at" DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP)`,
+		`INSERT INTO link_visits(link_id, visited_at) VALUES(?, '2024-01-01 10:00:00'), (?, '2024-02-01 10:00:00'), (?, '2024-03-01 10:00:00')`,
+		`INSERT INTO click_events(link_id, clicked_at) VALUES(?, '2024-03-01 10:00:00.000')`,
+		`DELETE FROM schema_migrations WHERE version = 30`,
+	}
+	for _, statement := range setup {
+		args := make([]interface{}, strings.Count(statement, "?"))
+		for i := range args {
+			args[i] = link.ID
+		}
+		if _, err := store.db.Exec(statement, args...); err != nil {
+			t.Fatalf("%s: %v", statement, err)
+		}
+	}
+	store.Close()
+
+	store, err = NewStore(config)
+	if err != nil {
+		t.Fatalf("reopening: %v", err)
+	}
+	defer store.Close()
+
+	events, err := store.GetClickEvents(ctx, link.ID, time.Time{})
+	if err != nil {
+		t.Fatalf("GetClickEvents: %v", err)
+	}
+	if len(events) != 3 {
+		t.Errorf("%d click events after the migration, want 3", len(events))
+	}
+	var exists bool
+	store.db.QueryRow(`SELECT EXISTS(SELECT 1 FROM sqlite_master WHERE name = 'link_visits')`).Scan(&exists)
+	if exists {
+		t.Error("link_visits still exists after the migration")
+	}
+}
@@ -1,11 +1,18 @@
 package main
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
+	"net"
+	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strconv"
+	"strings"
+	"time"
 )
 
 // Config holds all configuration for the application.
@@ -13,15 +20,185 @@ type Config struct {
 	Port   string
 	Host   string
 	DBPath string
+
+	// DBBusyTimeout is how long a write waits for another connection's lock
+	// before failing with "database is locked".
+	DBBusyTimeout time.Duration
+	// DBBusyRetries is how often link writes are retried when the database
+	// is still locked after DBBusyTimeout.
+	DBBusyRetries int
+	// DBJournalMode is the SQLite journal mode: wal or delete.
+	DBJournalMode string
+	// DBMaxOpenConns caps the database connection pool; zero means no limit.
+	DBMaxOpenConns int
+	// DBMaxIdleConns is how many pooled connections are kept open when idle.
+	DBMaxIdleConns int
+
+	// CanonicalizeTargets normalizes the authority of target URLs before storage.
+	CanonicalizeTargets bool
+	// LowercaseTargetHosts lowercases only the scheme and host of target URLs
+	// before storage.
+	LowercaseTargetHosts bool
+
+	// DisallowNumericPaths rejects paths consisting solely of digits.
+	DisallowNumericPaths bool
+
+	// BlockPrivateTargets rejects links whose target host resolves to a
+	// loopback, link-local or private address.
+	BlockPrivateTargets bool
+	// PrivateAllowlist lists hosts and CIDR ranges exempt from
+	// BlockPrivateTargets.
+	PrivateAllowlist []string
+
+	// AllowedDomains restricts link targets to these domains and their
+	// subdomains; empty allows every domain.
+	AllowedDomains []string
+
+	// SoftReserved lists discouraged paths that require an explicit override to use.
+	SoftReserved []string
+	// DefaultTags are applied to new links created without tags.
+	DefaultTags []string
+	// DefaultGroup is applied to new links created without a group.
+	DefaultGroup string
+
+	// TLSCertFile and TLSKeyFile enable HTTPS with the given certificate and
+	// key; the server speaks plain HTTP when they are unset.
+	TLSCertFile string
+	TLSKeyFile  string
+	// TLSMinVersion is the oldest TLS version accepted when TLS is enabled.
+	TLSMinVersion string
+
+	// AdminToken is the bearer token required by admin-only API endpoints.
+	// Admin endpoints are disabled when it is empty.
+	AdminToken string
+	// APIPublicRead leaves API reads open to anyone but requires the admin
+	// token for every API request that modifies data.
+	APIPublicRead bool
+
+	// AuthUser and AuthPass protect the portal, the API and the Swagger UI
+	// with HTTP basic auth; redirects stay public. Unset disables it.
+	AuthUser string
+	AuthPass string
+
+	// UnfurlBots lists User-Agent substrings that receive an Open Graph
+	// preview page instead of a redirect.
+	UnfurlBots []string
+
+	// BackupDir enables database backups into this directory.
+	BackupDir string
+	// BackupInterval schedules automatic backups; zero means on-demand only.
+	BackupInterval time.Duration
+	// BackupRetain is the number of backups kept in BackupDir.
+	BackupRetain int
+
+	// ExpiryPurgeInterval schedules deletion of expired links; zero keeps
+	// them, answering with the expired state.
+	ExpiryPurgeInterval time.Duration
+
+	// LinkCheckInterval schedules requests to every link target to find
+	// broken ones; zero disables checking.
+	LinkCheckInterval time.Duration
+
+	// CreateHookCmd is a shell command run with the link JSON on stdin before
+	// a link is created; a non-zero exit rejects the link.
+	CreateHookCmd string
+	// CreateHookTimeout bounds how long the create hook may run.
+	CreateHookTimeout time.Duration
+
+	// WebhookURLs receive a signed JSON POST for every link created, updated
+	// or deleted.
+	WebhookURLs []string
+	// WebhookSecret is the HMAC key signing webhook payloads; empty sends
+	// them unsigned.
+	WebhookSecret string
+
+	// AuditPageSize and AuditMaxPageSize control pagination of the audit
+	// endpoints.
+	AuditPageSize    int
+	AuditMaxPageSize int
+
+	// ShutdownTimeout bounds how long in-flight requests and background
+	// workers may take to finish on shutdown.
+	ShutdownTimeout time.Duration
+
+	// LogFormat selects the log output: "text" or "json".
+	LogFormat string
+	// LogLevel is the lowest level logged: debug, info, warn or error.
+	LogLevel string
+
+	// CreateRateLimit caps link creation requests per minute per client IP;
+	// zero disables the limit.
+	CreateRateLimit int
+
+	// RedirectStatus is the status code of link redirects: 301, 302, 307 or 308.
+	RedirectStatus int
+
+	// ForwardQuery appends the query string of a go link request to the target.
+	ForwardQuery bool
+
+	// PreviewDefault shows the interstitial preview page instead of
+	// redirecting unless a request asks for ?preview=0.
+	PreviewDefault bool
+
+	// RedirectHeaders are extra response headers added to every redirect.
+	RedirectHeaders map[string]string
+	// DebugHeaders exposes the matched path and target on link redirects.
+	DebugHeaders bool
+
+	// Brand customizes the portal's name, theme color and logo.
+	Brand BrandData
+
+	// CatchAllURL receives unmatched paths; "{path}" is replaced with the path.
+	CatchAllURL string
+
+	// SwaggerEnabled serves the Swagger UI and the OpenAPI spec.
+	SwaggerEnabled bool
+
+	// GzipEnabled compresses responses of at least GzipMinSize bytes for
+	// clients that accept gzip.
+	GzipEnabled bool
+	GzipMinSize int
+
+	// BaseURL is the public URL of this server, used for absolute links such
+	// as those in the Atom feed. Empty derives it from each request.
+	BaseURL string
+
+	// TemplatesDir serves the portal templates from this directory, parsed
+	// again on every render, instead of the copies built into the binary.
+	TemplatesDir string
+
+	// StateResponses configures how expired, deleted and disabled links are answered.
+	StateResponses map[LinkState]StateResponse
 }
 
 // LoadConfig loads configuration from environment variables and command line flags.
 // Priority: command line flags > environment variables > defaults.
 func LoadConfig() (*Config, error) {
 	config := &Config{
-		Port:   "3000",           // Default port
-		Host:   "",               // Default to all interfaces
-		DBPath: "./links.db",     // Default database path
+		Port:   "3000",       // Default port
+		Host:   "",           // Default to all interfaces
+		DBPath: "./links.db", // Default database path
+
+		DBBusyTimeout:     5 * time.Second,
+		DBBusyRetries:     3,
+		DBJournalMode:     journalModeWAL,
+		DBMaxOpenConns:    4,
+		DBMaxIdleConns:    4,
+		TLSMinVersion:     defaultTLSMinVersion,
+		RedirectStatus:    http.StatusFound,
+		ForwardQuery:      true,
+		SwaggerEnabled:    true,
+		GzipEnabled:       true,
+		GzipMinSize:       1024,
+		Brand:             BrandData{Name: defaultBrandName, Color: defaultBrandColor},
+		UnfurlBots:        defaultUnfurlBots,
+		BackupRetain:      7,
+		CreateHookTimeout: 5 * time.Second,
+		AuditPageSize:     50,
+		AuditMaxPageSize:  500,
+		ShutdownTimeout:   10 * time.Second,
+		LogFormat:         logFormatText,
+		LogLevel:          "info",
 	}
 
 	// Load from environment variables first
@@ -34,15 +211,298 @@ func LoadConfig() (*Config, error) {
 	if dbPath := os.Getenv("DB_PATH"); dbPath != "" {
 		config.DBPath = dbPath
 	}
+	if busyTimeout := os.Getenv("DB_BUSY_TIMEOUT"); busyTimeout != "" {
+		value, err := time.ParseDuration(busyTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid DB_BUSY_TIMEOUT '%s': must be a duration like 5s", busyTimeout)
+		}
+		config.DBBusyTimeout = value
+	}
+	if busyRetries := os.Getenv("DB_BUSY_RETRIES"); busyRetries != "" {
+		value, err := strconv.Atoi(busyRetries)
+		if err != nil {
+			return nil, fmt.Errorf("invalid DB_BUSY_RETRIES '%s': must be a number", busyRetries)
+		}
+		config.DBBusyRetries = value
+	}
+	if journalMode := os.Getenv("DB_JOURNAL_MODE"); journalMode != "" {
+		config.DBJournalMode = strings.ToLower(journalMode)
+	}
+	if maxOpen := os.Getenv("DB_MAX_OPEN_CONNS"); maxOpen != "" {
+		value, err := strconv.Atoi(maxOpen)
+		if err != nil {
+			return nil, fmt.Errorf("invalid DB_MAX_OPEN_CONNS '%s': must be a number", maxOpen)
+		}
+		config.DBMaxOpenConns = value
+	}
+	if maxIdle := os.Getenv("DB_MAX_IDLE_CONNS"); maxIdle != "" {
+		value, err := strconv.Atoi(maxIdle)
+		if err != nil {
+			return nil, fmt.Errorf("invalid DB_MAX_IDLE_CONNS '%s': must be a number", maxIdle)
+		}
+		config.DBMaxIdleConns = value
+	}
+	if canonicalize := os.Getenv("CANONICALIZE_TARGETS"); canonicalize != "" {
+		value, err := strconv.ParseBool(canonicalize)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CANONICALIZE_TARGETS '%s': must be a boolean", canonicalize)
+		}
+		config.CanonicalizeTargets = value
+	}
+	if lowercaseHosts := os.Getenv("LOWERCASE_TARGET_HOSTS"); lowercaseHosts != "" {
+		value, err := strconv.ParseBool(lowercaseHosts)
+		if err != nil {
+			return nil, fmt.Errorf("invalid LOWERCASE_TARGET_HOSTS '%s': must be a boolean", lowercaseHosts)
+		}
+		config.LowercaseTargetHosts = value
+	}
+	if disallowNumeric := os.Getenv("DISALLOW_NUMERIC_PATHS"); disallowNumeric != "" {
+		value, err := strconv.ParseBool(disallowNumeric)
+		if err != nil {
+			return nil, fmt.Errorf("invalid DISALLOW_NUMERIC_PATHS '%s': must be a boolean", disallowNumeric)
+		}
+		config.DisallowNumericPaths = value
+	}
+	if blockPrivate := os.Getenv("BLOCK_PRIVATE_TARGETS"); blockPrivate != "" {
+		value, err := strconv.ParseBool(blockPrivate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid BLOCK_PRIVATE_TARGETS '%s': must be a boolean", blockPrivate)
+		}
+		config.BlockPrivateTargets = value
+	}
+	if allowlist := os.Getenv("PRIVATE_TARGET_ALLOWLIST"); allowlist != "" {
+		config.PrivateAllowlist = splitList(allowlist)
+	}
+	if allowedDomains := os.Getenv("ALLOWED_DOMAINS"); allowedDomains != "" {
+		config.AllowedDomains = splitList(allowedDomains)
+	}
+	if softReserved := os.Getenv("SOFT_RESERVED"); softReserved != "" {
+		config.SoftReserved = splitList(softReserved)
+	}
+	if defaultTags := os.Getenv("DEFAULT_TAGS"); defaultTags != "" {
+		config.DefaultTags = normalizeTags(splitList(defaultTags))
+	}
+	if defaultGroup := os.Getenv("DEFAULT_GROUP"); defaultGroup != "" {
+		config.DefaultGroup = normalizeGroup(defaultGroup)
+	}
+	if tlsCertFile := os.Getenv("TLS_CERT_FILE"); tlsCertFile != "" {
+		config.TLSCertFile = tlsCertFile
+	}
+	if tlsKeyFile := os.Getenv("TLS_KEY_FILE"); tlsKeyFile != "" {
+		config.TLSKeyFile = tlsKeyFile
+	}
+	if tlsMinVersion := os.Getenv("TLS_MIN_VERSION"); tlsMinVersion != "" {
+		config.TLSMinVersion = tlsMinVersion
+	}
+	if adminToken := os.Getenv("ADMIN_TOKEN"); adminToken != "" {
+		config.AdminToken = adminToken
+	}
+	if apiPublicRead := os.Getenv("API_PUBLIC_READ"); apiPublicRead != "" {
+		value, err := strconv.ParseBool(apiPublicRead)
+		if err != nil {
+			return nil, fmt.Errorf("invalid API_PUBLIC_READ '%s': must be a boolean", apiPublicRead)
+		}
+		config.APIPublicRead = value
+	}
+	if authUser := os.Getenv("AUTH_USER"); authUser != "" {
+		config.AuthUser = authUser
+	}
+	if authPass := os.Getenv("AUTH_PASS"); authPass != "" {
+		config.AuthPass = authPass
+	}
+	if unfurlBots, ok := os.LookupEnv("UNFURL_BOTS"); ok {
+		config.UnfurlBots = splitList(unfurlBots)
+	}
+	if debugHeaders := os.Getenv("DEBUG_HEADERS"); debugHeaders != "" {
+		value, err := strconv.ParseBool(debugHeaders)
+		if err != nil {
+			return nil, fmt.Errorf("invalid DEBUG_HEADERS '%s': must be a boolean", debugHeaders)
+		}
+		config.DebugHeaders = value
+	}
+	if createRateLimit := os.Getenv("CREATE_RATE_LIMIT"); createRateLimit != "" {
+		value, err := strconv.Atoi(createRateLimit)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CREATE_RATE_LIMIT '%s': must be a number", createRateLimit)
+		}
+		config.CreateRateLimit = value
+	}
+	if redirectStatus := os.Getenv("REDIRECT_STATUS"); redirectStatus != "" {
+		value, err := strconv.Atoi(redirectStatus)
+		if err != nil {
+			return nil, fmt.Errorf("invalid REDIRECT_STATUS '%s': must be a number", redirectStatus)
+		}
+		config.RedirectStatus = value
+	}
+	if forwardQuery := os.Getenv("FORWARD_QUERY"); forwardQuery != "" {
+		value, err := strconv.ParseBool(forwardQuery)
+		if err != nil {
+			return nil, fmt.Errorf("invalid FORWARD_QUERY '%s': must be a boolean", forwardQuery)
+		}
+		config.ForwardQuery = value
+	}
+	if previewDefault := os.Getenv("PREVIEW_DEFAULT"); previewDefault != "" {
+		value, err := strconv.ParseBool(previewDefault)
+		if err != nil {
+			return nil, fmt.Errorf("invalid PREVIEW_DEFAULT '%s': must be a boolean", previewDefault)
+		}
+		config.PreviewDefault = value
+	}
+	if templatesDir := os.Getenv("TEMPLATES_DIR"); templatesDir != "" {
+		config.TemplatesDir = templatesDir
+	}
+	if redirectHeaders := os.Getenv("REDIRECT_HEADERS"); redirectHeaders != "" {
+		if err := json.Unmarshal([]byte(redirectHeaders), &config.RedirectHeaders); err != nil {
+			return nil, fmt.Errorf("invalid REDIRECT_HEADERS: must be a JSON object of header names to values: %v", err)
+		}
+	}
+	if brandName := os.Getenv("BRAND_NAME"); brandName != "" {
+		config.Brand.Name = brandName
+	}
+	if brandColor := os.Getenv("BRAND_COLOR"); brandColor != "" {
+		config.Brand.Color = brandColor
+	}
+	if brandLogoURL := os.Getenv("BRAND_LOGO_URL"); brandLogoURL != "" {
+		config.Brand.LogoURL = brandLogoURL
+	}
+	if catchAllURL := os.Getenv("CATCHALL_URL"); catchAllURL != "" {
+		config.CatchAllURL = catchAllURL
+	}
+	if swaggerEnabled := os.Getenv("SWAGGER_ENABLED"); swaggerEnabled != "" {
+		value, err := strconv.ParseBool(swaggerEnabled)
+		if err != nil {
+			return nil, fmt.Errorf("invalid SWAGGER_ENABLED '%s': must be a boolean", swaggerEnabled)
+		}
+		config.SwaggerEnabled = value
+	}
+	if gzipEnabled := os.Getenv("GZIP_ENABLED"); gzipEnabled != "" {
+		value, err := strconv.ParseBool(gzipEnabled)
+		if err != nil {
+			return nil, fmt.Errorf("invalid GZIP_ENABLED '%s': must be a boolean", gzipEnabled)
+		}
+		config.GzipEnabled = value
+	}
+	if gzipMinSize := os.Getenv("GZIP_MIN_SIZE"); gzipMinSize != "" {
+		value, err := strconv.Atoi(gzipMinSize)
+		if err != nil {
+			return nil, fmt.Errorf("invalid GZIP_MIN_SIZE '%s': must be a number", gzipMinSize)
+		}
+		config.GzipMinSize = value
+	}
+	if baseURL := os.Getenv("BASE_URL"); baseURL != "" {
+		config.BaseURL = strings.TrimSuffix(baseURL, "/")
+	}
+	if backupDir := os.Getenv("BACKUP_DIR"); backupDir != "" {
+		config.BackupDir = backupDir
+	}
+	if backupInterval := os.Getenv("BACKUP_INTERVAL"); backupInterval != "" {
+		value, err := time.ParseDuration(backupInterval)
+		if err != nil {
+			return nil, fmt.Errorf("invalid BACKUP_INTERVAL '%s': must be a duration like 24h", backupInterval)
+		}
+		config.BackupInterval = value
+	}
+	if backupRetain := os.Getenv("BACKUP_RETAIN"); backupRetain != "" {
+		value, err := strconv.Atoi(backupRetain)
+		if err != nil {
+			return nil, fmt.Errorf("invalid BACKUP_RETAIN '%s': must be a number", backupRetain)
+		}
+		config.BackupRetain = value
+	}
+	if expiryPurgeInterval := os.Getenv("EXPIRY_PURGE_INTERVAL"); expiryPurgeInterval != "" {
+		value, err := time.ParseDuration(expiryPurgeInterval)
+		if err != nil {
+			return nil, fmt.Errorf("invalid EXPIRY_PURGE_INTERVAL '%s': must be a duration like 1h", expiryPurgeInterval)
+		}
+		config.ExpiryPurgeInterval = value
+	}
+	if linkCheckInterval := os.Getenv("LINK_CHECK_INTERVAL"); linkCheckInterval != "" {
+		value, err := time.ParseDuration(linkCheckInterval)
+		if err != nil {
+			return nil, fmt.Errorf("invalid LINK_CHECK_INTERVAL '%s': must be a duration like 6h", linkCheckInterval)
+		}
+		config.LinkCheckInterval = value
+	}
+	if createHookCmd := os.Getenv("CREATE_HOOK_CMD"); createHookCmd != "" {
+		config.CreateHookCmd = createHookCmd
+	}
+	if createHookTimeout := os.Getenv("CREATE_HOOK_TIMEOUT"); createHookTimeout != "" {
+		value, err := time.ParseDuration(createHookTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CREATE_HOOK_TIMEOUT '%s': must be a duration like 5s", createHookTimeout)
+		}
+		config.CreateHookTimeout = value
+	}
+	if webhookURLs := os.Getenv("WEBHOOK_URL"); webhookURLs != "" {
+		config.WebhookURLs = splitList(webhookURLs)
+	}
+	if webhookSecret := os.Getenv("WEBHOOK_SECRET"); webhookSecret != "" {
+		config.WebhookSecret = webhookSecret
+	}
+	if auditPageSize := os.Getenv("AUDIT_PAGE_SIZE"); auditPageSize != "" {
+		value, err := strconv.Atoi(auditPageSize)
+		if err != nil {
+			return nil, fmt.Errorf("invalid AUDIT_PAGE_SIZE '%s': must be a number", auditPageSize)
+		}
+		config.AuditPageSize = value
+	}
+	if auditMaxPageSize := os.Getenv("AUDIT_MAX_PAGE_SIZE"); auditMaxPageSize != "" {
+		value, err := strconv.Atoi(auditMaxPageSize)
+		if err != nil {
+			return nil, fmt.Errorf("invalid AUDIT_MAX_PAGE_SIZE '%s': must be a number", auditMaxPageSize)
+		}
+		config.AuditMaxPageSize = value
+	}
+	if shutdownTimeout := os.Getenv("SHUTDOWN_TIMEOUT"); shutdownTimeout != "" {
+		value, err := time.ParseDuration(shutdownTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid SHUTDOWN_TIMEOUT '%s': must be a duration like 10s", shutdownTimeout)
+		}
+		config.ShutdownTimeout = value
+	}
+	if logFormat := os.Getenv("LOG_FORMAT"); logFormat != "" {
+		config.LogFormat = logFormat
+	}
+	if logLevel := os.Getenv("LOG_LEVEL"); logLevel != "" {
+		config.LogLevel = logLevel
+	}
+	stateResponses, err := loadStateResponses()
+	if err != nil {
+		return nil, err
+	}
+	config.StateResponses = stateResponses
 
 	// Define command line flags (these override environment variables)
 	var (
-		portFlag   = flag.String("port", config.Port, "Server port (can also be set via PORT env var)")
+		portFlag   = flag.String("port", config.Port, "Server port, or 'auto' for a free port (can also be set via PORT env var)")
 		pFlag      = flag.String("p", "", "Server port (shorthand)")
 		hostFlag   = flag.String("host", config.Host, "Server host (can also be set via HOST env var)")
 		hFlag      = flag.String("h", "", "Server host (shorthand)")
 		dbPathFlag = flag.String("db-path", config.DBPath, "Database file path (can also be set via DB_PATH env var)")
 		dFlag      = flag.String("d", "", "Database file path (shorthand)")
+		busyFlag   = flag.Duration("db-busy-timeout", config.DBBusyTimeout, "How long writes wait for a locked database (can also be set via DB_BUSY_TIMEOUT env var)")
+		retryFlag  = flag.Int("db-busy-retries", config.DBBusyRetries, "How often link writes are retried while the database is locked (can also be set via DB_BUSY_RETRIES env var)")
+		journFlag  = flag.String("db-journal-mode", config.DBJournalMode, "SQLite journal mode: wal or delete (can also be set via DB_JOURNAL_MODE env var)")
+		openFlag   = flag.Int("db-max-open-conns", config.DBMaxOpenConns, "Maximum open database connections, 0 for no limit (can also be set via DB_MAX_OPEN_CONNS env var)")
+		idleFlag   = flag.Int("db-max-idle-conns", config.DBMaxIdleConns, "Maximum idle database connections (can also be set via DB_MAX_IDLE_CONNS env var)")
+		canonFlag  = flag.Bool("canonicalize-targets", config.CanonicalizeTargets, "Normalize target URL hosts and ports before storage (can also be set via CANONICALIZE_TARGETS env var)")
+		numFlag    = flag.Bool("disallow-numeric-paths", config.DisallowNumericPaths, "Reject paths made only of digits (can also be set via DISALLOW_NUMERIC_PATHS env var)")
+		blockFlag  = flag.Bool("block-private-targets", config.BlockPrivateTargets, "Reject links to loopback, link-local and private addresses (can also be set via BLOCK_PRIVATE_TARGETS env var)")
+		allowFlag  = flag.String("private-target-allowlist", strings.Join(config.PrivateAllowlist, ","), "Comma-separated hosts and CIDRs exempt from --block-private-targets (can also be set via PRIVATE_TARGET_ALLOWLIST env var)")
+		domFlag    = flag.String("allowed-domains", strings.Join(config.AllowedDomains, ","), "Comma-separated domains link targets must belong to, empty for any (can also be set via ALLOWED_DOMAINS env var)")
+		prevFlag   = flag.Bool("preview-default", config.PreviewDefault, "Show a preview page instead of redirecting unless ?preview=0 (can also be set via PREVIEW_DEFAULT env var)")
+		gzipFlag   = flag.Bool("gzip", config.GzipEnabled, "Compress large responses for clients that accept gzip (can also be set via GZIP_ENABLED env var)")
+		gzMinFlag  = flag.Int("gzip-min-size", config.GzipMinSize, "Smallest response body in bytes that is compressed (can also be set via GZIP_MIN_SIZE env var)")
+		tmplFlag   = flag.String("templates-dir", config.TemplatesDir, "Serve templates from this directory, reloaded on every render (can also be set via TEMPLATES_DIR env var)")
+		statusFlag = flag.Int("redirect-status", config.RedirectStatus, "Status code of link redirects: 301, 302, 307 or 308 (can also be set via REDIRECT_STATUS env var)")
+		softFlag   = flag.String("soft-reserved", strings.Join(config.SoftReserved, ","), "Comma-separated discouraged paths that need ?force=true (can also be set via SOFT_RESERVED env var)")
+		limitFlag  = flag.Int("rate-limit", config.CreateRateLimit, "Link creation requests per minute per client IP, 0 for no limit (can also be set via CREATE_RATE_LIMIT env var)")
+		userFlag   = flag.String("auth-user", config.AuthUser, "Basic auth user for the portal and API (can also be set via AUTH_USER env var)")
+		passFlag   = flag.String("auth-pass", "", "Basic auth password for the portal and API (can also be set via AUTH_PASS env var)")
+		hookFlag   = flag.String("webhook-url", strings.Join(config.WebhookURLs, ","), "Comma-separated URLs notified of link changes (can also be set via WEBHOOK_URL env var)")
+		hsecFlag   = flag.String("webhook-secret", "", "HMAC key signing webhook payloads (can also be set via WEBHOOK_SECRET env var)")
+		formatFlag = flag.String("log-format", config.LogFormat, "Log output format: text or json (can also be set via LOG_FORMAT env var)")
+		levelFlag  = flag.String("log-level", config.LogLevel, "Lowest logged level: debug, info, warn or error (can also be set via LOG_LEVEL env var)")
 		helpFlag   = flag.Bool("help", false, "Show help information")
 	)
 
@@ -53,9 +513,62 @@ func LoadConfig() (*Config, error) {
 		fmt.Fprintf(os.Stderr, "Options:\n")
 		flag.PrintDefaults()
 		fmt.Fprintf(os.Stderr, "\nEnvironment Variables:\n")
-		fmt.Fprintf(os.Stderr, "  PORT      Server port (default: 3000)\n")
+		fmt.Fprintf(os.Stderr, "  PORT      Server port, or 'auto'/0 for a free port (default: 3000)\n")
 		fmt.Fprintf(os.Stderr, "  HOST      Server host (default: all interfaces)\n")
 		fmt.Fprintf(os.Stderr, "  DB_PATH   Database file path (default: ./links.db)\n")
+		fmt.Fprintf(os.Stderr, "  DB_BUSY_TIMEOUT       How long writes wait for a locked database (default: 5s)\n")
+		fmt.Fprintf(os.Stderr, "  DB_BUSY_RETRIES       How often link writes are retried while the database is locked (default: 3)\n")
+		fmt.Fprintf(os.Stderr, "  DB_JOURNAL_MODE       SQLite journal mode: wal or delete (default: wal)\n")
+		fmt.Fprintf(os.Stderr, "  DB_MAX_OPEN_CONNS     Maximum open database connections, 0 for no limit (default: 4)\n")
+		fmt.Fprintf(os.Stderr, "  DB_MAX_IDLE_CONNS     Maximum idle database connections (default: 4)\n")
+		fmt.Fprintf(os.Stderr, "  CANONICALIZE_TARGETS  Normalize target URL hosts and ports (default: false)\n")
+		fmt.Fprintf(os.Stderr, "  LOWERCASE_TARGET_HOSTS  Lowercase only the scheme and host of target URLs (default: false)\n")
+		fmt.Fprintf(os.Stderr, "  DISALLOW_NUMERIC_PATHS  Reject paths made only of digits (default: false)\n")
+		fmt.Fprintf(os.Stderr, "  BLOCK_PRIVATE_TARGETS Reject links to loopback, link-local and private addresses (default: false)\n")
+		fmt.Fprintf(os.Stderr, "  PRIVATE_TARGET_ALLOWLIST  Comma-separated hosts and CIDRs exempt from BLOCK_PRIVATE_TARGETS (default: none)\n")
+		fmt.Fprintf(os.Stderr, "  ALLOWED_DOMAINS       Comma-separated domains link targets must belong to (default: any)\n")
+		fmt.Fprintf(os.Stderr, "  SOFT_RESERVED         Comma-separated discouraged paths (default: none)\n")
+		fmt.Fprintf(os.Stderr, "  DEFAULT_TAGS          Comma-separated tags for new links created without tags (default: none)\n")
+		fmt.Fprintf(os.Stderr, "  DEFAULT_GROUP         Group for new links created without one (default: ungrouped)\n")
+		fmt.Fprintf(os.Stderr, "  TLS_CERT_FILE         Certificate file; serves HTTPS together with TLS_KEY_FILE (default: HTTP)\n")
+		fmt.Fprintf(os.Stderr, "  TLS_KEY_FILE          Private key file for TLS_CERT_FILE\n")
+		fmt.Fprintf(os.Stderr, "  TLS_MIN_VERSION       Oldest accepted TLS version: 1.0, 1.1, 1.2 or 1.3 (default: 1.2)\n")
+		fmt.Fprintf(os.Stderr, "  ADMIN_TOKEN           Bearer token for admin API endpoints (default: admin endpoints disabled)\n")
+		fmt.Fprintf(os.Stderr, "  API_PUBLIC_READ       Require ADMIN_TOKEN for API writes while reads stay open (default: false)\n")
+		fmt.Fprintf(os.Stderr, "  AUTH_USER             Basic auth user for the portal, API and Swagger UI (default: no auth)\n")
+		fmt.Fprintf(os.Stderr, "  AUTH_PASS             Basic auth password, required with AUTH_USER\n")
+		fmt.Fprintf(os.Stderr, "  UNFURL_BOTS           Comma-separated User-Agent substrings served a preview (empty disables)\n")
+		fmt.Fprintf(os.Stderr, "  DEBUG_HEADERS         Add X-GoLink-Path and X-GoLink-Target to redirects (default: false)\n")
+		fmt.Fprintf(os.Stderr, "  CREATE_RATE_LIMIT     Link creation requests per minute per client IP (default: 0, unlimited)\n")
+		fmt.Fprintf(os.Stderr, "  REDIRECT_STATUS       Status code of link redirects: 301, 302, 307 or 308 (default: 302)\n")
+		fmt.Fprintf(os.Stderr, "  FORWARD_QUERY         Append the request's query string to link targets (default: true)\n")
+		fmt.Fprintf(os.Stderr, "  PREVIEW_DEFAULT       Show a preview page instead of redirecting unless ?preview=0 (default: false)\n")
+		fmt.Fprintf(os.Stderr, "  REDIRECT_HEADERS      JSON object of extra redirect headers, e.g. {\"Referrer-Policy\":\"no-referrer\"}\n")
+		fmt.Fprintf(os.Stderr, "  BRAND_NAME            Portal name shown in the header and title (default: Go Links)\n")
+		fmt.Fprintf(os.Stderr, "  BRAND_COLOR           Portal theme color as hex (default: #0066cc)\n")
+		fmt.Fprintf(os.Stderr, "  BRAND_LOGO_URL        Logo image shown next to the portal name (default: none)\n")
+		fmt.Fprintf(os.Stderr, "  CATCHALL_URL          Redirect for unmatched paths, {path} is substituted (default: 404)\n")
+		fmt.Fprintf(os.Stderr, "  SWAGGER_ENABLED       Serve the Swagger UI and OpenAPI spec (default: true)\n")
+		fmt.Fprintf(os.Stderr, "  GZIP_ENABLED          Compress large responses for clients that accept gzip (default: true)\n")
+		fmt.Fprintf(os.Stderr, "  GZIP_MIN_SIZE         Smallest response body in bytes that is compressed (default: 1024)\n")
+		fmt.Fprintf(os.Stderr, "  BASE_URL              Public URL of this server, e.g. https://go.example.com (default: from each request)\n")
+		fmt.Fprintf(os.Stderr, "  TEMPLATES_DIR         Serve templates from this directory, reloaded on every render (default: built in)\n")
+		fmt.Fprintf(os.Stderr, "  BACKUP_DIR            Directory for database backups (default: backups disabled)\n")
+		fmt.Fprintf(os.Stderr, "  BACKUP_INTERVAL       Interval between scheduled backups, e.g. 24h (default: on-demand only)\n")
+		fmt.Fprintf(os.Stderr, "  BACKUP_RETAIN         Number of backups to keep (default: 7)\n")
+		fmt.Fprintf(os.Stderr, "  EXPIRY_PURGE_INTERVAL Interval between deletions of expired links, e.g. 1h (default: keep them)\n")
+		fmt.Fprintf(os.Stderr, "  LINK_CHECK_INTERVAL   Interval between checks of every link target, e.g. 6h (default: no checks)\n")
+		fmt.Fprintf(os.Stderr, "  CREATE_HOOK_CMD       Shell command given new links as JSON on stdin; non-zero exit rejects (default: none)\n")
+		fmt.Fprintf(os.Stderr, "  CREATE_HOOK_TIMEOUT   Time allowed for the create hook (default: 5s)\n")
+		fmt.Fprintf(os.Stderr, "  WEBHOOK_URL           Comma-separated URLs notified of link changes (default: none)\n")
+		fmt.Fprintf(os.Stderr, "  WEBHOOK_SECRET        HMAC key signing webhook payloads (default: unsigned)\n")
+		fmt.Fprintf(os.Stderr, "  AUDIT_PAGE_SIZE       Default page size of the audit endpoints (default: 50)\n")
+		fmt.Fprintf(os.Stderr, "  AUDIT_MAX_PAGE_SIZE   Largest page size clients may request (default: 500)\n")
+		fmt.Fprintf(os.Stderr, "  SHUTDOWN_TIMEOUT      Time allowed to drain requests and workers on shutdown (default: 10s)\n")
+		fmt.Fprintf(os.Stderr, "  LOG_FORMAT            Log output format: text or json (default: text)\n")
+		fmt.Fprintf(os.Stderr, "  LOG_LEVEL             Lowest logged level: debug, info, warn or error (default: info)\n")
+		fmt.Fprintf(os.Stderr, "  LINK_STATE_<STATE>_STATUS  Status for expired/deleted/disabled links (default: 410/410/404)\n")
+		fmt.Fprintf(os.Stderr, "  LINK_STATE_<STATE>_URL     Fallback redirect for the state, {path} is substituted (default: none)\n")
 		fmt.Fprintf(os.Stderr, "\nExamples:\n")
 		fmt.Fprintf(os.Stderr, "  %s --port 8080 --db-path /data/links.db\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  PORT=8080 %s\n", os.Args[0])
@@ -89,6 +602,35 @@ func LoadConfig() (*Config, error) {
 	if *dFlag != "" {
 		config.DBPath = *dFlag
 	}
+	config.DBBusyTimeout = *busyFlag
+	config.DBBusyRetries = *retryFlag
+	config.DBJournalMode = strings.ToLower(*journFlag)
+	config.DBMaxOpenConns = *openFlag
+	config.DBMaxIdleConns = *idleFlag
+	config.CanonicalizeTargets = *canonFlag
+	config.DisallowNumericPaths = *numFlag
+	config.BlockPrivateTargets = *blockFlag
+	config.PrivateAllowlist = splitList(*allowFlag)
+	config.AllowedDomains = normalizeDomains(splitList(*domFlag))
+	config.RedirectStatus = *statusFlag
+	config.PreviewDefault = *prevFlag
+	config.GzipEnabled = *gzipFlag
+	config.GzipMinSize = *gzMinFlag
+	config.TemplatesDir = *tmplFlag
+	config.SoftReserved = splitList(*softFlag)
+	config.CreateRateLimit = *limitFlag
+	config.AuthUser = *userFlag
+	// The password flag has no default so the help output never shows it
+	if *passFlag != "" {
+		config.AuthPass = *passFlag
+	}
+	config.WebhookURLs = splitList(*hookFlag)
+	// Like the password, the secret flag has no default
+	if *hsecFlag != "" {
+		config.WebhookSecret = *hsecFlag
+	}
+	config.LogFormat = *formatFlag
+	config.LogLevel = *levelFlag
 
 	// Validate configuration
 	if err := config.Validate(); err != nil {
@@ -100,11 +642,191 @@ func LoadConfig() (*Config, error) {
 
 // Validate checks if the configuration values are valid.
 func (c *Config) Validate() error {
-	// Validate port
-	if port, err := strconv.Atoi(c.Port); err != nil {
-		return fmt.Errorf("invalid port '%s': must be a number", c.Port)
-	} else if port < 1 || port > 65535 {
-		return fmt.Errorf("invalid port %d: must be between 1 and 65535", port)
+	// Validate port ("auto" and 0 let the OS pick a free port)
+	if c.Port != autoPort {
+		if port, err := strconv.Atoi(c.Port); err != nil {
+			return fmt.Errorf("invalid port '%s': must be a number or '%s'", c.Port, autoPort)
+		} else if port < 0 || port > 65535 {
+			return fmt.Errorf("invalid port %d: must be between 0 and 65535", port)
+		}
+	}
+
+	// Validate TLS settings; the minimum version only matters with TLS enabled
+	if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+		return fmt.Errorf("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+	if c.TLSEnabled() {
+		if _, err := parseTLSVersion(c.TLSMinVersion); err != nil {
+			return err
+		}
+	}
+
+	// Public reads protect writes with the admin token, so one must be set
+	if c.APIPublicRead && c.AdminToken == "" {
+		return fmt.Errorf("API_PUBLIC_READ requires ADMIN_TOKEN")
+	}
+
+	// Basic auth needs both a user and a password
+	if (c.AuthUser == "") != (c.AuthPass == "") {
+		return fmt.Errorf("AUTH_USER and AUTH_PASS must be set together")
+	}
+
+	// Validate default tags
+	if err := validateTags(c.DefaultTags); err != nil {
+		return fmt.Errorf("invalid DEFAULT_TAGS: %w", err)
+	}
+	if err := validateGroup(c.DefaultGroup); err != nil {
+		return fmt.Errorf("invalid DEFAULT_GROUP: %w", err)
+	}
+
+	// Validate the creation rate limit
+	if c.CreateRateLimit < 0 {
+		return fmt.Errorf("invalid create rate limit %d: cannot be negative", c.CreateRateLimit)
+	}
+
+	// Validate redirect status
+	switch c.RedirectStatus {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+	default:
+		return fmt.Errorf("invalid redirect status %d: must be 301, 302, 307 or 308", c.RedirectStatus)
+	}
+
+	// Validate redirect headers
+	for name, value := range c.RedirectHeaders {
+		if !headerNamePattern.MatchString(name) {
+			return fmt.Errorf("invalid redirect header name '%s'", name)
+		}
+		if strings.EqualFold(name, "Location") {
+			return fmt.Errorf("redirect header 'Location' cannot be overridden")
+		}
+		if strings.ContainsAny(value, "\r\n") {
+			return fmt.Errorf("invalid value for redirect header '%s': must not contain line breaks", name)
+		}
+	}
+
+	// Validate branding
+	if err := validateBrand(c.Brand); err != nil {
+		return err
+	}
+
+	// Validate catch-all URL
+	if c.CatchAllURL != "" {
+		u, err := url.Parse(c.CatchAllURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid catch-all URL '%s': must be an absolute http(s) URL", c.CatchAllURL)
+		}
+	}
+
+	// Validate base URL
+	if c.BaseURL != "" {
+		u, err := url.Parse(c.BaseURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.RawQuery != "" || u.Fragment != "" {
+			return fmt.Errorf("invalid base URL '%s': must be an absolute http(s) URL without query or fragment", c.BaseURL)
+		}
+	}
+
+	// Validate backup settings
+	if c.BackupInterval < 0 {
+		return fmt.Errorf("invalid backup interval %s: cannot be negative", c.BackupInterval)
+	}
+	if c.BackupInterval > 0 && c.BackupDir == "" {
+		return fmt.Errorf("backup interval requires a backup directory (BACKUP_DIR)")
+	}
+	if c.BackupRetain < 0 {
+		return fmt.Errorf("invalid backup retention %d: cannot be negative", c.BackupRetain)
+	}
+
+	// Validate expiry purge interval
+	if c.ExpiryPurgeInterval < 0 {
+		return fmt.Errorf("invalid expiry purge interval %s: cannot be negative", c.ExpiryPurgeInterval)
+	}
+
+	// Validate link check interval
+	if c.LinkCheckInterval < 0 {
+		return fmt.Errorf("invalid link check interval %s: cannot be negative", c.LinkCheckInterval)
+	}
+
+	// Validate create hook timeout
+	if c.CreateHookTimeout <= 0 {
+		return fmt.Errorf("invalid create hook timeout %s: must be positive", c.CreateHookTimeout)
+	}
+
+	// Validate webhook URLs
+	for _, webhookURL := range c.WebhookURLs {
+		u, err := url.Parse(webhookURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid webhook URL '%s': must be an http(s) URL", webhookURL)
+		}
+	}
+
+	// Validate the compression threshold
+	if c.GzipMinSize < 0 {
+		return fmt.Errorf("invalid gzip min size %d: cannot be negative", c.GzipMinSize)
+	}
+
+	// Validate audit page sizes
+	if c.AuditMaxPageSize < 1 {
+		return fmt.Errorf("invalid audit max page size %d: must be at least 1", c.AuditMaxPageSize)
+	}
+	if c.AuditPageSize < 1 || c.AuditPageSize > c.AuditMaxPageSize {
+		return fmt.Errorf("invalid audit page size %d: must be between 1 and %d", c.AuditPageSize, c.AuditMaxPageSize)
+	}
+
+	// Validate database settings
+	if c.DBBusyTimeout < 0 {
+		return fmt.Errorf("invalid database busy timeout %s: cannot be negative", c.DBBusyTimeout)
+	}
+	if c.DBBusyRetries < 0 {
+		return fmt.Errorf("invalid database busy retries %d: cannot be negative", c.DBBusyRetries)
+	}
+	if c.DBJournalMode != journalModeWAL && c.DBJournalMode != journalModeDelete {
+		return fmt.Errorf("invalid database journal mode '%s': must be '%s' or '%s'", c.DBJournalMode, journalModeWAL, journalModeDelete)
+	}
+	if c.DBMaxOpenConns < 0 {
+		return fmt.Errorf("invalid database max open connections %d: cannot be negative", c.DBMaxOpenConns)
+	}
+	if c.DBMaxIdleConns < 0 {
+		return fmt.Errorf("invalid database max idle connections %d: cannot be negative", c.DBMaxIdleConns)
+	}
+
+	// Validate templates directory
+	if c.TemplatesDir != "" {
+		info, err := os.Stat(c.TemplatesDir)
+		if err != nil {
+			return fmt.Errorf("invalid templates directory '%s': %w", c.TemplatesDir, err)
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("invalid templates directory '%s': not a directory", c.TemplatesDir)
+		}
+	}
+
+	// Validate private target allowlist
+	for _, entry := range c.PrivateAllowlist {
+		if strings.Contains(entry, "/") {
+			if _, _, err := net.ParseCIDR(entry); err != nil {
+				return fmt.Errorf("invalid private target allowlist entry '%s': must be a host or CIDR", entry)
+			}
+		}
+	}
+
+	// Validate allowed domains
+	for _, domain := range c.AllowedDomains {
+		if !allowedDomainPattern.MatchString(domain) {
+			return fmt.Errorf("invalid allowed domain '%s': must be a domain such as example.com", domain)
+		}
+	}
+
+	// Validate shutdown timeout
+	if c.ShutdownTimeout <= 0 {
+		return fmt.Errorf("invalid shutdown timeout %s: must be positive", c.ShutdownTimeout)
+	}
+
+	// Validate logging
+	if c.LogFormat != logFormatText && c.LogFormat != logFormatJSON {
+		return fmt.Errorf("invalid log format '%s': must be '%s' or '%s'", c.LogFormat, logFormatText, logFormatJSON)
+	}
+	if _, err := parseLogLevel(c.LogLevel); err != nil {
+		return err
 	}
 
 	// Validate database path
@@ -125,12 +847,179 @@ func (c *Config) Validate() error {
 	return nil
 }
 
+// headerNamePattern matches valid HTTP header field names (RFC 7230 tokens).
+var headerNamePattern = regexp.MustCompile("^[!#$%&'*+\\-.^_`|~0-9A-Za-z]+$")
+
+// autoPort asks the OS for a free port, like port 0.
+const autoPort = "auto"
+
 // Address returns the full address string for the HTTP server.
 func (c *Config) Address() string {
+	if c.Port == autoPort {
+		return c.Host + ":0"
+	}
 	return c.Host + ":" + c.Port
 }
 
-// String returns a string representation of the configuration.
+// redactedValue replaces secrets in the redacted configuration view.
+const redactedValue = "[REDACTED]"
+
+// RedactedConfig is a view of Config that is safe to log or serve, with
+// secrets replaced by a placeholder.
+type RedactedConfig struct {
+	Port                 string            `json:"port"`
+	Host                 string            `json:"host"`
+	DBPath               string            `json:"db_path"`
+	DBBusyTimeout        string            `json:"db_busy_timeout"`
+	DBBusyRetries        int               `json:"db_busy_retries"`
+	DBJournalMode        string            `json:"db_journal_mode"`
+	DBMaxOpenConns       int               `json:"db_max_open_conns"`
+	DBMaxIdleConns       int               `json:"db_max_idle_conns"`
+	CanonicalizeTargets  bool              `json:"canonicalize_targets"`
+	LowercaseTargetHosts bool              `json:"lowercase_target_hosts"`
+	DisallowNumericPaths bool              `json:"disallow_numeric_paths"`
+	BlockPrivateTargets  bool              `json:"block_private_targets"`
+	PrivateAllowlist     []string          `json:"private_target_allowlist"`
+	AllowedDomains       []string          `json:"allowed_domains"`
+	SoftReserved         []string          `json:"soft_reserved"`
+	DefaultTags          []string          `json:"default_tags"`
+	DefaultGroup         string            `json:"default_group"`
+	TLSCertFile          string            `json:"tls_cert_file"`
+	TLSKeyFile           string            `json:"tls_key_file"`
+	TLSMinVersion        string            `json:"tls_min_version"`
+	AdminToken           string            `json:"admin_token"`
+	APIPublicRead        bool              `json:"api_public_read"`
+	AuthUser             string            `json:"auth_user"`
+	AuthPass             string            `json:"auth_pass"`
+	UnfurlBots           []string          `json:"unfurl_bots"`
+	CreateRateLimit      int               `json:"create_rate_limit"`
+	RedirectStatus       int               `json:"redirect_status"`
+	ForwardQuery         bool              `json:"forward_query"`
+	PreviewDefault       bool              `json:"preview_default"`
+	RedirectHeaders      map[string]string `json:"redirect_headers"`
+	DebugHeaders         bool              `json:"debug_headers"`
+	BrandName            string            `json:"brand_name"`
+	BrandColor           string            `json:"brand_color"`
+	BrandLogoURL         string            `json:"brand_logo_url"`
+	CatchAllURL          string            `json:"catchall_url"`
+	SwaggerEnabled       bool              `json:"swagger_enabled"`
+	GzipEnabled          bool              `json:"gzip_enabled"`
+	GzipMinSize          int               `json:"gzip_min_size"`
+	BaseURL              string            `json:"base_url"`
+	TemplatesDir         string            `json:"templates_dir"`
+	BackupDir            string            `json:"backup_dir"`
+	BackupInterval       string            `json:"backup_interval"`
+	BackupRetain         int               `json:"backup_retain"`
+	ExpiryPurgeInterval  string            `json:"expiry_purge_interval"`
+	LinkCheckInterval    string            `json:"link_check_interval"`
+	CreateHookCmd        string            `json:"create_hook_cmd"`
+	CreateHookTimeout    string            `json:"create_hook_timeout"`
+	WebhookURLs          []string          `json:"webhook_urls"`
+	WebhookSecret        string            `json:"webhook_secret"`
+	AuditPageSize        int               `json:"audit_page_size"`
+	AuditMaxPageSize     int               `json:"audit_max_page_size"`
+	ShutdownTimeout      string            `json:"shutdown_timeout"`
+	LogFormat            string            `json:"log_format"`
+	LogLevel             string            `json:"log_level"`
+
+	StateResponses map[LinkState]StateResponse `json:"state_responses"`
+}
+
+// Redacted returns the configuration with sensitive fields redacted.
+func (c *Config) Redacted() RedactedConfig {
+	return RedactedConfig{
+		Port:                 c.Port,
+		Host:                 c.Host,
+		DBPath:               c.DBPath,
+		DBBusyTimeout:        c.DBBusyTimeout.String(),
+		DBBusyRetries:        c.DBBusyRetries,
+		DBJournalMode:        c.DBJournalMode,
+		DBMaxOpenConns:       c.DBMaxOpenConns,
+		DBMaxIdleConns:       c.DBMaxIdleConns,
+		CanonicalizeTargets:  c.CanonicalizeTargets,
+		LowercaseTargetHosts: c.LowercaseTargetHosts,
+		DisallowNumericPaths: c.DisallowNumericPaths,
+		BlockPrivateTargets:  c.BlockPrivateTargets,
+		PrivateAllowlist:     append([]string{}, c.PrivateAllowlist...),
+		AllowedDomains:       append([]string{}, c.AllowedDomains...),
+		SoftReserved:         append([]string{}, c.SoftReserved...),
+		DefaultTags:          append([]string{}, c.DefaultTags...),
+		DefaultGroup:         c.DefaultGroup,
+		TLSCertFile:          c.TLSCertFile,
+		TLSKeyFile:           c.TLSKeyFile,
+		TLSMinVersion:        c.TLSMinVersion,
+		AdminToken:           redact(c.AdminToken),
+		APIPublicRead:        c.APIPublicRead,
+		AuthUser:             redact(c.AuthUser),
+		AuthPass:             redact(c.AuthPass),
+		UnfurlBots:           append([]string{}, c.UnfurlBots...),
+		CreateRateLimit:      c.CreateRateLimit,
+		RedirectStatus:       c.RedirectStatus,
+		ForwardQuery:         c.ForwardQuery,
+		PreviewDefault:       c.PreviewDefault,
+		RedirectHeaders:      c.RedirectHeaders,
+		DebugHeaders:         c.DebugHeaders,
+		BrandName:            c.Brand.Name,
+		BrandColor:           c.Brand.Color,
+		BrandLogoURL:         c.Brand.LogoURL,
+		CatchAllURL:          c.CatchAllURL,
+		SwaggerEnabled:       c.SwaggerEnabled,
+		GzipEnabled:          c.GzipEnabled,
+		GzipMinSize:          c.GzipMinSize,
+		BaseURL:              c.BaseURL,
+		TemplatesDir:         c.TemplatesDir,
+		BackupDir:            c.BackupDir,
+		BackupInterval:       c.BackupInterval.String(),
+		BackupRetain:         c.BackupRetain,
+		ExpiryPurgeInterval:  c.ExpiryPurgeInterval.String(),
+		LinkCheckInterval:    c.LinkCheckInterval.String(),
+		CreateHookCmd:        redact(c.CreateHookCmd),
+		CreateHookTimeout:    c.CreateHookTimeout.String(),
+		WebhookURLs:          redactAll(c.WebhookURLs),
+		WebhookSecret:        redact(c.WebhookSecret),
+		AuditPageSize:        c.AuditPageSize,
+		AuditMaxPageSize:     c.AuditMaxPageSize,
+		ShutdownTimeout:      c.ShutdownTimeout.String(),
+		LogFormat:            c.LogFormat,
+		LogLevel:             c.LogLevel,
+		StateResponses:       c.StateResponses,
+	}
+}
+
+// redact hides a secret value, keeping unset values empty so it stays
+// visible whether the secret is configured.
+func redact(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	return redactedValue
+}
+
+// redactAll redacts each of a list of secrets, keeping their count.
+func redactAll(secrets []string) []string {
+	redacted := []string{}
+	for _, secret := range secrets {
+		redacted = append(redacted, redact(secret))
+	}
+	return redacted
+}
+
+// String returns the redacted configuration as JSON.
 func (c *Config) String() string {
-	return fmt.Sprintf("Config{Port: %s, Host: %s, DBPath: %s}", c.Port, c.Host, c.DBPath)
+	data, err := json.Marshal(c.Redacted())
+	if err != nil {
+		return fmt.Sprintf("Config{Port: %s, Host: %s, DBPath: %s}", c.Port, c.Host, c.DBPath)
+	}
+	return string(data)
+}
+
+// splitList parses a comma-separated list, dropping empty entries.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
 }
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestConfigRedacted(t *testing.T) {
+	config := testConfig(t)
+	config.AdminToken = "admin-secret"
+	config.AuthUser = "alice"
+	config.AuthPass = "hunter2"
+
+	redacted := config.Redacted()
+	secrets := map[string]string{
+		"admin_token": redacted.AdminToken,
+		"auth_user":   redacted.AuthUser,
+		"auth_pass":   redacted.AuthPass,
+	}
+	for name, value := range secrets {
+		if value != redactedValue {
+			t.Errorf("%s = %q, want %q", name, value, redactedValue)
+		}
+	}
+	if redacted.Port != config.Port || redacted.DBPath != config.DBPath {
+		t.Errorf("non-secret settings changed: port %q, db path %q", redacted.Port, redacted.DBPath)
+	}
+
+	// Unset secrets stay empty, so the view shows they are not configured
+	empty := testConfig(t).Redacted()
+	if empty.AdminToken != "" || empty.AuthUser != "" || empty.AuthPass != "" {
+		t.Errorf("unset secrets = %q %q %q, want empty", empty.AdminToken, empty.AuthUser, empty.AuthPass)
+	}
+
+	output := config.String()
+	for _, secret := range []string{"admin-secret", "alice", "hunter2"} {
+		if strings.Contains(output, secret) {
+			t.Errorf("String() contains %q", secret)
+		}
+	}
+}
+
+func TestConfigEndpointRedactsSecrets(t *testing.T) {
+	_, handler := newTestServer(t, func(c *Config) {
+		c.AdminToken = "admin-secret"
+		c.AuthUser = "alice"
+		c.AuthPass = "hunter2"
+	})
+	w := serveAs(t, handler, http.MethodGet, "/api/config", "", credentials{bearer: "admin-secret"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	for _, secret := range []string{"admin-secret", "alice", "hunter2"} {
+		if strings.Contains(w.Body.String(), secret) {
+			t.Errorf("GET /api/config exposes %q", secret)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// dashboardCacheTTL controls how long a computed dashboard snapshot is reused.
+const dashboardCacheTTL = 30 * time.Second
+
+// DashboardStats is a compact summary of operational numbers, suitable for a
+// Grafana JSON datasource or a status page.
+type DashboardStats struct {
+	TotalLinks     int64            `json:"total_links"`
+	RedirectsToday int64            `json:"redirects_today"`
+	RedirectsWeek  int64            `json:"redirects_week"`
+	TopLinks       []LinkVisitCount `json:"top_links"`
+	BrokenLinks    int64            `json:"broken_links"` // Failed their latest check; always 0 without LINK_CHECK_INTERVAL
+	GeneratedAt    time.Time        `json:"generated_at"`
+}
+
+// dashboardCache holds the most recently computed dashboard snapshot.
+type dashboardCache struct {
+	mu      sync.Mutex
+	stats   *DashboardStats
+	expires time.Time
+}
+
+// dashboardStats returns the cached dashboard snapshot, recomputing it once expired.
+func (s *Server) dashboardStats(ctx context.Context) (*DashboardStats, error) {
+	s.dashboard.mu.Lock()
+	defer s.dashboard.mu.Unlock()
+
+	now := time.Now()
+	if s.dashboard.stats != nil && now.Before(s.dashboard.expires) {
+		return s.dashboard.stats, nil
+	}
+
+	totalLinks, err := s.store.CountLinks(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	startOfDay := now.UTC().Truncate(24 * time.Hour)
+	redirectsToday, err := s.store.CountVisitsSince(ctx, startOfDay)
+	if err != nil {
+		return nil, err
+	}
+	redirectsWeek, err := s.store.CountVisitsSince(ctx, now.Add(-7*24*time.Hour))
+	if err != nil {
+		return nil, err
+	}
+
+	topLinks, err := s.store.GetTopLinks(ctx, 5)
+	if err != nil {
+		return nil, err
+	}
+
+	brokenLinks, err := s.store.CountBrokenLinks(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s.dashboard.stats = &DashboardStats{
+		TotalLinks:     totalLinks,
+		RedirectsToday: redirectsToday,
+		RedirectsWeek:  redirectsWeek,
+		TopLinks:       topLinks,
+		BrokenLinks:    brokenLinks,
+		GeneratedAt:    now.UTC(),
+	}
+	s.dashboard.expires = now.Add(dashboardCacheTTL)
+	return s.dashboard.stats, nil
+}
+
+// handleGetDashboard returns the aggregated dashboard statistics as JSON.
+// GetDashboard godoc
+// @Summary      Dashboard statistics
+// @Description  Aggregated link and redirect numbers for status dashboards
+// @Tags         stats
+// @Produce      json
+// @Success      200  {object}  DashboardStats
+// @Router       /dashboard [get]
+func (s *Server) handleGetDashboard(w http.ResponseWriter, r *http.Request) {
+	stats, err := s.dashboardStats(r.Context())
+	if err != nil {
+		logRequestError(r, "API GetDashboard error", err)
+		writeErrorJSON(w, "Failed to compute dashboard", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	// The API may require authentication, so shared caches must not keep it
+	w.Header().Set("Cache-Control", "private, max-age=30")
+	json.NewEncoder(w).Encode(stats)
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestDashboard(t *testing.T) {
+	server, handler := newTestServer(t, nil)
+	createLink(t, server, handler, Link{Path: "docs", URL: "https://docs.example.com"})
+	serve(t, handler, http.MethodGet, "/docs", nil)
+	flushClicks(server)
+
+	w := serve(t, handler, http.MethodGet, "/api/dashboard", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	if got := w.Header().Get("Cache-Control"); got != "private, max-age=30" {
+		t.Errorf("Cache-Control = %q, want %q", got, "private, max-age=30")
+	}
+	var stats DashboardStats
+	if err := json.Unmarshal(w.Body.Bytes(), &stats); err != nil {
+		t.Fatalf("decoding dashboard: %v", err)
+	}
+	if stats.TotalLinks != 1 || stats.RedirectsToday != 1 || len(stats.TopLinks) != 1 {
+		t.Errorf("dashboard = %+v, want 1 link with 1 redirect today", stats)
+	}
+}
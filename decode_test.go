@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestDecodeJSONStrict(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		wantErr string
+	}{
+		{"valid", `{"path":"docs","url":"https://docs.example.com"}`, ""},
+		{"surrounding whitespace", " \n{\"path\":\"docs\"}\n ", ""},
+		{"empty", ``, "Invalid request body: empty body"},
+		{"truncated", `{"path":"docs"`, "Invalid request body: unexpected end of JSON"},
+		{"malformed", `{"path":docs}`, "Invalid request body: malformed JSON at byte 9"},
+		{"unknown field", `{"path":"docs","urls":"https://docs.example.com"}`, `Invalid request body: unknown field "urls"`},
+		{"wrong type", `{"path":"docs","rate_limit":"ten"}`, `Invalid request body: field "rate_limit" must be an integer`},
+		{"trailing data", `{"path":"docs"} {"path":"wiki"}`, "Invalid request body: unexpected data after the JSON object"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var link Link
+			err := decodeJSONStrict(strings.NewReader(tt.body), &link)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("decodeJSONStrict(%q) = %v", tt.body, err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("decodeJSONStrict(%q) = %v, want %q", tt.body, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestStrictDecodingEndpoints(t *testing.T) {
+	server, handler := newTestServer(t, nil)
+	link := createLink(t, server, handler, Link{Path: "docs", URL: "https://docs.example.com"})
+	body := `{"path":"docs","url":"https://docs.example.com","urls":"typo"}`
+
+	tests := []struct {
+		method, target string
+	}{
+		{http.MethodPost, "/api/links"},
+		{http.MethodPut, linkTarget(link.ID)},
+		{http.MethodPost, linkTarget(link.ID) + "/aliases"},
+	}
+	for _, tt := range tests {
+		w := serveAs(t, handler, tt.method, tt.target, body, credentials{})
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s %s = %d, want %d: %s", tt.method, tt.target, w.Code, http.StatusBadRequest, w.Body.String())
+			continue
+		}
+		var response ErrorResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("decoding error response: %v", err)
+		}
+		if message := response.Error + " " + response.Message; !strings.Contains(message, "unknown field") {
+			t.Errorf("%s %s error = %q, want it to name the unknown field", tt.method, tt.target, message)
+		}
+	}
+
+	// Nothing was changed by the rejected update
+	stored, _ := server.store.GetLinkByID(t.Context(), link.ID)
+	if stored.URL != link.URL || len(stored.Aliases) != 0 {
+		t.Errorf("link changed by rejected requests: %+v", stored)
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// DomainGroup holds the links pointing at one target host.
+type DomainGroup struct {
+	Host  string `json:"host"`
+	Count int    `json:"count"`
+	Links []Link `json:"links"`
+}
+
+// targetHost returns the lowercased host of a target URL, without port, or
+// an empty string when the URL cannot be parsed.
+func targetHost(target string) string {
+	u, err := url.Parse(strings.TrimSpace(target))
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
+
+// backfillHosts populates the host column of links stored before it existed.
+func backfillHosts(db schemaExecer) error {
+	rows, err := db.Query(`SELECT id, url FROM links WHERE host = ''`)
+	if err != nil {
+		return err
+	}
+	hosts := make(map[int64]string)
+	for rows.Next() {
+		var id int64
+		var target string
+		if err := rows.Scan(&id, &target); err != nil {
+			rows.Close()
+			return err
+		}
+		if host := targetHost(target); host != "" {
+			hosts[id] = host
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for id, host := range hosts {
+		if _, err := db.Exec(`UPDATE links SET host = ? WHERE id = ?`, host, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetLinksByDomain groups links by target host, largest groups first. Links
+// within a group are ordered by path.
+func (s *Store) GetLinksByDomain(ctx context.Context) ([]DomainGroup, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT host, "+linkColumns+" FROM links WHERE host != '' ORDER BY host, path")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	groups := []DomainGroup{}
+	for rows.Next() {
+		var host string
+		link, err := scanLink(prefixScanner{rows, []interface{}{&host}})
+		if err != nil {
+			return nil, err
+		}
+		if len(groups) == 0 || groups[len(groups)-1].Host != host {
+			groups = append(groups, DomainGroup{Host: host})
+		}
+		group := &groups[len(groups)-1]
+		group.Links = append(group.Links, link)
+		group.Count++
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		return groups[i].Count > groups[j].Count
+	})
+	return groups, nil
+}
+
+// handleGetLinksByDomain returns links grouped by target host.
+// GetLinksByDomain godoc
+// @Summary      Links by target domain
+// @Description  Links grouped by the host they point at, largest groups first
+// @Tags         links
+// @Produce      json
+// @Success      200  {array}  DomainGroup
+// @Router       /links/by-domain [get]
+func (s *Server) handleGetLinksByDomain(w http.ResponseWriter, r *http.Request) {
+	groups, err := s.store.GetLinksByDomain(r.Context())
+	if err != nil {
+		logRequestError(r, "API GetLinksByDomain error", err)
+		writeErrorJSON(w, "Failed to retrieve links by domain", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(groups)
+}
@@ -0,0 +1,298 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ExpiryFilter selects the links affected by a bulk expiry change. Every set
+// criterion must match; at least one is required.
+type ExpiryFilter struct {
+	Tag       string `json:"tag,omitempty"`
+	Owner     string `json:"owner,omitempty"`
+	OlderThan string `json:"older_than,omitempty"` // e.g. "90d" or "720h"
+}
+
+// SetExpiryRequest is the payload of the bulk expiry endpoint. ExpiresAt is
+// required; null clears the expiration of the matching links.
+type SetExpiryRequest struct {
+	Filter    ExpiryFilter    `json:"filter"`
+	ExpiresAt json.RawMessage `json:"expires_at"`
+}
+
+// SetExpiryResponse reports how many links had their expiration changed.
+type SetExpiryResponse struct {
+	Updated int `json:"updated"`
+}
+
+// isExpired reports whether a link has passed its expiration time.
+func (l Link) isExpired(now time.Time) bool {
+	return l.ExpiresAt != nil && !now.Before(*l.ExpiresAt)
+}
+
+// expiryInputLayout is the format of the portal's datetime-local expiry
+// field, interpreted in the server's time zone.
+const expiryInputLayout = "2006-01-02T15:04"
+
+// ExpiresAtInput formats the expiration for the portal form's expiry field.
+func (l Link) ExpiresAtInput() string {
+	if l.ExpiresAt == nil {
+		return ""
+	}
+	return l.ExpiresAt.Local().Format(expiryInputLayout)
+}
+
+// parseExpiry reads an expiration from a form value, either RFC 3339 or the
+// datetime-local format. An empty value means the link never expires.
+func parseExpiry(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	expiresAt, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		expiresAt, err = time.ParseInLocation(expiryInputLayout, value, time.Local)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("expiration must be a date and time such as 2024-12-31T18:00")
+	}
+	return &expiresAt, nil
+}
+
+// sameExpiry reports whether two expirations are equal at the millisecond
+// precision they are stored with.
+func sameExpiry(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Truncate(time.Millisecond).Equal(b.Truncate(time.Millisecond))
+}
+
+// DeleteExpiredLinks deletes every link whose expiration has passed, leaving
+// tombstones as DeleteLink does, and returns the number of links deleted.
+func (s *Store) DeleteExpiredLinks(ctx context.Context) (int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `SELECT id FROM links WHERE expires_at IS NOT NULL AND expires_at <= ?`, time.Now().UTC().Format(sqliteMilliTimeFormat))
+	if err != nil {
+		return 0, err
+	}
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, id := range ids {
+		if err := deleteLinkTx(tx, id); err != nil {
+			return 0, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return len(ids), nil
+}
+
+// startExpiryPurge periodically deletes expired links when an interval is
+// configured. Until then expired links stay in the database and answer with
+// the expired state. The purge stops when ctx is cancelled.
+func startExpiryPurge(ctx context.Context, wg *sync.WaitGroup, store *Store, config *Config) {
+	if config.ExpiryPurgeInterval <= 0 {
+		return
+	}
+
+	slog.Info("Deleting expired links on a schedule", "interval", config.ExpiryPurgeInterval)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(config.ExpiryPurgeInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			deleted, err := store.DeleteExpiredLinks(ctx)
+			if err != nil {
+				slog.Error("Expired link purge failed", "error", err)
+				continue
+			}
+			if deleted > 0 {
+				slog.Info("Deleted expired links", "count", deleted)
+			}
+		}
+	}()
+}
+
+// SetExpiryByFilter sets (or, with a nil expiresAt, clears) the expiration of
+// every link created before createdBefore (when non-zero) that matches the
+// filter's tag and owner, recording an audit entry per changed link. Links
+// whose expiration already has the requested value are left untouched. It
+// returns the number of links changed.
+func (s *Store) SetExpiryByFilter(ctx context.Context, filter ExpiryFilter, createdBefore time.Time, expiresAt *time.Time) (int, error) {
+	var conditions []string
+	var args []interface{}
+	if filter.Tag != "" {
+		conditions = append(conditions, `EXISTS (SELECT 1 FROM link_tags t WHERE t.link_id = links.id AND t.tag = ?)`)
+		args = append(args, filter.Tag)
+	}
+	if filter.Owner != "" {
+		conditions = append(conditions, `owner = ?`)
+		args = append(args, filter.Owner)
+	}
+	if !createdBefore.IsZero() {
+		conditions = append(conditions, `created_at <= ?`)
+		args = append(args, createdBefore.UTC().Format(sqliteMilliTimeFormat))
+	}
+	if len(conditions) == 0 {
+		return 0, fmt.Errorf("at least one filter criterion is required")
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	query := `SELECT id, expires_at FROM links WHERE ` + strings.Join(conditions, " AND ") + ` ORDER BY id`
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	priors := make(map[int64]*time.Time)
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		var prior sql.NullTime
+		if err := rows.Scan(&id, &prior); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		if prior.Valid {
+			priors[id] = &prior.Time
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	value := sqliteTime(expiresAt)
+	updateSQL := `UPDATE links SET expires_at = ?, updated_at = ` + sqliteNowMilli + ` WHERE id = ?`
+	updated := 0
+	for _, id := range ids {
+		prior := priors[id]
+		if sameExpiry(prior, expiresAt) {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, updateSQL, value, id); err != nil {
+			return 0, err
+		}
+		changes := map[string]FieldChange{"expires_at": {Old: prior, New: expiresAt}}
+		if err := insertAuditEntry(tx, id, AuditActionExpiry, changes); err != nil {
+			return 0, err
+		}
+		updated++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return updated, nil
+}
+
+// handleSetExpiry sets or clears the expiration of many links at once.
+// Unknown filter fields are rejected so a typo cannot widen the selection.
+// SetExpiry godoc
+// @Summary      Bulk set link expiration
+// @Description  Set expires_at (RFC 3339, or null to clear) on links matching a tag, owner and/or older_than filter (admin only)
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        expiry  body      SetExpiryRequest  true  "Filter and expiration"
+// @Success      200  {object}  SetExpiryResponse
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Router       /links/set-expiry [post]
+func (s *Server) handleSetExpiry(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+
+	var req SetExpiryRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		writeErrorJSON(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	if len(req.ExpiresAt) == 0 {
+		writeErrorJSON(w, "expires_at is required; use null to clear expiration", http.StatusBadRequest)
+		return
+	}
+	var expiresAt *time.Time
+	if !bytes.Equal(req.ExpiresAt, []byte("null")) {
+		var value time.Time
+		if err := json.Unmarshal(req.ExpiresAt, &value); err != nil {
+			writeErrorJSON(w, "expires_at must be an RFC 3339 timestamp or null", http.StatusBadRequest)
+			return
+		}
+		expiresAt = &value
+	}
+
+	filter := req.Filter
+	filter.Tag = strings.ToLower(strings.TrimSpace(filter.Tag))
+	filter.Owner = strings.TrimSpace(filter.Owner)
+	if filter.Tag == "" && filter.Owner == "" && filter.OlderThan == "" {
+		writeErrorJSON(w, "filter needs at least one of tag, owner or older_than", http.StatusBadRequest)
+		return
+	}
+	if filter.Tag != "" {
+		if err := validateTag(filter.Tag); err != nil {
+			writeErrorJSON(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	var createdBefore time.Time
+	if filter.OlderThan != "" {
+		age, err := parseAge(filter.OlderThan)
+		if err != nil {
+			writeErrorJSON(w, fmt.Sprintf("Invalid 'older_than' value '%s': use a duration such as 90d or 720h", filter.OlderThan), http.StatusBadRequest)
+			return
+		}
+		createdBefore = time.Now().UTC().Add(-age)
+	}
+
+	updated, err := s.store.SetExpiryByFilter(r.Context(), filter, createdBefore, expiresAt)
+	if err != nil {
+		logRequestError(r, "API SetExpiry error", err)
+		writeErrorJSON(w, "Failed to set expiration", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(SetExpiryResponse{Updated: updated})
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// feedEntries is the number of most recently created links in the feed.
+const feedEntries = 50
+
+// atomFeed is an Atom (RFC 4287) feed of links.
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Author  atomAuthor  `xml:"author"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+// atomLink is an Atom link element.
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+}
+
+// atomEntry is one link in the feed.
+type atomEntry struct {
+	Title     string      `xml:"title"`
+	ID        string      `xml:"id"`
+	Link      atomLink    `xml:"link"`
+	Published string      `xml:"published"`
+	Updated   string      `xml:"updated"`
+	Author    *atomAuthor `xml:"author,omitempty"`
+	Summary   string      `xml:"summary"`
+}
+
+// atomAuthor names the owner of a link.
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+// GetRecentLinks returns the most recently created links, newest first.
+func (s *Store) GetRecentLinks(ctx context.Context, limit int) ([]Link, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT "+linkColumns+" FROM links ORDER BY created_at DESC, id DESC LIMIT ?", limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var links []Link
+	for rows.Next() {
+		link, err := scanLink(rows)
+		if err != nil {
+			return nil, err
+		}
+		links = append(links, link)
+	}
+	return links, rows.Err()
+}
+
+// baseURL returns the configured BASE_URL, or the scheme and host the
+// request was made to.
+func (s *Server) baseURL(r *http.Request) string {
+	if s.config.BaseURL != "" {
+		return s.config.BaseURL
+	}
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
+
+// buildFeed assembles the Atom feed for links, newest first. Entry IDs use
+// the link ID so they stay stable when a link is renamed. Entries without an
+// owner fall back to the feed author, named after the deployment.
+func buildFeed(name, base string, links []Link, now time.Time) atomFeed {
+	feed := atomFeed{
+		Title:   name + ": new links",
+		ID:      base + "/api/links/feed.xml",
+		Updated: now.UTC().Format(time.RFC3339),
+		Author:  atomAuthor{Name: name},
+		Links: []atomLink{
+			{Href: base + "/api/links/feed.xml", Rel: "self"},
+			{Href: base + "/go", Rel: "alternate"},
+		},
+		Entries: []atomEntry{},
+	}
+	if len(links) > 0 {
+		feed.Updated = links[0].CreatedAt.UTC().Format(time.RFC3339)
+	}
+
+	for _, link := range links {
+		entry := atomEntry{
+			Title:     link.Path,
+			ID:        fmt.Sprintf("%s/api/links/%d", base, link.ID),
+			Link:      atomLink{Href: link.URL, Rel: "alternate"},
+			Published: link.CreatedAt.UTC().Format(time.RFC3339),
+			Updated:   link.UpdatedAt.UTC().Format(time.RFC3339),
+			Summary:   fmt.Sprintf("%s/%s → %s", base, link.Path, link.URL),
+		}
+		if link.Owner != "" {
+			entry.Author = &atomAuthor{Name: link.Owner}
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+	return feed
+}
+
+// handleLinksFeed serves the most recently created links as an Atom feed.
+// LinksFeed godoc
+// @Summary      Feed of new links
+// @Description  Atom feed of the 50 most recently created links
+// @Tags         links
+// @Produce      application/atom+xml
+// @Success      200  {string}  string  "Atom feed"
+// @Router       /links/feed.xml [get]
+func (s *Server) handleLinksFeed(w http.ResponseWriter, r *http.Request) {
+	links, err := s.store.GetRecentLinks(r.Context(), feedEntries)
+	if err != nil {
+		logRequestError(r, "API LinksFeed error", err)
+		writeErrorJSON(w, "Failed to retrieve links", http.StatusInternalServerError)
+		return
+	}
+
+	feed := buildFeed(s.config.Brand.Name, s.baseURL(r), links, time.Now())
+	w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+	w.Write([]byte(xml.Header))
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(feed); err != nil {
+		logRequestError(r, "API LinksFeed encode error", err)
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"strings"
+	"unicode"
+)
+
+// ftsStatements create the links_fts full-text index over the path, URL and
+// description of links, and the triggers keeping it in sync. links_fts is an
+// external content table, so it stores only the index.
+var ftsStatements = []string{
+	`CREATE VIRTUAL TABLE IF NOT EXISTS links_fts USING fts5(path, url, description, content='links', content_rowid='id')`,
+	`CREATE TRIGGER IF NOT EXISTS links_fts_insert AFTER INSERT ON links BEGIN
+		INSERT INTO links_fts(rowid, path, url, description) VALUES (new.id, new.path, new.url, new.description);
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS links_fts_delete AFTER DELETE ON links BEGIN
+		INSERT INTO links_fts(links_fts, rowid, path, url, description) VALUES ('delete', old.id, old.path, old.url, old.description);
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS links_fts_update AFTER UPDATE OF path, url, description ON links BEGIN
+		INSERT INTO links_fts(links_fts, rowid, path, url, description) VALUES ('delete', old.id, old.path, old.url, old.description);
+		INSERT INTO links_fts(rowid, path, url, description) VALUES (new.id, new.path, new.url, new.description);
+	END`,
+}
+
+// ftsRank orders full-text matches best first. bm25 weighs the columns in
+// table order: a term in the path counts most, then the description, then
+// the URL.
+const ftsRank = "bm25(links_fts, 10.0, 1.0, 2.0)"
+
+// setupFTS creates the full-text index unless it exists, filling it from the
+// links already stored. It reports false when SQLite was built without FTS5;
+// searches then fall back to substring matching.
+func setupFTS(db *sql.DB) (bool, error) {
+	var exists bool
+	if err := db.QueryRow(`SELECT EXISTS(SELECT 1 FROM sqlite_master WHERE type = 'table' AND name = 'links_fts')`).Scan(&exists); err != nil {
+		return false, err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	for _, statement := range ftsStatements {
+		if _, err := tx.Exec(statement); err != nil {
+			if strings.Contains(err.Error(), "no such module: fts5") {
+				slog.Warn("SQLite lacks FTS5, search falls back to substring matching")
+				return false, nil
+			}
+			return false, fmt.Errorf("failed to create full-text index: %w", err)
+		}
+	}
+	if !exists {
+		if _, err := tx.Exec(`INSERT INTO links_fts(links_fts) VALUES ('rebuild')`); err != nil {
+			return false, fmt.Errorf("failed to build full-text index: %w", err)
+		}
+	}
+	return true, tx.Commit()
+}
+
+// ftsQuery turns a search into an FTS5 query matching links that contain
+// every word, the last one also as a prefix so results appear while typing.
+// Words are split like the index splits them and quoted, so FTS5 operators
+// in the search match literally. It returns "" when the search has no words.
+func ftsQuery(search string) string {
+	words := strings.FieldsFunc(search, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	terms := make([]string, len(words))
+	for i, word := range words {
+		terms[i] = `"` + word + `"`
+	}
+	if len(terms) > 0 {
+		terms[len(terms)-1] += "*"
+	}
+	return strings.Join(terms, " ")
+}
+
+// SearchLinksFTS retrieves the links whose path, URL or description contains
+// every word of query, best matches first. Without FTS5, or when the query
+// has no words, it falls back to SearchLinks ordered by path.
+func (s *Store) SearchLinksFTS(ctx context.Context, query string) ([]Link, error) {
+	match := ftsQuery(query)
+	if !s.ftsEnabled || match == "" {
+		return s.SearchLinks(ctx, query, defaultLinkSort)
+	}
+
+	// Ranked in a subquery, as the index shares the path, url and description
+	// column names with links
+	matches := "SELECT rowid, " + ftsRank + " AS score FROM links_fts WHERE links_fts MATCH ?"
+	rows, err := s.db.QueryContext(ctx, "SELECT "+linkColumns+" FROM links JOIN ("+matches+") AS matches ON matches.rowid = links.id ORDER BY matches.score, path", match)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	links := []Link{}
+	for rows.Next() {
+		link, err := scanLink(rows)
+		if err != nil {
+			return nil, err
+		}
+		links = append(links, link)
+	}
+	return links, rows.Err()
+}
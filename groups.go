@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// groupPattern restricts group names to lowercase words, optionally nested
+// with slashes, e.g. "platform" or "platform/infra".
+var groupPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9:_/-]{0,63}$`)
+
+// normalizeGroup trims and lowercases a group name.
+func normalizeGroup(group string) string {
+	return strings.ToLower(strings.TrimSpace(group))
+}
+
+// validateGroup ensures a normalized group name follows the allowed format.
+// The empty name means the link is ungrouped.
+func validateGroup(group string) error {
+	if group != "" && !groupPattern.MatchString(group) {
+		return fmt.Errorf("invalid group '%s': use up to 64 lowercase letters, numbers, hyphens, underscores, colons or slashes", group)
+	}
+	return nil
+}
+
+// MoveGroupRequest is the payload of the bulk group move endpoint. Links are
+// selected either by their current group (From, moved to To) or by ID (IDs,
+// moved to Group). An empty target makes the links ungrouped.
+type MoveGroupRequest struct {
+	From  string  `json:"from,omitempty"`
+	To    string  `json:"to,omitempty"`
+	IDs   []int64 `json:"ids,omitempty"`
+	Group string  `json:"group,omitempty"`
+}
+
+// MoveGroupResponse reports how many links changed group.
+type MoveGroupResponse struct {
+	Moved int `json:"moved"`
+}
+
+// MoveGroup moves the links in group from, or with the given IDs when ids is
+// non-empty, to group to in one transaction, recording an audit entry per
+// moved link. Links already in the target group are left untouched. It
+// returns the number of links moved.
+func (s *Store) MoveGroup(ctx context.Context, from string, ids []int64, to string) (int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	query := `SELECT id, link_group FROM links WHERE link_group = ?`
+	args := []interface{}{from}
+	if len(ids) > 0 {
+		placeholders := make([]string, len(ids))
+		args = nil
+		for i, id := range ids {
+			placeholders[i] = "?"
+			args = append(args, id)
+		}
+		query = `SELECT id, link_group FROM links WHERE id IN (` + strings.Join(placeholders, ", ") + `)`
+	}
+	query += ` AND link_group != ? ORDER BY id`
+	args = append(args, to)
+
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	priors := make(map[int64]string)
+	var linkIDs []int64
+	for rows.Next() {
+		var id int64
+		var prior string
+		if err := rows.Scan(&id, &prior); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		priors[id] = prior
+		linkIDs = append(linkIDs, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	updateSQL := `UPDATE links SET link_group = ?, updated_at = ` + sqliteNowMilli + ` WHERE id = ?`
+	for _, id := range linkIDs {
+		if _, err := tx.ExecContext(ctx, updateSQL, to, id); err != nil {
+			return 0, err
+		}
+		changes := map[string]FieldChange{"group": {Old: priors[id], New: to}}
+		if err := insertAuditEntry(tx, id, AuditActionMoveGroup, changes); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return len(linkIDs), nil
+}
+
+// handleMoveGroup reassigns the group of many links at once.
+// MoveGroup godoc
+// @Summary      Bulk move links between groups
+// @Description  Move all links in group "from" to "to", or the links in "ids" to "group"; an empty target ungroups them (admin only)
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        move  body      MoveGroupRequest  true  "Selection and target group"
+// @Success      200  {object}  MoveGroupResponse
+// @Failure      400  {object}  ErrorResponse
+// @Failure      422  {object}  ErrorResponse
+// @Router       /links/move-group [post]
+func (s *Server) handleMoveGroup(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+
+	var req MoveGroupRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		writeErrorJSON(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	from := normalizeGroup(req.From)
+	target := normalizeGroup(req.To)
+	switch {
+	case len(req.IDs) > 0 && (from != "" || target != ""):
+		writeErrorJSON(w, "use either from and to, or ids and group", http.StatusUnprocessableEntity)
+		return
+	case len(req.IDs) > 0:
+		target = normalizeGroup(req.Group)
+	case req.Group != "":
+		writeErrorJSON(w, "group is only used with ids; use to with from", http.StatusUnprocessableEntity)
+		return
+	case from == "":
+		writeErrorJSON(w, "from or ids is required", http.StatusUnprocessableEntity)
+		return
+	case from == target:
+		writeErrorJSON(w, "from and to must differ", http.StatusUnprocessableEntity)
+		return
+	}
+	if err := validateGroup(target); err != nil {
+		writeErrorJSON(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	moved, err := s.store.MoveGroup(r.Context(), from, req.IDs, target)
+	if err != nil {
+		logRequestError(r, "API MoveGroup error", err)
+		writeErrorJSON(w, "Failed to move links", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(MoveGroupResponse{Moved: moved})
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"compress/gzip"
+	"net/http"
+	"strings"
+)
+
+// gzipTypes lists the media types worth compressing; images other than SVG
+// and uploaded icons are compressed already.
+var gzipTypes = []string{"text/", "application/json", "application/javascript", "application/xml", "application/atom+xml", "image/svg+xml"}
+
+// gzipWriter holds back the start of a response until it is known whether
+// the body reaches the compression threshold, then either compresses the
+// rest or passes it through unchanged.
+type gzipWriter struct {
+	http.ResponseWriter
+	accepts bool
+	minSize int
+
+	status  int
+	buf     []byte
+	decided bool
+	gz      *gzip.Writer
+}
+
+func (w *gzipWriter) WriteHeader(status int) {
+	if status >= 100 && status < 200 {
+		// Informational responses are not the final header
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+	if w.status != 0 {
+		return
+	}
+	w.status = status
+	if !w.bodyAllowed() {
+		w.decide(false)
+	}
+}
+
+func (w *gzipWriter) Write(data []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if w.decided {
+		if w.gz != nil {
+			return w.gz.Write(data)
+		}
+		return w.ResponseWriter.Write(data)
+	}
+
+	w.buf = append(w.buf, data...)
+	if len(w.buf) >= w.minSize {
+		if err := w.decide(w.compressible()); err != nil {
+			return 0, err
+		}
+	}
+	return len(data), nil
+}
+
+// Flush sends what has been written so far, compressing it when the body
+// may still grow past the threshold.
+func (w *gzipWriter) Flush() {
+	if w.status == 0 {
+		return
+	}
+	if !w.decided {
+		w.decide(w.compressible())
+	}
+	if w.gz != nil {
+		w.gz.Flush()
+	}
+	http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *gzipWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// bodyAllowed reports whether the response can carry a body worth
+// compressing: redirects, 204 and 304 responses and partial content cannot.
+func (w *gzipWriter) bodyAllowed() bool {
+	switch {
+	case w.status >= 300 && w.status < 400:
+		return false
+	case w.status == http.StatusNoContent || w.status == http.StatusPartialContent:
+		return false
+	}
+	return true
+}
+
+// compressible reports whether the buffered response should be gzipped.
+func (w *gzipWriter) compressible() bool {
+	header := w.Header()
+	if !w.accepts || !w.bodyAllowed() || header.Get("Content-Encoding") != "" || header.Get("Content-Range") != "" {
+		return false
+	}
+	contentType := header.Get("Content-Type")
+	if contentType == "" {
+		// net/http would sniff the type from the first bytes; do it here so
+		// the header is settled before the response is committed
+		contentType = http.DetectContentType(w.buf)
+		header.Set("Content-Type", contentType)
+	}
+	for _, prefix := range gzipTypes {
+		if strings.HasPrefix(contentType, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// decide commits the status and headers, compressed or not, and writes the
+// buffered start of the body.
+func (w *gzipWriter) decide(compress bool) error {
+	w.decided = true
+	header := w.Header()
+	if w.bodyAllowed() {
+		// Caches must keep compressed and plain copies apart
+		header.Add("Vary", "Accept-Encoding")
+	}
+	if compress {
+		header.Set("Content-Encoding", "gzip")
+		header.Del("Content-Length")
+		w.gz = gzip.NewWriter(w.ResponseWriter)
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	var err error
+	if w.gz != nil {
+		_, err = w.gz.Write(buf)
+	} else {
+		_, err = w.ResponseWriter.Write(buf)
+	}
+	return err
+}
+
+// finish writes out a response that stayed below the threshold, or ends the
+// gzip stream of a compressed one.
+func (w *gzipWriter) finish() {
+	if !w.decided {
+		if w.status == 0 {
+			// Nothing was written; let net/http answer as usual
+			return
+		}
+		w.decide(false)
+	}
+	if w.gz != nil {
+		w.gz.Close()
+	}
+}
+
+// gzipResponses compresses response bodies of at least GZIP_MIN_SIZE bytes
+// for clients that send Accept-Encoding: gzip, unless GZIP_ENABLED is off.
+// Redirects, HEAD requests and responses that are compressed already pass
+// through untouched.
+func gzipResponses(next http.Handler, config *Config) http.Handler {
+	if !config.GzipEnabled {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		writer := &gzipWriter{ResponseWriter: w, accepts: acceptsGzip(r), minSize: config.GzipMinSize}
+		defer writer.finish()
+		next.ServeHTTP(writer, r)
+	})
+}
+
+// acceptsGzip reports whether the Accept-Encoding header of r allows gzip.
+func acceptsGzip(r *http.Request) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if !strings.EqualFold(strings.TrimSpace(coding), "gzip") {
+			continue
+		}
+		// "gzip;q=0" explicitly refuses it
+		q := strings.ReplaceAll(strings.TrimSpace(params), " ", "")
+		return q != "q=0" && q != "q=0.0" && q != "q=0.00" && q != "q=0.000"
+	}
+	return false
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// bodyHandler writes body in chunks of chunk bytes with the given headers and
+// status.
+func bodyHandler(status int, contentType, encoding string, body []byte, chunk int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if contentType != "" {
+			w.Header().Set("Content-Type", contentType)
+		}
+		if encoding != "" {
+			w.Header().Set("Content-Encoding", encoding)
+		}
+		if status != 0 {
+			w.WriteHeader(status)
+		}
+		for len(body) > 0 {
+			n := min(chunk, len(body))
+			w.Write(body[:n])
+			body = body[n:]
+		}
+	})
+}
+
+func TestGzipResponses(t *testing.T) {
+	const minSize = 1024
+	text := func(n int) []byte { return bytes.Repeat([]byte("a"), n) }
+	tests := []struct {
+		name         string
+		disabled     bool
+		method       string
+		accept       string
+		status       int
+		contentType  string
+		encoding     string
+		body         []byte
+		chunk        int
+		wantGzip     bool
+		wantSniffed  string
+		wantVaryless bool
+	}{
+		{name: "below threshold", accept: "gzip", contentType: "application/json", body: text(minSize - 1), wantGzip: false},
+		{name: "at threshold", accept: "gzip", contentType: "application/json", body: text(minSize), wantGzip: true},
+		{name: "above threshold", accept: "gzip", contentType: "text/html; charset=utf-8", body: text(4 * minSize), wantGzip: true},
+		{name: "small writes crossing threshold", accept: "gzip", contentType: "text/plain", body: text(2 * minSize), chunk: 100, wantGzip: true},
+		{name: "small writes below threshold", accept: "gzip", contentType: "text/plain", body: text(minSize - 1), chunk: 100, wantGzip: false},
+		{name: "not accepted", accept: "", contentType: "application/json", body: text(2 * minSize), wantGzip: false},
+		{name: "refused with q=0", accept: "gzip;q=0, deflate", contentType: "application/json", body: text(2 * minSize), wantGzip: false},
+		{name: "accepted among others", accept: "br, GZIP;q=0.8", contentType: "application/json", body: text(2 * minSize), wantGzip: true},
+		{name: "incompressible type", accept: "gzip", contentType: "image/png", body: text(2 * minSize), wantGzip: false},
+		{name: "svg", accept: "gzip", contentType: "image/svg+xml", body: text(2 * minSize), wantGzip: true},
+		{name: "already encoded", accept: "gzip", contentType: "application/json", encoding: "br", body: text(2 * minSize), wantGzip: false},
+		{name: "sniffed type", accept: "gzip", body: []byte("<html>" + strings.Repeat("a", 2*minSize)), wantGzip: true, wantSniffed: "text/html; charset=utf-8"},
+		{name: "redirect", accept: "gzip", status: http.StatusFound, contentType: "text/html", body: text(2 * minSize), wantGzip: false, wantVaryless: true},
+		{name: "HEAD", method: http.MethodHead, accept: "gzip", contentType: "application/json", body: text(2 * minSize), wantGzip: false, wantVaryless: true},
+		{name: "disabled", disabled: true, accept: "gzip", contentType: "application/json", body: text(2 * minSize), wantGzip: false, wantVaryless: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &Config{GzipEnabled: !tt.disabled, GzipMinSize: minSize}
+			chunk := tt.chunk
+			if chunk == 0 {
+				chunk = len(tt.body)
+			}
+			handler := gzipResponses(bodyHandler(tt.status, tt.contentType, tt.encoding, tt.body, chunk), config)
+			method := tt.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			r := httptest.NewRequest(method, "/", nil)
+			if tt.accept != "" {
+				r.Header.Set("Accept-Encoding", tt.accept)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			gzipped := w.Header().Get("Content-Encoding") == "gzip"
+			if gzipped != tt.wantGzip {
+				t.Fatalf("Content-Encoding = %q, want gzip %v", w.Header().Get("Content-Encoding"), tt.wantGzip)
+			}
+			if vary := w.Header().Get("Vary"); (vary == "") != tt.wantVaryless {
+				t.Errorf("Vary = %q", vary)
+			}
+			if tt.wantSniffed != "" && w.Header().Get("Content-Type") != tt.wantSniffed {
+				t.Errorf("Content-Type = %q, want %q", w.Header().Get("Content-Type"), tt.wantSniffed)
+			}
+			if wantStatus := max(tt.status, http.StatusOK); w.Code != wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, wantStatus)
+			}
+
+			body := w.Body.Bytes()
+			if gzipped {
+				reader, err := gzip.NewReader(w.Body)
+				if err != nil {
+					t.Fatalf("reading gzip stream: %v", err)
+				}
+				if body, err = io.ReadAll(reader); err != nil {
+					t.Fatalf("decompressing: %v", err)
+				}
+			}
+			if !bytes.Equal(body, tt.body) {
+				t.Errorf("body has %d bytes, want the %d written", len(body), len(tt.body))
+			}
+		})
+	}
+}
+
+func TestGzipNoContent(t *testing.T) {
+	handler := gzipResponses(bodyHandler(http.StatusNoContent, "", "", nil, 1), &Config{GzipEnabled: true, GzipMinSize: 1})
+	r := httptest.NewRequest(http.MethodDelete, "/", nil)
+	r.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if w.Code != http.StatusNoContent || w.Header().Get("Content-Encoding") != "" || w.Body.Len() != 0 {
+		t.Errorf("status = %d, Content-Encoding = %q, body = %d bytes", w.Code, w.Header().Get("Content-Encoding"), w.Body.Len())
+	}
+}
+
+func TestGzipAPIResponses(t *testing.T) {
+	server, handler := newTestServer(t, func(c *Config) { c.GzipMinSize = 512 })
+	for _, path := range []string{"alpha", "bravo", "charlie", "delta", "echo", "foxtrot", "golf", "hotel"} {
+		createLink(t, server, handler, Link{Path: path, URL: "https://" + path + ".example.com", Description: "A link long enough to grow the listing"})
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/api/links", nil)
+	r.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if w.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("listing was not compressed: %d bytes", w.Body.Len())
+	}
+
+	// Redirects stay plain regardless of size
+	r = httptest.NewRequest(http.MethodGet, "/alpha", nil)
+	r.Header.Set("Accept-Encoding", "gzip")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if w.Code != http.StatusFound || w.Header().Get("Content-Encoding") != "" {
+		t.Errorf("redirect: status = %d, Content-Encoding = %q", w.Code, w.Header().Get("Content-Encoding"))
+	}
+}
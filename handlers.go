@@ -1,51 +1,61 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"html/template"
-	"log"
+	"io"
+	"log/slog"
+	"mime"
 	"net/http"
 	"net/url"
+	"reflect"
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
+	"unicode/utf8"
 )
 
 // Server holds the dependencies for the web application.
 type Server struct {
-	store     *Store
-	templates *template.Template
+	config      *Config
+	store       LinkStore
+	templates   *template.Template
+	dashboard   dashboardCache
+	linkLimiter *linkRateLimiter
+	submissions *submissionTracker
+	// createLimiter enforces CREATE_RATE_LIMIT; nil when it is disabled
+	createLimiter *clientRateLimiter
+	// clicks queues click events; its worker is started by main
+	clicks *clickRecorder
+	// webhooks queues link change events; nil without WEBHOOK_URL
+	webhooks *webhookNotifier
 }
 
 // NewServer creates a new Server with necessary dependencies.
-func NewServer(store *Store) (*Server, error) {
-	// Parse template files from the templates directory
-	templates, err := template.ParseGlob("templates/*.html")
+func NewServer(store LinkStore, config *Config) (*Server, error) {
+	templates, err := parseTemplates(templateFS(config.TemplatesDir))
 	if err != nil {
-		return nil, fmt.Errorf("error parsing templates: %w", err)
+		return nil, err
 	}
 
-	// Parse component templates
-	componentTemplates, err := template.ParseGlob("templates/components/*.html")
-	if err != nil {
-		// Components are optional for now, just log the error
-		log.Printf("Warning: Could not parse component templates: %v", err)
-	} else {
-		// Add component templates to the main template
-		for _, t := range componentTemplates.Templates() {
-			templates, err = templates.AddParseTree(t.Name(), t.Tree)
-			if err != nil {
-				log.Printf("Warning: Could not add component template %s: %v", t.Name(), err)
-			}
-		}
+	server := &Server{
+		config:      config,
+		store:       store,
+		templates:   templates,
+		linkLimiter: newLinkRateLimiter(linkLimiterMaxEntries),
+		submissions: newSubmissionTracker(),
+		clicks:      newClickRecorder(store, clickQueueSize),
+		webhooks:    newWebhookNotifier(config, webhookQueueSize),
 	}
-
-	return &Server{
-		store:     store,
-		templates: templates,
-	}, nil
+	if config.CreateRateLimit > 0 {
+		server.createLimiter = newClientRateLimiter(config.CreateRateLimit, clientLimiterMaxEntries)
+	}
+	return server, nil
 }
 
 // rootHandler is the main entry point for all requests.
@@ -55,13 +65,13 @@ func (s *Server) rootHandler(w http.ResponseWriter, r *http.Request) {
 		s.goPortalHandler(w, r)
 		return
 	}
-	
+
 	// Handle portal link management (traditional forms)
 	if strings.HasPrefix(r.URL.Path, "/go/links") {
 		s.goLinksRouter(w, r)
 		return
 	}
-	
+
 	// Handle HTMX portal requests
 	if strings.HasPrefix(r.URL.Path, "/go/htmx") {
 		s.htmxRouter(w, r)
@@ -74,6 +84,22 @@ func (s *Server) rootHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Handle health checks
+	if r.URL.Path == "/healthz" {
+		s.handleHealth(w, r)
+		return
+	}
+	if r.URL.Path == "/readyz" {
+		s.handleReady(w, r)
+		return
+	}
+
+	// Handle build information
+	if r.URL.Path == "/version" {
+		s.handleVersion(w, r)
+		return
+	}
+
 	// Handle favicon requests
 	if r.URL.Path == "/favicon.ico" {
 		http.NotFound(w, r)
@@ -97,7 +123,7 @@ func (s *Server) goLinksRouter(w http.ResponseWriter, r *http.Request) {
 		}
 		return
 	}
-	
+
 	// /go/links/{id} - edit/delete link
 	if path[0] == '/' {
 		idStr := path[1:]
@@ -106,7 +132,7 @@ func (s *Server) goLinksRouter(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, "Invalid link ID", http.StatusBadRequest)
 			return
 		}
-		
+
 		// Handle method override for PUT/DELETE via forms
 		method := r.Method
 		if r.Method == http.MethodPost {
@@ -114,7 +140,7 @@ func (s *Server) goLinksRouter(w http.ResponseWriter, r *http.Request) {
 				method = methodOverride
 			}
 		}
-		
+
 		switch method {
 		case http.MethodPut:
 			s.handlePortalUpdate(w, r, id)
@@ -125,7 +151,7 @@ func (s *Server) goLinksRouter(w http.ResponseWriter, r *http.Request) {
 		}
 		return
 	}
-	
+
 	http.NotFound(w, r)
 }
 
@@ -134,39 +160,36 @@ func (s *Server) handlePortalUpdate(w http.ResponseWriter, r *http.Request, id i
 	// Parse form data
 	err := r.ParseForm()
 	if err != nil {
-		log.Printf("Error parsing form: %v", err)
+		logRequestError(r, "Error parsing form", err, "id", id)
 		http.Error(w, "Bad request", http.StatusBadRequest)
 		return
 	}
 
 	// Get form values
-	path := strings.TrimSpace(r.FormValue("path"))
-	url := strings.TrimSpace(r.FormValue("url"))
-
-	// Create link object for validation
-	link := Link{
-		ID:   id,
-		Path: path,
-		URL:  url,
-	}
+	link, errors := linkFromForm(r)
+	link.ID = id
 
 	// Validate the link
-	errors := make(map[string]string)
-	if err := validateLink(link); err != nil {
+	if err := s.validateLink(r.Context(), link); err != nil {
 		errors["General"] = err.Error()
 	}
+	if warning := s.softReservedWarning(link.Path); warning != "" && !isForced(r) {
+		errors["Force"] = warning
+	}
 
 	// If validation passes, update the link
 	if len(errors) == 0 {
-		err = s.store.UpdateLink(id, path, url)
+		err = s.store.UpdateLink(r.Context(), id, link)
 		if err != nil {
-			log.Printf("Error updating link: %v", err)
+			logRequestError(r, "Error updating link", err, "id", id)
 			if strings.Contains(err.Error(), "already exists") {
 				errors["Path"] = err.Error()
 			} else {
 				errors["General"] = "Failed to update link"
 			}
 		} else {
+			s.linkLimiter.Reset(id)
+			s.notifyUpdated(r.Context(), id)
 			// Success - redirect
 			http.Redirect(w, r, "/go?success=Link updated successfully", http.StatusSeeOther)
 			return
@@ -179,9 +202,10 @@ func (s *Server) handlePortalUpdate(w http.ResponseWriter, r *http.Request, id i
 
 // handlePortalDelete handles deleting a link via the portal
 func (s *Server) handlePortalDelete(w http.ResponseWriter, r *http.Request, id int64) {
-	err := s.store.DeleteLink(id)
+	deleted := s.linkBeforeDelete(r.Context(), id)
+	err := s.store.DeleteLink(r.Context(), id)
 	if err != nil {
-		log.Printf("Error deleting link: %v", err)
+		logRequestError(r, "Error deleting link", err, "id", id)
 		if strings.Contains(err.Error(), "not found") {
 			http.Redirect(w, r, "/go?error=Link not found", http.StatusSeeOther)
 		} else {
@@ -191,6 +215,7 @@ func (s *Server) handlePortalDelete(w http.ResponseWriter, r *http.Request, id i
 	}
 
 	// Success
+	s.notifyDeleted(deleted)
 	http.Redirect(w, r, "/go?success=Link deleted successfully", http.StatusSeeOther)
 }
 
@@ -198,24 +223,44 @@ func (s *Server) handlePortalDelete(w http.ResponseWriter, r *http.Request, id i
 func (s *Server) htmxRouter(w http.ResponseWriter, r *http.Request) {
 	// Parse the path
 	path := strings.TrimPrefix(r.URL.Path, "/go/htmx")
-	
+
 	if path == "/search" {
 		s.htmxSearchHandler(w, r)
 		return
 	}
-	
+
+	if path == "/misses" {
+		s.htmxMissesHandler(w, r)
+		return
+	}
+
+	if path == "/import" {
+		s.htmxImportHandler(w, r)
+		return
+	}
+
+	if path == "/trash" {
+		s.htmxTrashHandler(w, r)
+		return
+	}
+
+	if strings.HasPrefix(path, "/trash/") {
+		s.htmxTrashRouter(w, r, path)
+		return
+	}
+
 	if strings.HasPrefix(path, "/links") {
 		s.htmxLinksRouter(w, r, path)
 		return
 	}
-	
+
 	http.NotFound(w, r)
 }
 
 // htmxLinksRouter handles /go/htmx/links/* routes
 func (s *Server) htmxLinksRouter(w http.ResponseWriter, r *http.Request, path string) {
 	linksPath := strings.TrimPrefix(path, "/links")
-	
+
 	if linksPath == "" {
 		// /go/htmx/links - create new link
 		if r.Method == http.MethodPost {
@@ -225,7 +270,17 @@ func (s *Server) htmxLinksRouter(w http.ResponseWriter, r *http.Request, path st
 		}
 		return
 	}
-	
+
+	if linksPath == "/available" {
+		// /go/htmx/links/available - inline path availability
+		if r.Method == http.MethodGet {
+			s.htmxPathAvailability(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+
 	if linksPath == "/new" {
 		// /go/htmx/links/new - show new link form
 		if r.Method == http.MethodGet {
@@ -235,7 +290,7 @@ func (s *Server) htmxLinksRouter(w http.ResponseWriter, r *http.Request, path st
 		}
 		return
 	}
-	
+
 	// /go/htmx/links/{id} or /go/htmx/links/{id}/edit
 	if linksPath[0] == '/' {
 		parts := strings.Split(linksPath[1:], "/")
@@ -243,13 +298,23 @@ func (s *Server) htmxLinksRouter(w http.ResponseWriter, r *http.Request, path st
 			http.NotFound(w, r)
 			return
 		}
-		
+
 		id, err := strconv.ParseInt(parts[0], 10, 64)
 		if err != nil {
 			http.Error(w, "Invalid link ID", http.StatusBadRequest)
 			return
 		}
-		
+
+		if len(parts) == 2 && parts[1] == "toggle" {
+			// /go/htmx/links/{id}/toggle - enable or disable
+			if r.Method == http.MethodPost {
+				s.htmxToggleLink(w, r, id)
+			} else {
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+			return
+		}
+
 		if len(parts) == 2 && parts[1] == "edit" {
 			// /go/htmx/links/{id}/edit - show edit form
 			if r.Method == http.MethodGet {
@@ -259,7 +324,7 @@ func (s *Server) htmxLinksRouter(w http.ResponseWriter, r *http.Request, path st
 			}
 			return
 		}
-		
+
 		if len(parts) == 1 {
 			// /go/htmx/links/{id} - update or delete
 			switch r.Method {
@@ -273,45 +338,37 @@ func (s *Server) htmxLinksRouter(w http.ResponseWriter, r *http.Request, path st
 			return
 		}
 	}
-	
+
 	http.NotFound(w, r)
 }
 
 // htmxSearchHandler handles real-time search requests
 func (s *Server) htmxSearchHandler(w http.ResponseWriter, r *http.Request) {
 	searchQuery := r.URL.Query().Get("search")
-	
-	// Get all links from the database
-	links, err := s.store.GetAllLinks()
+
+	// Get the requested page of matching links
+	links, page, err := s.portalLinks(r.Context(), searchQuery, portalSort(r), portalOffset(r))
 	if err != nil {
-		log.Printf("Error fetching links for search: %v", err)
+		logRequestError(r, "Error fetching links for search", err)
 		http.Error(w, "Failed to search links", http.StatusInternalServerError)
 		return
 	}
 
-	// Filter links if search query provided
-	if searchQuery != "" {
-		filteredLinks := []Link{}
-		for _, link := range links {
-			if strings.Contains(strings.ToLower(link.Path), strings.ToLower(searchQuery)) ||
-			   strings.Contains(strings.ToLower(link.URL), strings.ToLower(searchQuery)) {
-				filteredLinks = append(filteredLinks, link)
-			}
-		}
-		links = filteredLinks
-	}
-
 	// Prepare data for the link-list template
 	data := struct {
-		Links []Link
+		Links       []Link
+		Page        LinkPage
+		SearchQuery string
 	}{
-		Links: links,
+		Links:       links,
+		Page:        page,
+		SearchQuery: searchQuery,
 	}
 
 	// Render only the link-list component
-	err = s.templates.ExecuteTemplate(w, "link-list", data)
+	err = s.executeTemplate(w, "link-list", data)
 	if err != nil {
-		log.Printf("Template execution error in search: %v", err)
+		logRequestError(r, "Template execution error in search", err)
 		http.Error(w, "Template rendering error", http.StatusInternalServerError)
 		return
 	}
@@ -327,13 +384,13 @@ func (s *Server) htmxNewLinkForm(w http.ResponseWriter, r *http.Request) {
 	}{
 		ShowForm: true,
 		EditMode: false,
-		Link:     Link{},
+		Link:     Link{Path: normalizePath(r.URL.Query().Get("path"))},
 		Errors:   make(map[string]string),
 	}
 
-	err := s.templates.ExecuteTemplate(w, "link-form", data)
+	err := s.executeTemplate(w, "link-form", data)
 	if err != nil {
-		log.Printf("Template execution error: %v", err)
+		logRequestError(r, "Template execution error", err)
 		http.Error(w, "Template rendering error", http.StatusInternalServerError)
 		return
 	}
@@ -342,25 +399,14 @@ func (s *Server) htmxNewLinkForm(w http.ResponseWriter, r *http.Request) {
 // htmxEditLinkForm shows the edit link form
 func (s *Server) htmxEditLinkForm(w http.ResponseWriter, r *http.Request, id int64) {
 	// Get the link from database
-	links, err := s.store.GetAllLinks()
-	if err != nil {
-		log.Printf("Error fetching links: %v", err)
-		http.Error(w, "Failed to load link", http.StatusInternalServerError)
+	link, err := s.store.GetLinkByID(r.Context(), id)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Link not found", http.StatusNotFound)
 		return
 	}
-
-	var link Link
-	found := false
-	for _, l := range links {
-		if l.ID == id {
-			link = l
-			found = true
-			break
-		}
-	}
-
-	if !found {
-		http.Error(w, "Link not found", http.StatusNotFound)
+	if err != nil {
+		logRequestError(r, "Error fetching link", err, "id", id)
+		http.Error(w, "Failed to load link", http.StatusInternalServerError)
 		return
 	}
 
@@ -372,13 +418,13 @@ func (s *Server) htmxEditLinkForm(w http.ResponseWriter, r *http.Request, id int
 	}{
 		ShowForm: true,
 		EditMode: true,
-		Link:     link,
+		Link:     *link,
 		Errors:   make(map[string]string),
 	}
 
-	err = s.templates.ExecuteTemplate(w, "link-form", data)
+	err = s.executeTemplate(w, "link-form", data)
 	if err != nil {
-		log.Printf("Template execution error: %v", err)
+		logRequestError(r, "Template execution error", err, "id", id)
 		http.Error(w, "Template rendering error", http.StatusInternalServerError)
 		return
 	}
@@ -386,43 +432,65 @@ func (s *Server) htmxEditLinkForm(w http.ResponseWriter, r *http.Request, id int
 
 // htmxCreateLink handles creating a link via HTMX
 func (s *Server) htmxCreateLink(w http.ResponseWriter, r *http.Request) {
+	if !s.allowCreate(w, r) {
+		return
+	}
+
 	// Parse form data
 	err := r.ParseForm()
 	if err != nil {
-		log.Printf("Error parsing form: %v", err)
+		logRequestError(r, "Error parsing form", err)
 		http.Error(w, "Bad request", http.StatusBadRequest)
 		return
 	}
 
-	// Get form values
-	path := strings.TrimSpace(r.FormValue("path"))
-	url := strings.TrimSpace(r.FormValue("url"))
-
-	// Create link object for validation
-	link := Link{
-		Path: path,
-		URL:  url,
+	// A repeated submit of a form that already created its link is treated
+	// as a success rather than a duplicate path error
+	token := r.FormValue("submit_token")
+	if prior := s.submissions.begin(token); prior != nil {
+		if prior.wait() {
+			s.htmxRenderPortalContent(w, r, "Link created successfully", "", "")
+			return
+		}
+		token = ""
 	}
+	created := false
+	defer func() { s.submissions.finish(token, created) }()
+
+	// Get form values
+	link, errors := linkFromForm(r)
+	link.CreatedBy = requestCreator(r)
 
 	// Validate the link
-	errors := make(map[string]string)
-	if err := validateLink(link); err != nil {
+	if err := s.validateLink(r.Context(), link); err != nil {
 		errors["General"] = err.Error()
 	}
+	if warning := s.softReservedWarning(link.Path); warning != "" && !isForced(r) {
+		errors["Force"] = warning
+	}
+
+	if len(errors) == 0 {
+		if err := s.runCreateHook(r.Context(), link); err != nil {
+			errors["General"] = err.Error()
+		}
+	}
 
 	// If validation passes, create the link
 	if len(errors) == 0 {
-		err = s.store.CreateLink(path, url)
+		duplicates := s.duplicateURLWarning(r, link)
+		err = s.store.CreateLink(r.Context(), link)
 		if err != nil {
-			log.Printf("Error creating link: %v", err)
+			logRequestError(r, "Error creating link", err)
 			if strings.Contains(err.Error(), "already exists") {
 				errors["Path"] = err.Error()
 			} else {
 				errors["General"] = "Failed to create link"
 			}
 		} else {
+			created = true
+			s.notifyCreated(r.Context(), link.Path)
 			// Success - return the updated portal content
-			s.htmxRenderPortalContent(w, r, "Link created successfully", "")
+			s.htmxRenderPortalContent(w, r, "Link created successfully", "", duplicates)
 			return
 		}
 	}
@@ -440,9 +508,9 @@ func (s *Server) htmxCreateLink(w http.ResponseWriter, r *http.Request) {
 		Errors:   errors,
 	}
 
-	err = s.templates.ExecuteTemplate(w, "link-form", data)
+	err = s.executeTemplate(w, "link-form", data)
 	if err != nil {
-		log.Printf("Template execution error: %v", err)
+		logRequestError(r, "Template execution error", err)
 		http.Error(w, "Template rendering error", http.StatusInternalServerError)
 		return
 	}
@@ -453,41 +521,38 @@ func (s *Server) htmxUpdateLink(w http.ResponseWriter, r *http.Request, id int64
 	// Parse form data
 	err := r.ParseForm()
 	if err != nil {
-		log.Printf("Error parsing form: %v", err)
+		logRequestError(r, "Error parsing form", err, "id", id)
 		http.Error(w, "Bad request", http.StatusBadRequest)
 		return
 	}
 
 	// Get form values
-	path := strings.TrimSpace(r.FormValue("path"))
-	url := strings.TrimSpace(r.FormValue("url"))
-
-	// Create link object for validation
-	link := Link{
-		ID:   id,
-		Path: path,
-		URL:  url,
-	}
+	link, errors := linkFromForm(r)
+	link.ID = id
 
 	// Validate the link
-	errors := make(map[string]string)
-	if err := validateLink(link); err != nil {
+	if err := s.validateLink(r.Context(), link); err != nil {
 		errors["General"] = err.Error()
 	}
+	if warning := s.softReservedWarning(link.Path); warning != "" && !isForced(r) {
+		errors["Force"] = warning
+	}
 
 	// If validation passes, update the link
 	if len(errors) == 0 {
-		err = s.store.UpdateLink(id, path, url)
+		err = s.store.UpdateLink(r.Context(), id, link)
 		if err != nil {
-			log.Printf("Error updating link: %v", err)
+			logRequestError(r, "Error updating link", err, "id", id)
 			if strings.Contains(err.Error(), "already exists") {
 				errors["Path"] = err.Error()
 			} else {
 				errors["General"] = "Failed to update link"
 			}
 		} else {
+			s.linkLimiter.Reset(id)
+			s.notifyUpdated(r.Context(), id)
 			// Success - return the updated portal content
-			s.htmxRenderPortalContent(w, r, "Link updated successfully", "")
+			s.htmxRenderPortalContent(w, r, "Link updated successfully", "", "")
 			return
 		}
 	}
@@ -505,9 +570,9 @@ func (s *Server) htmxUpdateLink(w http.ResponseWriter, r *http.Request, id int64
 		Errors:   errors,
 	}
 
-	err = s.templates.ExecuteTemplate(w, "link-form", data)
+	err = s.executeTemplate(w, "link-form", data)
 	if err != nil {
-		log.Printf("Template execution error: %v", err)
+		logRequestError(r, "Template execution error", err, "id", id)
 		http.Error(w, "Template rendering error", http.StatusInternalServerError)
 		return
 	}
@@ -515,35 +580,38 @@ func (s *Server) htmxUpdateLink(w http.ResponseWriter, r *http.Request, id int64
 
 // htmxDeleteLink handles deleting a link via HTMX
 func (s *Server) htmxDeleteLink(w http.ResponseWriter, r *http.Request, id int64) {
-	err := s.store.DeleteLink(id)
+	deleted := s.linkBeforeDelete(r.Context(), id)
+	err := s.store.DeleteLink(r.Context(), id)
 	if err != nil {
-		log.Printf("Error deleting link: %v", err)
+		logRequestError(r, "Error deleting link", err, "id", id)
 		if strings.Contains(err.Error(), "not found") {
-			s.htmxRenderPortalContent(w, r, "", "Link not found")
+			s.htmxRenderPortalContent(w, r, "", "Link not found", "")
 		} else {
-			s.htmxRenderPortalContent(w, r, "", "Failed to delete link")
+			s.htmxRenderPortalContent(w, r, "", "Failed to delete link", "")
 		}
 		return
 	}
 
 	// Success
-	s.htmxRenderPortalContent(w, r, "Link deleted successfully", "")
+	s.notifyDeleted(deleted)
+	s.htmxRenderPortalContent(w, r, "Link deleted successfully", "", "")
 }
 
 // htmxRenderPortalContent renders the entire portal content with messages
-func (s *Server) htmxRenderPortalContent(w http.ResponseWriter, r *http.Request, successMessage, errorMessage string) {
-	// Get all links for display
-	links, err := s.store.GetAllLinks()
+func (s *Server) htmxRenderPortalContent(w http.ResponseWriter, r *http.Request, successMessage, errorMessage, infoMessage string) {
+	// Get the first page of links for display
+	links, page, err := s.portalLinks(r.Context(), "", defaultLinkSort, 0)
 	if err != nil {
-		log.Printf("Error fetching links for portal: %v", err)
+		logRequestError(r, "Error fetching links for portal", err)
 		http.Error(w, "Failed to load links", http.StatusInternalServerError)
 		return
 	}
 
 	// Calculate dashboard stats
-	var mostRecentLink string
-	if len(links) > 0 {
-		mostRecentLink = links[len(links)-1].Path
+	mostPopularLink, err := s.mostPopularPath(r.Context())
+	if err != nil {
+		// The header simply leaves the most popular link out
+		logRequestError(r, "Error fetching most popular link", err)
 	}
 
 	// Prepare template data
@@ -553,20 +621,22 @@ func (s *Server) htmxRenderPortalContent(w http.ResponseWriter, r *http.Request,
 		PageDescription: "Manage your go links with ease",
 		ShowDashboard:   true,
 		Links:           links,
-		LinkCount:       len(links),
-		MostPopularLink: mostRecentLink,
+		Page:            page,
+		LinkCount:       page.Total,
+		MostPopularLink: mostPopularLink,
 		DatabaseStatus:  "OK",
 		ShowForm:        false,
 		EditMode:        false,
 		Errors:          make(map[string]string),
 		SuccessMessage:  successMessage,
 		ErrorMessage:    errorMessage,
+		InfoMessage:     infoMessage,
 	}
 
 	// Render the portal content template
-	err = s.templates.ExecuteTemplate(w, "content", data)
+	err = s.renderPortal(w, "content", data)
 	if err != nil {
-		log.Printf("Template execution error: %v", err)
+		logRequestError(r, "Template execution error", err)
 		http.Error(w, "Template rendering error", http.StatusInternalServerError)
 		return
 	}
@@ -582,18 +652,161 @@ func (s *Server) redirectHandler(w http.ResponseWriter, r *http.Request) {
 	// Strip the leading slash from the path to match database storage
 	path := strings.TrimPrefix(r.URL.Path, "/")
 
-	link, err := s.store.GetLinkByPath(path)
+	link, _, err := s.store.ResolveLink(r.Context(), path)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			http.NotFound(w, r)
+			s.handleMissingLink(w, r, path)
 			return
 		}
-		log.Printf("Database error: %v", err)
+		logRequestError(r, "Database error", err)
 		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 		return
 	}
 
-	http.Redirect(w, r, link.URL, http.StatusFound)
+	if !link.Enabled {
+		s.respondToState(w, r, LinkStateDisabled, path)
+		return
+	}
+	if link.isExpired(time.Now()) {
+		s.respondToState(w, r, LinkStateExpired, path)
+		return
+	}
+
+	// Chat unfurlers get a preview page instead of the redirect
+	if s.isUnfurlBot(r.UserAgent()) {
+		s.renderUnfurl(w, *link)
+		return
+	}
+
+	// Enforce the optional per-link rate limit
+	if link.RateLimit > 0 && !s.linkLimiter.Allow(link.ID, link.RateLimit) {
+		w.Header().Set("Retry-After", "60")
+		http.Error(w, "Too Many Requests", http.StatusTooManyRequests)
+		return
+	}
+
+	// Requested with ?preview=1, or by default with PREVIEW_DEFAULT. Only
+	// the redirect counts as a click: Continue goes straight to the target
+	// and Cancel goes back to the portal
+	if s.wantsPreview(r) {
+		s.renderPreview(w, r, link)
+		return
+	}
+
+	s.clicks.Record(ClickEvent{LinkID: link.ID, Referrer: r.Referer(), UserAgent: r.UserAgent()})
+	s.writeLinkRedirect(w, r, link)
+}
+
+// writeLinkRedirect redirects to the target of a link with the configured
+// status code and redirect and debug headers. Unless FORWARD_QUERY is off,
+// the request's query string is appended to the target.
+func (s *Server) writeLinkRedirect(w http.ResponseWriter, r *http.Request, link *Link) {
+	target := s.redirectTarget(r, link)
+
+	s.applyRedirectHeaders(w)
+	if s.config.DebugHeaders {
+		w.Header().Set("X-GoLink-Path", link.Path)
+		w.Header().Set("X-GoLink-Target", target)
+	}
+	http.Redirect(w, r, target, s.config.RedirectStatus)
+}
+
+// appendQuery adds a raw query string to a target URL, joining it with "&"
+// when the target already has query parameters and keeping any fragment last.
+func appendQuery(target, rawQuery string) string {
+	if rawQuery == "" {
+		return target
+	}
+
+	fragment := ""
+	if i := strings.Index(target, "#"); i != -1 {
+		target, fragment = target[:i], target[i:]
+	}
+
+	switch {
+	case !strings.Contains(target, "?"):
+		target += "?"
+	case !strings.HasSuffix(target, "?") && !strings.HasSuffix(target, "&"):
+		target += "&"
+	}
+	return target + rawQuery + fragment
+}
+
+// handleTestRedirect answers with the same redirect as the link's path would,
+// without recording a visit or click or consuming its rate limit, so link
+// checkers can probe targets without skewing usage statistics. The response
+// carries X-GoLink-Test so analytics can ignore it.
+// TestRedirect godoc
+// @Summary      Test a link's redirect
+// @Description  Redirect to the link's target like a real visit, without counting it
+// @Tags         links
+// @Param        id  path  int  true  "Link ID"
+// @Success      302
+// @Failure      404  {object}  ErrorResponse
+// @Router       /links/{id}/test-redirect [get]
+func (s *Server) handleTestRedirect(w http.ResponseWriter, r *http.Request, id int64) {
+	link, err := s.store.GetLinkByID(r.Context(), id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			writeErrorJSON(w, fmt.Sprintf("Link with id %d not found", id), http.StatusNotFound)
+			return
+		}
+		logRequestError(r, "API TestRedirect error", err, "id", id)
+		writeErrorJSON(w, "Failed to retrieve link", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("X-GoLink-Test", "true")
+	s.writeLinkRedirect(w, r, link)
+}
+
+// applyRedirectHeaders adds the configured REDIRECT_HEADERS to a redirect
+// response. Location is always set by the redirect itself.
+func (s *Server) applyRedirectHeaders(w http.ResponseWriter) {
+	for name, value := range s.config.RedirectHeaders {
+		w.Header().Set(name, value)
+	}
+}
+
+// handleMissingLink answers a request for a path with no active link,
+// distinguishing deleted links from paths that never existed.
+func (s *Server) handleMissingLink(w http.ResponseWriter, r *http.Request, path string) {
+	deleted, err := s.store.IsDeletedPath(r.Context(), path)
+	if err != nil {
+		logRequestError(r, "Database error", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	if deleted {
+		s.respondToState(w, r, LinkStateDeleted, path)
+		return
+	}
+	s.recordMiss(r.Context(), path)
+	if target, ok := s.catchAllTarget(r, path); ok {
+		s.applyRedirectHeaders(w)
+		http.Redirect(w, r, target, http.StatusFound)
+		return
+	}
+	s.renderNotFound(w, r, path)
+}
+
+// catchAllTarget returns the configured catch-all URL for an unmatched path.
+// It declines for the root and reserved paths, and when the catch-all points
+// back at this server, which would loop.
+func (s *Server) catchAllTarget(r *http.Request, path string) (string, bool) {
+	if s.config.CatchAllURL == "" || path == "" {
+		return "", false
+	}
+	if isReservedPath(strings.SplitN(path, "/", 2)[0]) {
+		return "", false
+	}
+
+	target := strings.ReplaceAll(s.config.CatchAllURL, "{path}", url.QueryEscape(path))
+	if u, err := url.Parse(target); err != nil || strings.EqualFold(u.Host, r.Host) {
+		slog.Warn("Catch-all URL points back at this server; returning 404", "url", s.config.CatchAllURL)
+		return "", false
+	}
+	return target, true
 }
 
 // PortalData holds data for the portal template.
@@ -603,6 +816,7 @@ type PortalData struct {
 	PageDescription string
 	ShowDashboard   bool
 	Links           []Link
+	Page            LinkPage
 	LinkCount       int
 	MostPopularLink string
 	DatabaseStatus  string
@@ -614,6 +828,7 @@ type PortalData struct {
 	SuccessMessage  string
 	ErrorMessage    string
 	InfoMessage     string
+	Brand           BrandData
 }
 
 // goPortalHandler serves the main management UI.
@@ -633,48 +848,37 @@ func (s *Server) goPortalHandler(w http.ResponseWriter, r *http.Request) {
 func (s *Server) handlePortalGet(w http.ResponseWriter, r *http.Request) {
 	// Get search query if any
 	searchQuery := r.URL.Query().Get("search")
-	
-	// Get all links from the database
-	links, err := s.store.GetAllLinks()
+
+	// Get the requested page of matching links
+	links, page, err := s.portalLinks(r.Context(), searchQuery, portalSort(r), portalOffset(r))
 	if err != nil {
-		log.Printf("Error fetching links for portal: %v", err)
+		logRequestError(r, "Error fetching links for portal", err)
 		writeErrorJSON(w, "Failed to load links", http.StatusInternalServerError)
 		return
 	}
 
-	// Filter links if search query provided
-	if searchQuery != "" {
-		filteredLinks := []Link{}
-		for _, link := range links {
-			if strings.Contains(strings.ToLower(link.Path), strings.ToLower(searchQuery)) ||
-			   strings.Contains(strings.ToLower(link.URL), strings.ToLower(searchQuery)) {
-				filteredLinks = append(filteredLinks, link)
-			}
-		}
-		links = filteredLinks
-	}
-
 	// Calculate dashboard stats
-	var mostRecentLink string
-	if len(links) > 0 {
-		// Use the last link in the sorted list as "most recent"
-		// (links are ordered by path, so we'll use the last one for now)
-		mostRecentLink = links[len(links)-1].Path
+	mostPopularLink, err := s.mostPopularPath(r.Context())
+	if err != nil {
+		// The header simply leaves the most popular link out
+		logRequestError(r, "Error fetching most popular link", err)
 	}
 
 	// Check for messages in URL
 	successMessage := r.URL.Query().Get("success")
 	errorMessage := r.URL.Query().Get("error")
+	infoMessage := r.URL.Query().Get("info")
 
 	// Prepare template data
 	data := PortalData{
 		Title:           "Portal",
-		PageHeader:      "Link Management Portal", 
+		PageHeader:      "Link Management Portal",
 		PageDescription: "Manage your go links with ease",
 		ShowDashboard:   true,
 		Links:           links,
-		LinkCount:       len(links),
-		MostPopularLink: mostRecentLink,
+		Page:            page,
+		LinkCount:       page.Total,
+		MostPopularLink: mostPopularLink,
 		DatabaseStatus:  "OK",
 		SearchQuery:     searchQuery,
 		ShowForm:        false,
@@ -682,12 +886,19 @@ func (s *Server) handlePortalGet(w http.ResponseWriter, r *http.Request) {
 		Errors:          make(map[string]string),
 		SuccessMessage:  successMessage,
 		ErrorMessage:    errorMessage,
+		InfoMessage:     infoMessage,
+	}
+
+	// Open the create form for ?new=<path>, e.g. from the not found page
+	if newPath := normalizePath(r.URL.Query().Get("new")); newPath != "" {
+		data.ShowForm = true
+		data.Link = Link{Path: newPath}
 	}
 
 	// Render the portal template
-	err = s.templates.ExecuteTemplate(w, "base.html", data)
+	err = s.renderPortal(w, "base.html", data)
 	if err != nil {
-		log.Printf("Template execution error: %v", err)
+		logRequestError(r, "Template execution error", err)
 		writeErrorJSON(w, "Template rendering error", http.StatusInternalServerError)
 		return
 	}
@@ -695,44 +906,69 @@ func (s *Server) handlePortalGet(w http.ResponseWriter, r *http.Request) {
 
 // handlePortalPost handles form submissions for creating links
 func (s *Server) handlePortalPost(w http.ResponseWriter, r *http.Request) {
+	if !s.allowCreate(w, r) {
+		return
+	}
+
 	// Parse form data
 	err := r.ParseForm()
 	if err != nil {
-		log.Printf("Error parsing form: %v", err)
+		logRequestError(r, "Error parsing form", err)
 		http.Error(w, "Bad request", http.StatusBadRequest)
 		return
 	}
 
-	// Get form values
-	path := strings.TrimSpace(r.FormValue("path"))
-	url := strings.TrimSpace(r.FormValue("url"))
-
-	// Create link object for validation
-	link := Link{
-		Path: path,
-		URL:  url,
+	// A repeated submit of a form that already created its link is treated
+	// as a success rather than a duplicate path error
+	token := r.FormValue("submit_token")
+	if prior := s.submissions.begin(token); prior != nil {
+		if prior.wait() {
+			http.Redirect(w, r, "/go?success=Link created successfully", http.StatusSeeOther)
+			return
+		}
+		token = ""
 	}
+	created := false
+	defer func() { s.submissions.finish(token, created) }()
+
+	// Get form values
+	link, errors := linkFromForm(r)
+	link.CreatedBy = requestCreator(r)
 
 	// Validate the link
-	errors := make(map[string]string)
-	if err := validateLink(link); err != nil {
-		// Parse validation error
+	if err := s.validateLink(r.Context(), link); err != nil {
 		errors["General"] = err.Error()
 	}
+	if warning := s.softReservedWarning(link.Path); warning != "" && !isForced(r) {
+		errors["Force"] = warning
+	}
+
+	if len(errors) == 0 {
+		if err := s.runCreateHook(r.Context(), link); err != nil {
+			errors["General"] = err.Error()
+		}
+	}
 
 	// If validation passes, create the link
 	if len(errors) == 0 {
-		err = s.store.CreateLink(path, url)
+		duplicates := s.duplicateURLWarning(r, link)
+		err = s.store.CreateLink(r.Context(), link)
 		if err != nil {
-			log.Printf("Error creating link: %v", err)
+			logRequestError(r, "Error creating link", err)
 			if strings.Contains(err.Error(), "already exists") {
 				errors["Path"] = err.Error()
 			} else {
 				errors["General"] = "Failed to create link"
 			}
 		} else {
+			created = true
+			s.notifyCreated(r.Context(), link.Path)
 			// Success - redirect to avoid resubmission
-			http.Redirect(w, r, "/go?success=Link created successfully", http.StatusSeeOther)
+			target := "/go?success=Link created successfully"
+			if duplicates != "" {
+				target += "&info=" + url.QueryEscape(duplicates)
+			}
+			http.Redirect(w, r, target, http.StatusSeeOther)
 			return
 		}
 	}
@@ -743,25 +979,26 @@ func (s *Server) handlePortalPost(w http.ResponseWriter, r *http.Request) {
 
 // renderPortalWithForm renders the portal with the form visible and any messages
 func (s *Server) renderPortalWithForm(w http.ResponseWriter, r *http.Request, link Link, errors map[string]string, showForm bool, editMode bool, successMessage string) {
-	// Get all links for display
-	links, err := s.store.GetAllLinks()
+	// Get the first page of links for display
+	links, page, err := s.portalLinks(r.Context(), "", defaultLinkSort, 0)
 	if err != nil {
-		log.Printf("Error fetching links for portal: %v", err)
+		logRequestError(r, "Error fetching links for portal", err)
 		writeErrorJSON(w, "Failed to load links", http.StatusInternalServerError)
 		return
 	}
 
 	// Calculate dashboard stats
-	var mostRecentLink string
-	if len(links) > 0 {
-		mostRecentLink = links[len(links)-1].Path
+	mostPopularLink, err := s.mostPopularPath(r.Context())
+	if err != nil {
+		// The header simply leaves the most popular link out
+		logRequestError(r, "Error fetching most popular link", err)
 	}
 
 	// Check for success message in URL
 	if successMessage == "" {
 		successMessage = r.URL.Query().Get("success")
 	}
-	
+
 	// Check for error message in URL
 	errorMessage := r.URL.Query().Get("error")
 
@@ -772,8 +1009,9 @@ func (s *Server) renderPortalWithForm(w http.ResponseWriter, r *http.Request, li
 		PageDescription: "Manage your go links with ease",
 		ShowDashboard:   true,
 		Links:           links,
-		LinkCount:       len(links),
-		MostPopularLink: mostRecentLink,
+		Page:            page,
+		LinkCount:       page.Total,
+		MostPopularLink: mostPopularLink,
 		DatabaseStatus:  "OK",
 		ShowForm:        showForm,
 		EditMode:        editMode,
@@ -784,9 +1022,9 @@ func (s *Server) renderPortalWithForm(w http.ResponseWriter, r *http.Request, li
 	}
 
 	// Render the portal template
-	err = s.templates.ExecuteTemplate(w, "base.html", data)
+	err = s.renderPortal(w, "base.html", data)
 	if err != nil {
-		log.Printf("Template execution error: %v", err)
+		logRequestError(r, "Template execution error", err)
 		writeErrorJSON(w, "Template rendering error", http.StatusInternalServerError)
 		return
 	}
@@ -800,6 +1038,19 @@ func (s *Server) apiRouter(w http.ResponseWriter, r *http.Request) {
 		http.NotFound(w, r)
 		return
 	}
+	if !s.requireWriteAuth(w, r) {
+		return
+	}
+
+	// /api/links/resolve
+	if len(parts) == 3 && parts[2] == "resolve" {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		s.handleResolveLink(w, r)
+		return
+	}
 
 	// /api/links/{id}
 	if len(parts) == 3 {
@@ -836,6 +1087,8 @@ func (s *Server) apiLinksHandler(w http.ResponseWriter, r *http.Request) {
 // apiLinkIDHandler handles requests for a specific link by its ID.
 func (s *Server) apiLinkIDHandler(w http.ResponseWriter, r *http.Request, id int64) {
 	switch r.Method {
+	case http.MethodGet:
+		s.handleGetLink(w, r, id)
 	case http.MethodPut:
 		s.handleUpdateLink(w, r, id)
 	case http.MethodDelete:
@@ -845,32 +1098,233 @@ func (s *Server) apiLinkIDHandler(w http.ResponseWriter, r *http.Request, id int
 	}
 }
 
-// handleGetLinks retrieves all links and returns them as JSON.
+// handleGetLinks returns a page of links as JSON, optionally only those with
+// a tag, with the total number of matching links in X-Total-Count.
 // GetLinks godoc
 // @Summary      List links
-// @Description  Retrieve all stored links
+// @Description  Retrieve a page of stored links, by default ordered by path; the total is returned in X-Total-Count
 // @Tags         links
 // @Produce      json
+// @Param        limit   query  int     false  "Page size (default 50, max 500)"
+// @Param        offset  query  int     false  "Number of links to skip"
+// @Param        sort    query  string  false  "path, clicks or created (default path)"
+// @Param        order   query  string  false  "asc or desc (default asc)"
+// @Param        tag     query  string  false  "Only links with this tag"
+// @Param        owner   query  string  false  "Only links owned or created by this user"
 // @Success      200  {array}   Link
+// @Failure      400  {object}  ErrorResponse
 // @Router       /links [get]
 func (s *Server) handleGetLinks(w http.ResponseWriter, r *http.Request) {
-	links, err := s.store.GetAllLinks()
+	limit, offset, err := parsePage(r, defaultLinksLimit, maxLinksLimit)
 	if err != nil {
-		log.Printf("API GetLinks error: %v", err)
+		writeErrorJSON(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if tag := r.URL.Query().Get("tag"); tag != "" {
+		s.handleGetLinksByTag(w, r, tag, limit, offset)
+		return
+	}
+	if owner := strings.TrimSpace(r.URL.Query().Get("owner")); owner != "" {
+		links, err := s.store.GetLinksByOwner(r.Context(), owner, parseSortQuery(r))
+		if err != nil {
+			logRequestError(r, "API GetLinks error", err)
+			writeErrorJSON(w, "Failed to retrieve links", http.StatusInternalServerError)
+			return
+		}
+		writeLinkPage(w, links, limit, offset)
+		return
+	}
+
+	total, err := s.store.CountLinks(r.Context())
+	if err != nil {
+		logRequestError(r, "API GetLinks error", err)
+		writeErrorJSON(w, "Failed to retrieve links", http.StatusInternalServerError)
+		return
+	}
+	links, err := s.store.GetLinksPaged(r.Context(), parseSortQuery(r), limit, offset)
+	if err != nil {
+		logRequestError(r, "API GetLinks error", err)
+		writeErrorJSON(w, "Failed to retrieve links", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Total-Count", strconv.FormatInt(total, 10))
+	json.NewEncoder(w).Encode(links)
+}
+
+// handleGetLinksByTag writes the page of links carrying tag for
+// handleGetLinks.
+func (s *Server) handleGetLinksByTag(w http.ResponseWriter, r *http.Request, tag string, limit, offset int) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if err := validateTag(tag); err != nil {
+		writeErrorJSON(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	links, err := s.store.GetLinksByTag(r.Context(), tag, parseSortQuery(r))
+	if err != nil {
+		logRequestError(r, "API GetLinks error", err)
 		writeErrorJSON(w, "Failed to retrieve links", http.StatusInternalServerError)
 		return
 	}
+	writeLinkPage(w, links, limit, offset)
+}
+
+// writeLinkPage writes the page of a filtered link listing at offset, with
+// the number of matching links in X-Total-Count.
+func writeLinkPage(w http.ResponseWriter, links []Link, limit, offset int) {
+	total := len(links)
+	links = links[min(offset, total):min(offset+limit, total)]
 
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
 	json.NewEncoder(w).Encode(links)
 }
 
+// LinkChanges is the response of the incremental sync endpoint.
+type LinkChanges struct {
+	Links   []Link    `json:"links"`
+	Deleted []int64   `json:"deleted"`
+	Until   time.Time `json:"until"`
+}
+
+// handleGetLinkChanges returns links changed or deleted since a timestamp.
+// GetLinkChanges godoc
+// @Summary      Link changes
+// @Description  Links updated and IDs deleted after "since"; poll again with the returned "until"
+// @Tags         links
+// @Produce      json
+// @Param        since  query  string  true  "RFC 3339 timestamp"
+// @Success      200  {object}  LinkChanges
+// @Failure      400  {object}  ErrorResponse
+// @Router       /links/changes [get]
+func (s *Server) handleGetLinkChanges(w http.ResponseWriter, r *http.Request) {
+	sinceParam := r.URL.Query().Get("since")
+	if sinceParam == "" {
+		writeErrorJSON(w, "since is required", http.StatusBadRequest)
+		return
+	}
+	since, err := time.Parse(time.RFC3339, sinceParam)
+	if err != nil {
+		writeErrorJSON(w, "since must be an RFC 3339 timestamp", http.StatusBadRequest)
+		return
+	}
+
+	until := time.Now().UTC().Truncate(time.Millisecond)
+	links, deleted, err := s.store.GetChangedSince(r.Context(), since, until)
+	if err != nil {
+		logRequestError(r, "API GetLinkChanges error", err)
+		writeErrorJSON(w, "Failed to retrieve link changes", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(LinkChanges{
+		Links:   links,
+		Deleted: deleted,
+		Until:   until,
+	})
+}
+
+// maxVisitCountIDs caps the number of IDs accepted by the bulk visits endpoint.
+const maxVisitCountIDs = 500
+
+// handleGetVisitCounts returns visit counts for many links in one call.
+// GetVisitCounts godoc
+// @Summary      Bulk visit counts
+// @Description  Map of link ID to visit count for the given IDs, or all links when ids is omitted
+// @Tags         stats
+// @Produce      json
+// @Param        ids  query  string  false  "Comma-separated link IDs"
+// @Success      200  {object}  map[string]int64
+// @Failure      400  {object}  ErrorResponse
+// @Router       /links/visits [get]
+func (s *Server) handleGetVisitCounts(w http.ResponseWriter, r *http.Request) {
+	var ids []int64
+	for _, idStr := range splitList(r.URL.Query().Get("ids")) {
+		id, err := strconv.ParseInt(idStr, 10, 64)
+		if err != nil {
+			writeErrorJSON(w, fmt.Sprintf("Invalid link ID '%s'", idStr), http.StatusBadRequest)
+			return
+		}
+		ids = append(ids, id)
+	}
+	if len(ids) > maxVisitCountIDs {
+		writeErrorJSON(w, fmt.Sprintf("At most %d ids can be requested at once", maxVisitCountIDs), http.StatusBadRequest)
+		return
+	}
+
+	counts, err := s.store.GetVisitCounts(r.Context(), ids)
+	if err != nil {
+		logRequestError(r, "API GetVisitCounts error", err)
+		writeErrorJSON(w, "Failed to retrieve visit counts", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(counts)
+}
+
+// handleGetNeverUsedLinks lists links that have never served a redirect.
+// GetNeverUsedLinks godoc
+// @Summary      Never-used links
+// @Description  Links without a single visit, oldest first; older_than skips recently created links
+// @Tags         stats
+// @Produce      json
+// @Param        older_than  query  string  false  "Minimum link age, e.g. 30d or 12h"
+// @Success      200  {array}   Link
+// @Failure      400  {object}  ErrorResponse
+// @Router       /links/never-used [get]
+func (s *Server) handleGetNeverUsedLinks(w http.ResponseWriter, r *http.Request) {
+	var olderThan time.Duration
+	if value := r.URL.Query().Get("older_than"); value != "" {
+		age, err := parseAge(value)
+		if err != nil {
+			writeErrorJSON(w, fmt.Sprintf("Invalid 'older_than' value '%s': use a duration such as 30d or 12h", value), http.StatusBadRequest)
+			return
+		}
+		olderThan = age
+	}
+
+	links, err := s.store.GetNeverUsedLinks(r.Context(), time.Now().Add(-olderThan))
+	if err != nil {
+		logRequestError(r, "API GetNeverUsedLinks error", err)
+		writeErrorJSON(w, "Failed to retrieve links", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(links)
+}
+
+// parseAge parses a non-negative duration, additionally accepting whole days
+// such as "30d".
+func parseAge(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid number of days '%s'", days)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	age, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if age < 0 {
+		return 0, fmt.Errorf("duration cannot be negative")
+	}
+	return age, nil
+}
+
 // handleCreateLink creates a new link from the request body.
 // CreateLink godoc
 // @Summary      Create a link
 // @Description  Create a new link
 // @Tags         links
-// @Accept       json
+// @Accept       json,x-www-form-urlencoded
 // @Produce      json
 // @Param        link  body      Link  true  "Link payload"
 // @Success      201
@@ -878,19 +1332,35 @@ func (s *Server) handleGetLinks(w http.ResponseWriter, r *http.Request) {
 // @Failure      500  {string}  string  "Failed to create link"
 // @Router       /links [post]
 func (s *Server) handleCreateLink(w http.ResponseWriter, r *http.Request) {
-	var link Link
-	if err := json.NewDecoder(r.Body).Decode(&link); err != nil {
-		writeErrorJSON(w, "Invalid request body", http.StatusBadRequest)
+	if !s.allowCreate(w, r) {
+		return
+	}
+	link, err := decodeLink(r)
+	if err != nil {
+		writeErrorJSON(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	link.CreatedBy = requestCreator(r)
 
-	if err := validateLink(link); err != nil {
+	if err := s.validateLink(r.Context(), link); err != nil {
 		writeErrorJSON(w, err.Error(), http.StatusUnprocessableEntity)
 		return
 	}
 
-	if err := s.store.CreateLink(link.Path, link.URL); err != nil {
-		log.Printf("API CreateLink error: %v", err)
+	warning := s.softReservedWarning(link.Path)
+	if warning != "" && !isForced(r) {
+		writeErrorJSON(w, warning+"; retry with ?force=true to use it anyway", http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err := s.runCreateHook(r.Context(), link); err != nil {
+		writeErrorJSON(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	duplicates := s.duplicateURLWarning(r, link)
+	if err := s.store.CreateLink(r.Context(), link); err != nil {
+		logRequestError(r, "API CreateLink error", err)
 		// Check if it's a user-friendly error (like duplicate path)
 		if strings.Contains(err.Error(), "already exists") {
 			writeErrorJSON(w, err.Error(), http.StatusConflict)
@@ -900,7 +1370,8 @@ func (s *Server) handleCreateLink(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	w.WriteHeader(http.StatusCreated)
+	s.notifyCreated(r.Context(), link.Path)
+	writeWarningsJSON(w, http.StatusCreated, warning, duplicates)
 }
 
 // handleUpdateLink updates an existing link.
@@ -908,7 +1379,7 @@ func (s *Server) handleCreateLink(w http.ResponseWriter, r *http.Request) {
 // @Summary      Update a link
 // @Description  Update an existing link by ID
 // @Tags         links
-// @Accept       json
+// @Accept       json,x-www-form-urlencoded
 // @Produce      json
 // @Param        id    path      int   true  "Link ID"
 // @Param        link  body      Link  true  "Link payload"
@@ -918,9 +1389,9 @@ func (s *Server) handleCreateLink(w http.ResponseWriter, r *http.Request) {
 // @Router       /links/{id} [put]
 func (s *Server) handleUpdateLink(w http.ResponseWriter, r *http.Request, id int64) {
 	// Check if link exists first
-	exists, err := s.store.LinkExists(id)
+	exists, err := s.store.LinkExists(r.Context(), id)
 	if err != nil {
-		log.Printf("API UpdateLink existence check error: %v", err)
+		logRequestError(r, "API UpdateLink existence check error", err, "id", id)
 		writeErrorJSON(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
@@ -929,19 +1400,25 @@ func (s *Server) handleUpdateLink(w http.ResponseWriter, r *http.Request, id int
 		return
 	}
 
-	var link Link
-	if err := json.NewDecoder(r.Body).Decode(&link); err != nil {
-		writeErrorJSON(w, "Invalid request body", http.StatusBadRequest)
+	link, err := decodeLink(r)
+	if err != nil {
+		writeErrorJSON(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	if err := validateLink(link); err != nil {
+	if err := s.validateLink(r.Context(), link); err != nil {
 		writeErrorJSON(w, err.Error(), http.StatusUnprocessableEntity)
 		return
 	}
 
-	if err := s.store.UpdateLink(id, link.Path, link.URL); err != nil {
-		log.Printf("API UpdateLink error: %v", err)
+	warning := s.softReservedWarning(link.Path)
+	if warning != "" && !isForced(r) {
+		writeErrorJSON(w, warning+"; retry with ?force=true to use it anyway", http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err := s.store.UpdateLink(r.Context(), id, link); err != nil {
+		logRequestError(r, "API UpdateLink error", err, "id", id)
 		// Check if it's a user-friendly error (like duplicate path)
 		if strings.Contains(err.Error(), "already exists") {
 			writeErrorJSON(w, err.Error(), http.StatusConflict)
@@ -951,7 +1428,69 @@ func (s *Server) handleUpdateLink(w http.ResponseWriter, r *http.Request, id int
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
+	s.linkLimiter.Reset(id)
+	s.notifyUpdated(r.Context(), id)
+	writeWarningsJSON(w, http.StatusOK, warning)
+}
+
+// handleGetLink retrieves a single link by its ID.
+// GetLink godoc
+// @Summary      Get a link
+// @Description  Retrieve a link by ID
+// @Tags         links
+// @Produce      json
+// @Param        id  path  int  true  "Link ID"
+// @Success      200  {object}  Link
+// @Failure      404  {object}  ErrorResponse
+// @Router       /links/{id} [get]
+func (s *Server) handleGetLink(w http.ResponseWriter, r *http.Request, id int64) {
+	link, err := s.store.GetLinkByID(r.Context(), id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			writeErrorJSON(w, fmt.Sprintf("Link with id %d not found", id), http.StatusNotFound)
+			return
+		}
+		logRequestError(r, "API GetLink error", err, "id", id)
+		writeErrorJSON(w, "Failed to retrieve link", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(link)
+}
+
+// handleResolveLink returns the link stored under an exact path, without
+// following the redirect.
+// ResolveLink godoc
+// @Summary      Get a link by path
+// @Description  Retrieve the link stored under an exact path without redirecting
+// @Tags         links
+// @Produce      json
+// @Param        path  query  string  true  "Link path"
+// @Success      200  {object}  Link
+// @Failure      400  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Router       /links/resolve [get]
+func (s *Server) handleResolveLink(w http.ResponseWriter, r *http.Request) {
+	path := normalizePath(r.URL.Query().Get("path"))
+	if path == "" {
+		writeErrorJSON(w, "Query parameter 'path' is required", http.StatusBadRequest)
+		return
+	}
+
+	link, err := s.store.GetLinkByPath(r.Context(), path)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			writeErrorJSON(w, fmt.Sprintf("Link with path '%s' not found", path), http.StatusNotFound)
+			return
+		}
+		logRequestError(r, "API ResolveLink error", err)
+		writeErrorJSON(w, "Failed to retrieve link", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(link)
 }
 
 // handleDeleteLink deletes a link by its ID.
@@ -964,8 +1503,9 @@ func (s *Server) handleUpdateLink(w http.ResponseWriter, r *http.Request, id int
 // @Failure      500  {string}  string  "Failed to delete link"
 // @Router       /links/{id} [delete]
 func (s *Server) handleDeleteLink(w http.ResponseWriter, r *http.Request, id int64) {
-	if err := s.store.DeleteLink(id); err != nil {
-		log.Printf("API DeleteLink error: %v", err)
+	deleted := s.linkBeforeDelete(r.Context(), id)
+	if err := s.store.DeleteLink(r.Context(), id); err != nil {
+		logRequestError(r, "API DeleteLink error", err, "id", id)
 		// Check if it's a "not found" error
 		if strings.Contains(err.Error(), "not found") {
 			writeErrorJSON(w, err.Error(), http.StatusNotFound)
@@ -975,15 +1515,242 @@ func (s *Server) handleDeleteLink(w http.ResponseWriter, r *http.Request, id int
 		return
 	}
 
+	s.notifyDeleted(deleted)
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// decodeLink reads a Link from a JSON or form-encoded request body, so the
+// API endpoints accept the same submissions as the portal.
+func decodeLink(r *http.Request) (Link, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "application/x-www-form-urlencoded":
+		if err := r.ParseForm(); err != nil {
+			return Link{}, fmt.Errorf("Invalid request body")
+		}
+		link, fieldErrors := linkFromForm(r)
+		for _, message := range fieldErrors {
+			return Link{}, fmt.Errorf("%s", message)
+		}
+		return link, nil
+	default:
+		var link Link
+		if err := decodeJSONStrict(r.Body, &link); err != nil {
+			return Link{}, err
+		}
+		normalizeLink(&link)
+		return link, nil
+	}
+}
+
+// decodeJSONStrict decodes a single JSON value from body into v. Unlike a
+// plain json.Decoder it rejects unknown fields, so a typo such as "urls" is
+// reported instead of silently dropped, and anything after the value. The
+// errors are meant for the client and name the offending field.
+func decodeJSONStrict(body io.Reader, v interface{}) error {
+	decoder := json.NewDecoder(body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		var syntaxErr *json.SyntaxError
+		var typeErr *json.UnmarshalTypeError
+		switch {
+		case errors.Is(err, io.EOF):
+			return fmt.Errorf("Invalid request body: empty body")
+		case errors.Is(err, io.ErrUnexpectedEOF):
+			return fmt.Errorf("Invalid request body: unexpected end of JSON")
+		case errors.As(err, &syntaxErr):
+			return fmt.Errorf("Invalid request body: malformed JSON at byte %d", syntaxErr.Offset)
+		case errors.As(err, &typeErr) && typeErr.Field != "":
+			return fmt.Errorf("Invalid request body: field \"%s\" must be %s", typeErr.Field, jsonKind(typeErr.Type))
+		case strings.HasPrefix(err.Error(), "json: unknown field "):
+			return fmt.Errorf("Invalid request body: unknown field %s", strings.TrimPrefix(err.Error(), "json: unknown field "))
+		}
+		return fmt.Errorf("Invalid request body: %v", err)
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return fmt.Errorf("Invalid request body: unexpected data after the JSON object")
+	}
+	return nil
+}
+
+// jsonKind describes the JSON value expected for a Go type, e.g. "a string".
+func jsonKind(t reflect.Type) string {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == reflect.TypeOf(time.Time{}) {
+		return "an RFC 3339 timestamp"
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	}
+	return "an object"
+}
+
+// linkFromForm builds a Link from submitted portal form values. Fields that
+// fail to parse are reported in the returned errors map keyed by field name.
+func linkFromForm(r *http.Request) (Link, map[string]string) {
+	errors := make(map[string]string)
+	link := Link{
+		Path:        r.FormValue("path"),
+		URL:         r.FormValue("url"),
+		Owner:       r.FormValue("owner"),
+		Group:       r.FormValue("group"),
+		Description: r.FormValue("description"),
+		Tags:        splitList(r.FormValue("tags")),
+	}
+	normalizeLink(&link)
+	link.Prefix, _ = strconv.ParseBool(r.FormValue("prefix"))
+
+	expiresAt, err := parseExpiry(strings.TrimSpace(r.FormValue("expires_at")))
+	if err != nil {
+		errors["ExpiresAt"] = err.Error()
+	}
+	link.ExpiresAt = expiresAt
+
+	if rateLimit := strings.TrimSpace(r.FormValue("rate_limit")); rateLimit != "" {
+		value, err := strconv.Atoi(rateLimit)
+		if err != nil {
+			errors["RateLimit"] = "rate limit must be a whole number"
+		}
+		link.RateLimit = value
+	}
+
+	return link, errors
+}
+
+// handleGetConfig returns the effective configuration with secrets redacted.
+// GetConfig godoc
+// @Summary      Server configuration
+// @Description  Effective configuration with secrets redacted (admin only)
+// @Tags         admin
+// @Produce      json
+// @Success      200  {object}  RedactedConfig
+// @Failure      401  {object}  ErrorResponse
+// @Router       /config [get]
+func (s *Server) handleGetConfig(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(s.config.Redacted())
+}
+
+// TransferRequest is the payload of the ownership transfer endpoint.
+type TransferRequest struct {
+	From string  `json:"from"`
+	To   string  `json:"to"`
+	IDs  []int64 `json:"ids,omitempty"`
+}
+
+// TransferResponse reports how many links changed owner.
+type TransferResponse struct {
+	Transferred int `json:"transferred"`
+}
+
+// handleTransferOwnership reassigns links from one owner to another.
+// TransferOwnership godoc
+// @Summary      Transfer link ownership
+// @Description  Reassign all (or the listed) links owned by "from" to "to" (admin only)
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        transfer  body      TransferRequest  true  "Transfer payload"
+// @Success      200  {object}  TransferResponse
+// @Failure      400  {object}  ErrorResponse
+// @Router       /links/transfer [post]
+func (s *Server) handleTransferOwnership(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+
+	// A misspelled "ids" must not widen the transfer to every link of the
+	// owner, so unknown fields are rejected
+	var req TransferRequest
+	if err := decodeJSONStrict(r.Body, &req); err != nil {
+		writeErrorJSON(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	req.From = strings.TrimSpace(req.From)
+	req.To = strings.TrimSpace(req.To)
+	if req.From == "" || req.To == "" {
+		writeErrorJSON(w, "from and to are required", http.StatusUnprocessableEntity)
+		return
+	}
+	if req.From == req.To {
+		writeErrorJSON(w, "from and to must differ", http.StatusUnprocessableEntity)
+		return
+	}
+
+	count, err := s.store.TransferOwnership(r.Context(), req.From, req.To, req.IDs)
+	if err != nil {
+		logRequestError(r, "API TransferOwnership error", err)
+		writeErrorJSON(w, "Failed to transfer links", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(TransferResponse{Transferred: count})
+}
+
+// validateLink applies the general link rules of validateLink plus the
+// deployment-specific rules from the server configuration. ctx bounds the
+// lookup of the target host when private targets are blocked.
+func (s *Server) validateLink(ctx context.Context, link Link) error {
+	if err := validateLink(link); err != nil {
+		return err
+	}
+	if err := s.validatePath(link.Path); err != nil {
+		return err
+	}
+	if err := s.checkAllowedDomain(link.URL); err != nil {
+		return err
+	}
+	return s.checkPrivateTarget(ctx, link.URL)
+}
+
+// validatePath applies validatePath plus the deployment-specific path rules
+// from the server configuration.
+func (s *Server) validatePath(path string) error {
+	if err := validatePath(path); err != nil {
+		return err
+	}
+
+	// Reject purely numeric paths, which are easily confused with link IDs
+	if s.config.DisallowNumericPaths && numericPathPattern.MatchString(strings.TrimSpace(path)) {
+		return fmt.Errorf("path cannot consist only of digits")
+	}
+
+	return nil
+}
+
+// numericPathPattern matches paths made up solely of digits.
+var numericPathPattern = regexp.MustCompile(`^[0-9]+$`)
+
+// maxDescriptionLength caps the length of a link description in characters.
+const maxDescriptionLength = 500
+
 // validateLink ensures the link payload has a valid path and HTTP/HTTPS URL.
 func validateLink(link Link) error {
 	// Validate path
 	if err := validatePath(link.Path); err != nil {
 		return err
 	}
+	if err := validateTemplateURL(normalizePath(link.Path), link.URL); err != nil {
+		return err
+	}
 
 	// Validate URL
 	if strings.TrimSpace(link.URL) == "" {
@@ -999,9 +1766,52 @@ func validateLink(link Link) error {
 	if u.Host == "" {
 		return fmt.Errorf("url host is required")
 	}
-	return nil
+
+	// Validate rate limit (0 means unlimited)
+	if link.RateLimit < 0 {
+		return fmt.Errorf("rate limit cannot be negative")
+	}
+
+	if err := validateGroup(link.Group); err != nil {
+		return err
+	}
+
+	if n := utf8.RuneCountInString(link.Description); n > maxDescriptionLength {
+		return fmt.Errorf("description is too long: %d characters, at most %d allowed", n, maxDescriptionLength)
+	}
+
+	return validateTags(link.Tags)
+}
+
+// normalizePath trims surrounding whitespace and a single leading and trailing
+// slash, so "/deploy" and "deploy" refer to the same link. Embedded slashes
+// separate the segments of multi-segment paths such as "team/deploy".
+func normalizePath(path string) string {
+	path = strings.TrimSpace(path)
+	path = strings.TrimPrefix(path, "/")
+	path = strings.TrimSuffix(path, "/")
+	return path
+}
+
+// normalizeLink cleans up the user-editable fields of a submitted link the
+// same way for every entry point, the portal form as well as JSON bodies:
+// surrounding whitespace is trimmed, the path loses its slashes and is
+// lowercased like stored paths, and group and tags are normalized.
+func normalizeLink(link *Link) {
+	link.Path = strings.ToLower(normalizePath(link.Path))
+	link.URL = strings.TrimSpace(link.URL)
+	link.Owner = strings.TrimSpace(link.Owner)
+	link.Group = normalizeGroup(link.Group)
+	link.Description = strings.TrimSpace(link.Description)
+	link.Tags = normalizeTags(link.Tags)
 }
 
+// maxPathSegments caps the depth of multi-segment paths.
+const maxPathSegments = 5
+
+// pathSegmentPattern matches a single path segment.
+var pathSegmentPattern = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
 // validatePath ensures the path follows allowed format rules and isn't reserved.
 func validatePath(path string) error {
 	// Trim whitespace
@@ -1015,24 +1825,64 @@ func validatePath(path string) error {
 		return fmt.Errorf("path must be 50 characters or less")
 	}
 
-	// Format validation (alphanumeric, hyphens, underscores only)
+	// A templated link's final {*} segment is checked separately, against
+	// its URL
+	path = strings.TrimSuffix(path, "/"+templateToken)
+
+	// Segment validation: "/" separates segments, each limited to
+	// alphanumerics, hyphens and underscores.
 	// Allow both uppercase and lowercase, but we'll normalize to lowercase in storage
-	if !regexp.MustCompile(`^[a-zA-Z0-9_-]+$`).MatchString(path) {
-		return fmt.Errorf("path can only contain letters, numbers, hyphens, and underscores")
+	segments := strings.Split(path, "/")
+	if len(segments) > maxPathSegments {
+		return fmt.Errorf("path can have at most %d segments", maxPathSegments)
 	}
-
-	// Check for reserved words (case-insensitive)
-	pathLower := strings.ToLower(path)
-	reserved := []string{"api", "swagger", "go", "favicon.ico", "robots.txt"}
-	for _, word := range reserved {
-		if pathLower == word {
-			return fmt.Errorf("'%s' is a reserved path", path)
+	for _, segment := range segments {
+		if !pathSegmentPattern.MatchString(segment) {
+			return fmt.Errorf("path can only contain letters, numbers, hyphens, and underscores, with single slashes between segments")
 		}
 	}
 
+	// Check for reserved words (case-insensitive). Only the first segment is
+	// checked so "go/..." and "api/..." never shadow the portal and API routes.
+	if isReservedPath(segments[0]) {
+		return fmt.Errorf("'%s' is a reserved path", segments[0])
+	}
+
 	return nil
 }
 
+// reservedPaths are path segments owned by the server's own routes.
+var reservedPaths = []string{"api", "swagger", "go", "healthz", "readyz", "version", "favicon.ico", "robots.txt"}
+
+// isReservedPath reports whether the segment is a reserved word (case-insensitive).
+func isReservedPath(segment string) bool {
+	segmentLower := strings.ToLower(segment)
+	for _, word := range reservedPaths {
+		if segmentLower == word {
+			return true
+		}
+	}
+	return false
+}
+
+// softReservedWarning returns a warning if the path is on the configured
+// soft-reserved list, or an empty string otherwise.
+func (s *Server) softReservedWarning(path string) string {
+	pathLower := strings.ToLower(strings.TrimSpace(path))
+	for _, word := range s.config.SoftReserved {
+		if pathLower == strings.ToLower(word) {
+			return fmt.Sprintf("'%s' is a discouraged path name", path)
+		}
+	}
+	return ""
+}
+
+// isForced reports whether the request asked to override soft-reserved paths.
+func isForced(r *http.Request) bool {
+	force, _ := strconv.ParseBool(r.FormValue("force"))
+	return force
+}
+
 // ErrorResponse represents a structured error response.
 type ErrorResponse struct {
 	Error   string `json:"error"`
@@ -1049,3 +1899,45 @@ func writeErrorJSON(w http.ResponseWriter, message string, statusCode int) {
 	}
 	json.NewEncoder(w).Encode(response)
 }
+
+// duplicateURLWarning returns a warning naming the existing links with the
+// same target as link, or "" when there are none. It is advisory only, so a
+// failed lookup is logged and yields no warning.
+func (s *Server) duplicateURLWarning(r *http.Request, link Link) string {
+	existing, err := s.store.FindLinksByURL(r.Context(), link.URL)
+	if err != nil {
+		logRequestError(r, "Error finding links with the same URL", err)
+		return ""
+	}
+	if len(existing) == 0 {
+		return ""
+	}
+	paths := make([]string, len(existing))
+	for i, other := range existing {
+		paths[i] = "/" + other.Path
+	}
+	return fmt.Sprintf("Other links already point to this URL: %s", strings.Join(paths, ", "))
+}
+
+// WarningsResponse carries non-fatal warnings for a successful request.
+type WarningsResponse struct {
+	Warnings []string `json:"warnings"`
+}
+
+// writeWarningsJSON writes the status code, including a JSON body only when
+// there is a warning to report. Empty warnings are skipped.
+func writeWarningsJSON(w http.ResponseWriter, statusCode int, warnings ...string) {
+	response := WarningsResponse{Warnings: []string{}}
+	for _, warning := range warnings {
+		if warning != "" {
+			response.Warnings = append(response.Warnings, warning)
+		}
+	}
+	if len(response.Warnings) == 0 {
+		w.WriteHeader(statusCode)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(response)
+}
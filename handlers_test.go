@@ -0,0 +1,398 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSoftReservedPathsRequireForce(t *testing.T) {
+	tests := []struct {
+		name   string
+		path   string
+		query  string
+		status int
+	}{
+		{"allowed path", "deploy", "", http.StatusCreated},
+		{"soft-reserved without force", "home", "", http.StatusUnprocessableEntity},
+		{"soft-reserved with force=false", "home", "?force=false", http.StatusUnprocessableEntity},
+		{"soft-reserved with force", "home", "?force=true", http.StatusCreated},
+		{"soft-reserved in another case", "HOME", "?force=1", http.StatusCreated},
+		{"hard-reserved with force", "api", "?force=true", http.StatusUnprocessableEntity},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, handler := newTestServer(t, func(c *Config) { c.SoftReserved = []string{"home", "wiki"} })
+			w := serve(t, handler, http.MethodPost, "/api/links"+tt.query, Link{Path: tt.path, URL: "https://example.com"})
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestSoftReservedOverrideReturnsWarning(t *testing.T) {
+	_, handler := newTestServer(t, func(c *Config) { c.SoftReserved = []string{"home"} })
+
+	w := serve(t, handler, http.MethodPost, "/api/links?force=true", Link{Path: "home", URL: "https://example.com"})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusCreated, w.Body.String())
+	}
+	var response WarningsResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+	if len(response.Warnings) != 1 || !strings.Contains(response.Warnings[0], "discouraged") {
+		t.Errorf("warnings = %q, want one about a discouraged path", response.Warnings)
+	}
+}
+
+func TestSoftReservedUpdateRequiresForce(t *testing.T) {
+	server, handler := newTestServer(t, func(c *Config) { c.SoftReserved = []string{"home"} })
+	link := createLink(t, server, handler, Link{Path: "start", URL: "https://example.com"})
+
+	target := linkTarget(link.ID)
+	update := Link{Path: "home", URL: "https://example.com"}
+	if w := serve(t, handler, http.MethodPut, target, update); w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("update without force: status = %d, want %d", w.Code, http.StatusUnprocessableEntity)
+	}
+	if w := serve(t, handler, http.MethodPut, target+"?force=true", update); w.Code != http.StatusOK {
+		t.Fatalf("update with force: status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+}
+
+func TestNormalizePath(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"deploy", "deploy"},
+		{"/deploy", "deploy"},
+		{"deploy/", "deploy"},
+		{"/deploy/", "deploy"},
+		{"  deploy  ", "deploy"},
+		{" /deploy/ ", "deploy"},
+		{"//deploy", "/deploy"},
+		{"team/deploy", "team/deploy"},
+		{"/team/deploy/", "team/deploy"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := normalizePath(tt.in); got != tt.want {
+			t.Errorf("normalizePath(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestCreateLinkNormalizesPathSlashesAndSpaces(t *testing.T) {
+	tests := []struct {
+		name   string
+		path   string
+		status int
+	}{
+		{"plain", "deploy", http.StatusCreated},
+		{"leading slash", "/deploy", http.StatusCreated},
+		{"trailing slash", "deploy/", http.StatusCreated},
+		{"both slashes and spaces", "  /deploy/  ", http.StatusCreated},
+		{"double leading slash", "//deploy", http.StatusUnprocessableEntity},
+		{"empty segment", "team//deploy", http.StatusUnprocessableEntity},
+		{"only a slash", "/", http.StatusUnprocessableEntity},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, handler := newTestServer(t, nil)
+			w := serve(t, handler, http.MethodPost, "/api/links", Link{Path: tt.path, URL: "https://example.com"})
+			if w.Code != tt.status {
+				t.Fatalf("API status = %d, want %d: %s", w.Code, tt.status, w.Body.String())
+			}
+			if tt.status == http.StatusCreated {
+				if _, err := server.store.GetLinkByPath(context.Background(), "deploy"); err != nil {
+					t.Errorf("stored under %q: %v", "deploy", err)
+				}
+			}
+		})
+	}
+}
+
+func TestPortalCreateNormalizesPath(t *testing.T) {
+	server, handler := newTestServer(t, nil)
+	form := url.Values{"path": {" /Deploy/ "}, "url": {"https://example.com"}}
+	if w := serveForm(t, handler, http.MethodPost, "/go/links", form); w.Code != http.StatusSeeOther {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusSeeOther, w.Body.String())
+	}
+	if _, err := server.store.GetLinkByPath(context.Background(), "deploy"); err != nil {
+		t.Errorf("stored under %q: %v", "deploy", err)
+	}
+}
+
+func TestValidatePathSegments(t *testing.T) {
+	tests := []struct {
+		path    string
+		wantErr bool
+	}{
+		{"deploy", false},
+		{"team/deploy", false},
+		{"Team/Deploy_2/x-y", false},
+		{"a/b/c/d/e", false},
+		{"a/b/c/d/e/f", true},
+		{"team//deploy", true},
+		{"team/", true},
+		{"team/de ploy", true},
+		{"team/deploy.html", true},
+		{"api/team", true},
+		{"go/team", true},
+		{"team/api", false},
+		{"Swagger", true},
+		{"", true},
+		{strings.Repeat("a", 51), true},
+	}
+	for _, tt := range tests {
+		if err := validatePath(tt.path); (err != nil) != tt.wantErr {
+			t.Errorf("validatePath(%q) error = %v, want error %v", tt.path, err, tt.wantErr)
+		}
+	}
+}
+
+func TestMultiSegmentPathCreateAndRedirect(t *testing.T) {
+	server, handler := newTestServer(t, nil)
+	createLink(t, server, handler, Link{Path: "team/deploy", URL: "https://deploy.example.com"})
+	createLink(t, server, handler, Link{Path: "team", URL: "https://team.example.com"})
+
+	tests := []struct {
+		path     string
+		status   int
+		location string
+	}{
+		{"/team/deploy", http.StatusFound, "https://deploy.example.com"},
+		{"/team", http.StatusFound, "https://team.example.com"},
+		{"/Team/Deploy", http.StatusFound, "https://deploy.example.com"},
+		{"/team/other", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		w := serve(t, handler, http.MethodGet, tt.path, nil)
+		if w.Code != tt.status {
+			t.Errorf("GET %s: status = %d, want %d", tt.path, w.Code, tt.status)
+			continue
+		}
+		if got := w.Header().Get("Location"); got != tt.location {
+			t.Errorf("GET %s: Location = %q, want %q", tt.path, got, tt.location)
+		}
+	}
+
+	w := serve(t, handler, http.MethodPost, "/api/links", Link{Path: "team/deploy", URL: "https://other.example.com"})
+	if w.Code != http.StatusConflict {
+		t.Errorf("duplicate multi-segment path: status = %d, want %d", w.Code, http.StatusConflict)
+	}
+}
+
+func TestDisallowNumericPaths(t *testing.T) {
+	tests := []struct {
+		path     string
+		disallow bool
+		status   int
+	}{
+		{"123", false, http.StatusCreated},
+		{"123", true, http.StatusUnprocessableEntity},
+		{" /123/ ", true, http.StatusUnprocessableEntity},
+		{"abc123", true, http.StatusCreated},
+		{"123abc", true, http.StatusCreated},
+		{"team/123", true, http.StatusCreated},
+		{"abc123", false, http.StatusCreated},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s disallow=%v", tt.path, tt.disallow), func(t *testing.T) {
+			_, handler := newTestServer(t, func(c *Config) { c.DisallowNumericPaths = tt.disallow })
+			w := serve(t, handler, http.MethodPost, "/api/links", Link{Path: tt.path, URL: "https://example.com"})
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestCreateLinkAcceptsJSONAndForm(t *testing.T) {
+	tests := []struct {
+		name   string
+		json   map[string]interface{}
+		form   url.Values
+		status int
+	}{
+		{
+			name:   "full link",
+			json:   map[string]interface{}{"path": "Team/Docs", "url": "https://docs.example.com", "owner": "ops", "group": "Infra", "description": "Team docs", "tags": []string{"docs", "Wiki"}, "rate_limit": 10, "prefix": true},
+			form:   url.Values{"path": {"Team/Docs"}, "url": {"https://docs.example.com"}, "owner": {"ops"}, "group": {"Infra"}, "description": {"Team docs"}, "tags": {"docs, Wiki"}, "rate_limit": {"10"}, "prefix": {"true"}},
+			status: http.StatusCreated,
+		},
+		{
+			name:   "invalid url",
+			json:   map[string]interface{}{"path": "docs", "url": "ftp://docs.example.com"},
+			form:   url.Values{"path": {"docs"}, "url": {"ftp://docs.example.com"}},
+			status: http.StatusUnprocessableEntity,
+		},
+		{
+			name:   "reserved path",
+			json:   map[string]interface{}{"path": "api", "url": "https://docs.example.com"},
+			form:   url.Values{"path": {"api"}, "url": {"https://docs.example.com"}},
+			status: http.StatusUnprocessableEntity,
+		},
+		{
+			name:   "missing url",
+			json:   map[string]interface{}{"path": "docs"},
+			form:   url.Values{"path": {"docs"}},
+			status: http.StatusUnprocessableEntity,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jsonServer, jsonHandler := newTestServer(t, nil)
+			formServer, formHandler := newTestServer(t, nil)
+
+			jsonResponse := serve(t, jsonHandler, http.MethodPost, "/api/links", tt.json)
+			formResponse := serveForm(t, formHandler, http.MethodPost, "/api/links", tt.form)
+			if jsonResponse.Code != tt.status || formResponse.Code != tt.status {
+				t.Fatalf("status JSON = %d, form = %d, want %d: %s / %s", jsonResponse.Code, formResponse.Code, tt.status, jsonResponse.Body.String(), formResponse.Body.String())
+			}
+			if tt.status != http.StatusCreated {
+				if jsonResponse.Body.String() != formResponse.Body.String() {
+					t.Errorf("error JSON = %s, form = %s", jsonResponse.Body.String(), formResponse.Body.String())
+				}
+				return
+			}
+
+			path := normalizePath(strings.ToLower(tt.form.Get("path")))
+			fromJSON, err := jsonServer.store.GetLinkByPath(context.Background(), path)
+			if err != nil {
+				t.Fatalf("link created from JSON: %v", err)
+			}
+			fromForm, err := formServer.store.GetLinkByPath(context.Background(), path)
+			if err != nil {
+				t.Fatalf("link created from form: %v", err)
+			}
+			if got, want := comparableLink(*fromForm), comparableLink(*fromJSON); !reflect.DeepEqual(got, want) {
+				t.Errorf("form link = %+v, JSON link = %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestUpdateLinkAcceptsJSONAndForm(t *testing.T) {
+	server, handler := newTestServer(t, nil)
+	first := createLink(t, server, handler, Link{Path: "first", URL: "https://example.com"})
+	second := createLink(t, server, handler, Link{Path: "second", URL: "https://example.com"})
+
+	if w := serve(t, handler, http.MethodPut, linkTarget(first.ID), Link{Path: "first", URL: "https://one.example.com", Tags: []string{"a", "b"}}); w.Code != http.StatusOK {
+		t.Fatalf("JSON update: status = %d: %s", w.Code, w.Body.String())
+	}
+	form := url.Values{"path": {"second"}, "url": {"https://one.example.com"}, "tags": {"a,b"}}
+	if w := serveForm(t, handler, http.MethodPut, linkTarget(second.ID), form); w.Code != http.StatusOK {
+		t.Fatalf("form update: status = %d: %s", w.Code, w.Body.String())
+	}
+
+	fromJSON, _ := server.store.GetLinkByID(context.Background(), first.ID)
+	fromForm, _ := server.store.GetLinkByID(context.Background(), second.ID)
+	if fromJSON.URL != fromForm.URL || !reflect.DeepEqual(fromJSON.Tags, fromForm.Tags) {
+		t.Errorf("form update = %q %q, JSON update = %q %q", fromForm.URL, fromForm.Tags, fromJSON.URL, fromJSON.Tags)
+	}
+}
+
+// comparableLink clears the fields of link that differ between otherwise
+// identical links, such as IDs and timestamps.
+func comparableLink(link Link) Link {
+	link.ID = 0
+	link.CreatedAt = time.Time{}
+	link.UpdatedAt = time.Time{}
+	return link
+}
+
+func TestNormalizeLink(t *testing.T) {
+	tests := []struct {
+		name string
+		in   Link
+		want Link
+	}{
+		{"already normal", Link{Path: "docs", URL: "https://docs.example.com"}, Link{Path: "docs", URL: "https://docs.example.com"}},
+		{"path spaces and slashes", Link{Path: " /Team/Docs/ ", URL: "https://docs.example.com"}, Link{Path: "team/docs", URL: "https://docs.example.com"}},
+		{"url spaces", Link{Path: "docs", URL: "  https://docs.example.com/A  "}, Link{Path: "docs", URL: "https://docs.example.com/A"}},
+		{"owner and description", Link{Path: "docs", Owner: " ops ", Description: "\tTeam docs\n"}, Link{Path: "docs", Owner: "ops", Description: "Team docs"}},
+		{"group", Link{Path: "docs", Group: " Infra "}, Link{Path: "docs", Group: "infra"}},
+		{"tags", Link{Path: "docs", Tags: []string{" Wiki", "docs", "wiki", " "}}, Link{Path: "docs", Tags: []string{"docs", "wiki"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.in
+			normalizeLink(&got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("normalizeLink(%+v) = %+v, want %+v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEntryPointsNormalizeLinksAlike(t *testing.T) {
+	submitted := Link{Path: " /Team/Docs/ ", URL: "  https://docs.example.com  ", Owner: " ops ", Group: " Infra ", Description: " Team docs ", Tags: []string{" Wiki", "docs"}}
+	form := url.Values{
+		"path":        {submitted.Path},
+		"url":         {submitted.URL},
+		"owner":       {submitted.Owner},
+		"group":       {submitted.Group},
+		"description": {submitted.Description},
+		"tags":        {strings.Join(submitted.Tags, ",")},
+	}
+	want := Link{Path: "team/docs", URL: "https://docs.example.com", Owner: "ops", Group: "infra", Description: "Team docs", Tags: []string{"docs", "wiki"}}
+
+	entryPoints := []struct {
+		name   string
+		submit func(http.Handler) *httptest.ResponseRecorder
+	}{
+		{"API JSON", func(h http.Handler) *httptest.ResponseRecorder {
+			return serve(t, h, http.MethodPost, "/api/links", submitted)
+		}},
+		{"API form", func(h http.Handler) *httptest.ResponseRecorder {
+			return serveForm(t, h, http.MethodPost, "/api/links", form)
+		}},
+		{"portal form", func(h http.Handler) *httptest.ResponseRecorder {
+			return serveForm(t, h, http.MethodPost, "/go/links", form)
+		}},
+		{"htmx form", func(h http.Handler) *httptest.ResponseRecorder {
+			return serveForm(t, h, http.MethodPost, "/go/htmx/links", form)
+		}},
+	}
+	for _, entry := range entryPoints {
+		t.Run(entry.name, func(t *testing.T) {
+			server, handler := newTestServer(t, nil)
+			if w := entry.submit(handler); w.Code >= http.StatusBadRequest {
+				t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+			}
+			link, err := server.store.GetLinkByPath(context.Background(), want.Path)
+			if err != nil {
+				t.Fatalf("stored under %q: %v", want.Path, err)
+			}
+			got := Link{Path: link.Path, URL: link.URL, Owner: link.Owner, Group: link.Group, Description: link.Description, Tags: link.Tags}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("stored %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestUpdateNormalizesLink(t *testing.T) {
+	server, handler := newTestServer(t, nil)
+	link := createLink(t, server, handler, Link{Path: "docs", URL: "https://docs.example.com"})
+	update := Link{Path: " /Docs/ ", URL: " https://docs2.example.com ", Group: " Infra "}
+	if w := serve(t, handler, http.MethodPut, linkTarget(link.ID), update); w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	updated, err := server.store.GetLinkByID(context.Background(), link.ID)
+	if err != nil {
+		t.Fatalf("GetLinkByID: %v", err)
+	}
+	if updated.Path != "docs" || updated.URL != "https://docs2.example.com" || updated.Group != "infra" {
+		t.Errorf("updated to %q %q %q, want %q %q %q", updated.Path, updated.URL, updated.Group, "docs", "https://docs2.example.com", "infra")
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+// Health statuses. Unreachable means the database cannot be read; not
+// writable means it can be read but a write failed, e.g. on a read-only
+// filesystem or a full disk.
+const (
+	HealthStatusOK          = "ok"
+	HealthStatusUnreachable = "unreachable"
+	HealthStatusNotWritable = "not_writable"
+)
+
+// HealthResponse is the body of /healthz and /readyz. Database is only set
+// by /readyz, and Writable only by a deep readiness check.
+type HealthResponse struct {
+	Status   string `json:"status"`
+	Database string `json:"database,omitempty"`
+	Writable string `json:"writable,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Ping verifies that the database connection is alive and can be read.
+func (s *Store) Ping(ctx context.Context) error {
+	if err := s.db.PingContext(ctx); err != nil {
+		return err
+	}
+	var one int
+	err := s.db.QueryRowContext(ctx, `SELECT 1 FROM links LIMIT 1`).Scan(&one)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	return err
+}
+
+// CheckWritable writes a row to the health_checks scratch table and rolls it
+// back, proving the database accepts writes without changing it.
+func (s *Store) CheckWritable(ctx context.Context) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO health_checks(checked_at) VALUES(`+sqliteNowMilli+`)`)
+	return err
+}
+
+// handleHealth is the liveness check: it answers 200 whenever the process
+// serves requests and does not touch the database.
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(HealthResponse{Status: HealthStatusOK})
+}
+
+// handleReady is the readiness check: it reports whether the database is
+// reachable and, with ?deep=true, writable. Both failures answer 503 and are
+// told apart by the status field.
+func (s *Server) handleReady(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	deep, _ := strconv.ParseBool(r.URL.Query().Get("deep"))
+
+	response := HealthResponse{Status: HealthStatusOK, Database: HealthStatusOK}
+	status := http.StatusOK
+	if err := s.store.Ping(r.Context()); err != nil {
+		logRequestError(r, "Readiness check: database unreachable", err)
+		response.Status = HealthStatusUnreachable
+		response.Database = HealthStatusUnreachable
+		response.Error = err.Error()
+		status = http.StatusServiceUnavailable
+	} else if deep {
+		response.Writable = HealthStatusOK
+		if err := s.store.CheckWritable(r.Context()); err != nil {
+			logRequestError(r, "Readiness check: database not writable", err)
+			response.Status = HealthStatusNotWritable
+			response.Writable = HealthStatusNotWritable
+			response.Error = err.Error()
+			status = http.StatusServiceUnavailable
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"strings"
+)
+
+// maxHookMessage caps how much of a hook's stderr is returned to the client.
+const maxHookMessage = 1024
+
+// runCreateHook runs CREATE_HOOK_CMD through the shell with the link as JSON
+// on stdin. A non-zero exit rejects the link, using the command's stderr as
+// the reason. Hooks that cannot start or time out reject the link as well,
+// so a broken hook never lets links through unchecked.
+func (s *Server) runCreateHook(ctx context.Context, link Link) error {
+	if s.config.CreateHookCmd == "" {
+		return nil
+	}
+
+	payload, err := json.Marshal(link)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.config.CreateHookTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", s.config.CreateHookCmd)
+	cmd.Stdin = bytes.NewReader(payload)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	err = cmd.Run()
+	if err == nil {
+		return nil
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		slog.Warn("Create hook timed out", "path", link.Path, "timeout", s.config.CreateHookTimeout)
+		return fmt.Errorf("create hook timed out after %s", s.config.CreateHookTimeout)
+	}
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		slog.Error("Create hook failed to run", "error", err)
+		return fmt.Errorf("create hook failed to run")
+	}
+
+	message := strings.TrimSpace(stderr.String())
+	if len(message) > maxHookMessage {
+		message = message[:maxHookMessage]
+	}
+	if message == "" {
+		message = fmt.Sprintf("rejected by create hook (exit status %d)", exitErr.ExitCode())
+	}
+	return errors.New(message)
+}
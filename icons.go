@@ -0,0 +1,267 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxIconBytes caps the size of an uploaded icon image.
+const maxIconBytes = 64 << 10
+
+// maxEmojiRunes caps the length of an emoji icon; flags and ZWJ sequences
+// span several code points.
+const maxEmojiRunes = 8
+
+// iconBlobPrefix marks a link icon that references an uploaded image in the
+// link_icons table rather than an emoji.
+const iconBlobPrefix = "blob:"
+
+// allowedIconTypes lists the image types accepted for uploaded icons. SVG is
+// excluded because it can carry script.
+var allowedIconTypes = map[string]bool{
+	"image/png":  true,
+	"image/gif":  true,
+	"image/jpeg": true,
+	"image/webp": true,
+}
+
+// IconRequest sets an emoji icon; an empty emoji clears the icon.
+type IconRequest struct {
+	Emoji string `json:"emoji"`
+}
+
+// HasIconImage reports whether the link icon is an uploaded image.
+func (l Link) HasIconImage() bool {
+	return strings.HasPrefix(l.Icon, iconBlobPrefix)
+}
+
+// validateEmoji checks that an icon is a short emoji rather than arbitrary text.
+func validateEmoji(emoji string) error {
+	if utf8.RuneCountInString(emoji) > maxEmojiRunes {
+		return fmt.Errorf("emoji icon must be at most %d characters", maxEmojiRunes)
+	}
+	hasSymbol := false
+	for _, r := range emoji {
+		if unicode.IsSpace(r) || unicode.IsLetter(r) {
+			return fmt.Errorf("icon must be an emoji")
+		}
+		if r > unicode.MaxASCII {
+			hasSymbol = true
+		}
+	}
+	if !hasSymbol {
+		return fmt.Errorf("icon must be an emoji")
+	}
+	return nil
+}
+
+// SetLinkIcon sets the emoji icon of a link, replacing any uploaded image.
+// An empty emoji clears the icon.
+func (s *Store) SetLinkIcon(ctx context.Context, id int64, emoji string) error {
+	return s.replaceLinkIcon(ctx, id, func(tx *sql.Tx) (string, error) {
+		return emoji, nil
+	})
+}
+
+// SetLinkIconImage stores an uploaded icon image for a link.
+func (s *Store) SetLinkIconImage(ctx context.Context, id int64, contentType string, data []byte) error {
+	return s.replaceLinkIcon(ctx, id, func(tx *sql.Tx) (string, error) {
+		result, err := tx.ExecContext(ctx, `INSERT INTO link_icons(link_id, content_type, data) VALUES(?, ?, ?)`, id, contentType, data)
+		if err != nil {
+			return "", err
+		}
+		blobID, err := result.LastInsertId()
+		if err != nil {
+			return "", err
+		}
+		return iconBlobPrefix + strconv.FormatInt(blobID, 10), nil
+	})
+}
+
+// replaceLinkIcon swaps the icon of a link for the one produced by newIcon,
+// dropping previously uploaded images and recording the change in the audit log.
+func (s *Store) replaceLinkIcon(ctx context.Context, id int64, newIcon func(tx *sql.Tx) (string, error)) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var prior string
+	err = tx.QueryRowContext(ctx, `SELECT icon FROM links WHERE id = ?`, id).Scan(&prior)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("link with id %d not found", id)
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM link_icons WHERE link_id = ?`, id); err != nil {
+		return err
+	}
+	icon, err := newIcon(tx)
+	if err != nil {
+		return err
+	}
+	if icon == prior {
+		return tx.Commit()
+	}
+
+	updateSQL := `UPDATE links SET icon = ?, updated_at = ` + sqliteNowMilli + ` WHERE id = ?`
+	if _, err := tx.ExecContext(ctx, updateSQL, icon, id); err != nil {
+		return err
+	}
+	changes := map[string]FieldChange{"icon": {Old: prior, New: icon}}
+	if err := insertAuditEntry(tx, id, AuditActionIcon, changes); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetLinkIconImage retrieves the uploaded icon image referenced by a link.
+func (s *Store) GetLinkIconImage(ctx context.Context, link Link) (string, []byte, error) {
+	blobID, err := strconv.ParseInt(strings.TrimPrefix(link.Icon, iconBlobPrefix), 10, 64)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid icon reference '%s'", link.Icon)
+	}
+	var contentType string
+	var data []byte
+	query := `SELECT content_type, data FROM link_icons WHERE id = ? AND link_id = ?`
+	err = s.db.QueryRowContext(ctx, query, blobID, link.ID).Scan(&contentType, &data)
+	return contentType, data, err
+}
+
+// handleSetIcon sets the icon of a link from an emoji JSON body or a
+// multipart image upload in the "icon" field.
+// SetIcon godoc
+// @Summary      Set link icon
+// @Description  Set an emoji icon with a JSON body, or upload a PNG, GIF, JPEG or WebP image (max 64 KiB) as multipart field "icon"
+// @Tags         links
+// @Accept       json,mpfd
+// @Produce      json
+// @Param        id    path  int          true   "Link ID"
+// @Param        icon  body  IconRequest  false  "Emoji icon"
+// @Success      200  {object}  Link
+// @Failure      400  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      413  {object}  ErrorResponse
+// @Failure      415  {object}  ErrorResponse
+// @Router       /links/{id}/icon [post]
+func (s *Server) handleSetIcon(w http.ResponseWriter, r *http.Request, id int64) {
+	var err error
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		r.Body = http.MaxBytesReader(w, r.Body, maxIconBytes+4096)
+		file, _, formErr := r.FormFile("icon")
+		var tooLarge *http.MaxBytesError
+		if errors.As(formErr, &tooLarge) {
+			writeErrorJSON(w, fmt.Sprintf("Icon image must be at most %d KiB", maxIconBytes>>10), http.StatusRequestEntityTooLarge)
+			return
+		}
+		if formErr != nil {
+			writeErrorJSON(w, "Multipart field 'icon' with an image is required", http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+
+		data, readErr := io.ReadAll(io.LimitReader(file, maxIconBytes+1))
+		if readErr != nil {
+			writeErrorJSON(w, "Failed to read icon upload", http.StatusBadRequest)
+			return
+		}
+		if len(data) > maxIconBytes {
+			writeErrorJSON(w, fmt.Sprintf("Icon image must be at most %d KiB", maxIconBytes>>10), http.StatusRequestEntityTooLarge)
+			return
+		}
+		contentType := http.DetectContentType(data)
+		if !allowedIconTypes[contentType] {
+			writeErrorJSON(w, "Icon image must be PNG, GIF, JPEG or WebP", http.StatusUnsupportedMediaType)
+			return
+		}
+		err = s.store.SetLinkIconImage(r.Context(), id, contentType, data)
+	} else {
+		var req IconRequest
+		if decodeErr := json.NewDecoder(r.Body).Decode(&req); decodeErr != nil {
+			writeErrorJSON(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		req.Emoji = strings.TrimSpace(req.Emoji)
+		if req.Emoji != "" {
+			if validateErr := validateEmoji(req.Emoji); validateErr != nil {
+				writeErrorJSON(w, validateErr.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		err = s.store.SetLinkIcon(r.Context(), id, req.Emoji)
+	}
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			writeErrorJSON(w, fmt.Sprintf("Link with id %d not found", id), http.StatusNotFound)
+			return
+		}
+		logRequestError(r, "API SetIcon error", err, "id", id)
+		writeErrorJSON(w, "Failed to set link icon", http.StatusInternalServerError)
+		return
+	}
+
+	link, err := s.store.GetLinkByID(r.Context(), id)
+	if err != nil {
+		logRequestError(r, "API SetIcon reload error", err, "id", id)
+		writeErrorJSON(w, "Failed to retrieve link", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(link)
+}
+
+// handleGetIcon serves the icon of a link: the image bytes for an uploaded
+// icon, or {"emoji": "..."} for an emoji icon.
+// GetIcon godoc
+// @Summary      Get link icon
+// @Description  Uploaded icon image, or a JSON object holding the emoji icon
+// @Tags         links
+// @Produce      json,png,gif,jpeg,webp
+// @Param        id  path  int  true  "Link ID"
+// @Success      200  {object}  IconRequest
+// @Failure      404  {object}  ErrorResponse
+// @Router       /links/{id}/icon [get]
+func (s *Server) handleGetIcon(w http.ResponseWriter, r *http.Request, id int64) {
+	link, err := s.store.GetLinkByID(r.Context(), id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			writeErrorJSON(w, fmt.Sprintf("Link with id %d not found", id), http.StatusNotFound)
+			return
+		}
+		logRequestError(r, "API GetIcon error", err, "id", id)
+		writeErrorJSON(w, "Failed to retrieve link", http.StatusInternalServerError)
+		return
+	}
+	if link.Icon == "" {
+		writeErrorJSON(w, fmt.Sprintf("Link with id %d has no icon", id), http.StatusNotFound)
+		return
+	}
+
+	if !link.HasIconImage() {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(IconRequest{Emoji: link.Icon})
+		return
+	}
+
+	contentType, data, err := s.store.GetLinkIconImage(r.Context(), *link)
+	if err != nil {
+		logRequestError(r, "API GetIcon image error", err, "id", id)
+		writeErrorJSON(w, "Failed to retrieve link icon", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", "public, max-age=3600")
+	w.Write(data)
+}
@@ -0,0 +1,359 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// maxImportRows caps the number of links accepted in one import.
+const maxImportRows = 1000
+
+// Outcomes of a single import row. A dry run reports the outcome the row
+// would have had.
+const (
+	ImportStatusCreated = "created"
+	ImportStatusSkipped = "skipped"
+	ImportStatusFailed  = "failed"
+)
+
+// ImportRowResult is the outcome of one imported row. Row numbers start at 1
+// and refer to data rows, not counting a CSV header; Line is the line of the
+// CSV file the row starts on.
+type ImportRowResult struct {
+	Row    int    `json:"row"`
+	Line   int    `json:"line,omitempty"`
+	Path   string `json:"path"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// ImportResponse reports the totals and per-row outcomes of an import. With
+// DryRun set nothing was persisted.
+type ImportResponse struct {
+	DryRun  bool              `json:"dry_run"`
+	Created int               `json:"created"`
+	Skipped int               `json:"skipped"`
+	Failed  int               `json:"failed"`
+	Results []ImportRowResult `json:"results"`
+}
+
+// ImportOptions controls how ImportLinks stores links.
+type ImportOptions struct {
+	DryRun      bool // Roll back instead of committing
+	PreserveIDs bool // Store each link under its own ID
+	Overwrite   bool // With PreserveIDs, replace a link already using the ID
+}
+
+// ImportLinks inserts links in a single transaction and returns one error per
+// link (nil when it was created). Each link is inserted under its own
+// savepoint, so a failing row leaves no partial state behind. With DryRun set
+// the transaction is rolled back instead of committed.
+func (s *Store) ImportLinks(ctx context.Context, links []Link, opts ImportOptions) ([]error, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rowErrors := make([]error, len(links))
+	for i, link := range links {
+		if _, err := tx.ExecContext(ctx, `SAVEPOINT import_row`); err != nil {
+			return nil, err
+		}
+		if opts.PreserveIDs && opts.Overwrite {
+			rowErrors[i] = deleteLinkRow(tx, link.ID)
+		}
+		if rowErrors[i] == nil {
+			rowErrors[i] = s.insertLink(tx, link, opts.PreserveIDs)
+		}
+		if rowErrors[i] != nil {
+			if _, err := tx.ExecContext(ctx, `ROLLBACK TO import_row`); err != nil {
+				return nil, err
+			}
+		}
+		if _, err := tx.ExecContext(ctx, `RELEASE import_row`); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.DryRun {
+		return rowErrors, nil
+	}
+	return rowErrors, tx.Commit()
+}
+
+// deleteLinkRow removes a link and its tags, if it exists, so an import can
+// store a replacement under the same ID. Visits and audit entries are kept.
+func deleteLinkRow(tx *sql.Tx, id int64) error {
+	if _, err := tx.Exec(`DELETE FROM link_tags WHERE link_id = ?`, id); err != nil {
+		return err
+	}
+	_, err := tx.Exec(`DELETE FROM links WHERE id = ?`, id)
+	return err
+}
+
+// csvColumns is the column order of a CSV import without a header row.
+var csvColumns = []string{"path", "url", "owner", "group", "description", "rate_limit", "tags"}
+
+// parseImportLinks reads links from a JSON array or a CSV body. For CSV it
+// also returns the line each row starts on.
+func parseImportLinks(r *http.Request) ([]Link, []int, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "text/csv" {
+		return parseImportCSV(r.Body)
+	}
+
+	var links []Link
+	if err := json.NewDecoder(r.Body).Decode(&links); err != nil {
+		return nil, nil, fmt.Errorf("Invalid request body: expected a JSON array of links")
+	}
+	return links, nil, nil
+}
+
+// parseImportCSV reads links from CSV, returning them with the line each row
+// starts on. A header row naming the path, url and optional owner, group,
+// description, rate_limit and tags columns is recognized when it has both a
+// path and a url column; without one the columns are taken in that order.
+func parseImportCSV(body io.Reader) ([]Link, []int, error) {
+	reader := csv.NewReader(body)
+	reader.FieldsPerRecord = -1
+	var records [][]string
+	var lines []int
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("Invalid CSV: %v", err)
+		}
+		line, _ := reader.FieldPos(0)
+		records = append(records, record)
+		lines = append(lines, line)
+	}
+	if len(records) == 0 {
+		return nil, nil, fmt.Errorf("Invalid CSV: no rows")
+	}
+
+	columns := make(map[string]int)
+	for i, name := range records[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	_, hasPath := columns["path"]
+	_, hasURL := columns["url"]
+	if hasPath && hasURL {
+		records, lines = records[1:], lines[1:]
+	} else {
+		columns = make(map[string]int)
+		for i, name := range csvColumns {
+			columns[name] = i
+		}
+	}
+
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	links := make([]Link, 0, len(records))
+	for n, record := range records {
+		link := Link{
+			Path:        field(record, "path"),
+			URL:         field(record, "url"),
+			Owner:       field(record, "owner"),
+			Group:       field(record, "group"),
+			Description: field(record, "description"),
+			Tags:        splitList(field(record, "tags")),
+		}
+		if rateLimit := field(record, "rate_limit"); rateLimit != "" {
+			value, err := strconv.Atoi(rateLimit)
+			if err != nil {
+				return nil, nil, fmt.Errorf("Invalid CSV: line %d: rate limit must be a whole number", lines[n])
+			}
+			link.RateLimit = value
+		}
+		links = append(links, link)
+	}
+	return links, lines, nil
+}
+
+// handleImportLinks creates many links at once. Rows that fail validation are
+// reported as failed and rows whose path already exists (in the database or
+// earlier in the import) as skipped; the remaining rows are created. With
+// dry_run=true the same pipeline runs and is rolled back. With
+// preserve_ids=true every row keeps its id, e.g. when moving the output of
+// GET /api/links to another instance; a row whose id is in use fails unless
+// overwrite=true replaces that link.
+// ImportLinks godoc
+// @Summary      Import links
+// @Description  Create links from a JSON array or CSV (path,url[,owner,group,description,rate_limit,tags], header row optional); dry_run reports outcomes without saving
+// @Tags         links
+// @Accept       json,csv
+// @Produce      json
+// @Param        dry_run  query  boolean  false  "Report outcomes without saving"
+// @Param        force    query  boolean  false  "Allow soft-reserved paths"
+// @Param        preserve_ids  query  boolean  false  "Keep each link's id"
+// @Param        overwrite     query  boolean  false  "With preserve_ids, replace links using the same id"
+// @Success      200  {object}  ImportResponse
+// @Failure      400  {object}  ErrorResponse
+// @Router       /links/import [post]
+func (s *Server) handleImportLinks(w http.ResponseWriter, r *http.Request) {
+	if !s.allowCreate(w, r) {
+		return
+	}
+	var opts ImportOptions
+	opts.DryRun, _ = strconv.ParseBool(r.URL.Query().Get("dry_run"))
+	opts.PreserveIDs, _ = strconv.ParseBool(r.URL.Query().Get("preserve_ids"))
+	opts.Overwrite, _ = strconv.ParseBool(r.URL.Query().Get("overwrite"))
+
+	links, lines, err := parseImportLinks(r)
+	if err != nil {
+		writeErrorJSON(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(links) > maxImportRows {
+		writeErrorJSON(w, fmt.Sprintf("At most %d links can be imported at once", maxImportRows), http.StatusBadRequest)
+		return
+	}
+
+	response, err := s.importLinks(r, links, lines, opts)
+	if err != nil {
+		logRequestError(r, "API ImportLinks error", err)
+		writeErrorJSON(w, "Failed to import links", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// importLinks validates links and stores the valid ones, reporting the
+// outcome of every row. lines, when set, holds the CSV line of each row.
+func (s *Server) importLinks(r *http.Request, links []Link, lines []int, opts ImportOptions) (ImportResponse, error) {
+	response := ImportResponse{DryRun: opts.DryRun, Results: make([]ImportRowResult, len(links))}
+	var valid []Link
+	var validRows []int
+	for i, link := range links {
+		normalizeLink(&link)
+		link.CreatedBy = requestCreator(r)
+		response.Results[i] = ImportRowResult{Row: i + 1, Path: link.Path}
+		if i < len(lines) {
+			response.Results[i].Line = lines[i]
+		}
+
+		err := s.validateLink(r.Context(), link)
+		if err == nil && opts.PreserveIDs && link.ID <= 0 {
+			err = fmt.Errorf("id is required when preserving ids")
+		}
+		if err == nil {
+			if warning := s.softReservedWarning(link.Path); warning != "" && !isForced(r) {
+				err = fmt.Errorf("%s; retry with ?force=true to use it anyway", warning)
+			}
+		}
+		if err == nil {
+			err = s.runCreateHook(r.Context(), link)
+		}
+		if err != nil {
+			response.Results[i].Status = ImportStatusFailed
+			response.Results[i].Error = err.Error()
+			continue
+		}
+		valid = append(valid, link)
+		validRows = append(validRows, i)
+	}
+
+	rowErrors, err := s.store.ImportLinks(r.Context(), valid, opts)
+	if err != nil {
+		return response, err
+	}
+	for j, rowErr := range rowErrors {
+		result := &response.Results[validRows[j]]
+		switch {
+		case rowErr == nil:
+			result.Status = ImportStatusCreated
+			s.notifyCreated(r.Context(), valid[j].Path)
+		case strings.Contains(rowErr.Error(), "already exists"):
+			result.Status = ImportStatusSkipped
+			result.Error = rowErr.Error()
+		default:
+			result.Status = ImportStatusFailed
+			result.Error = rowErr.Error()
+		}
+	}
+
+	for _, result := range response.Results {
+		switch result.Status {
+		case ImportStatusCreated:
+			response.Created++
+		case ImportStatusSkipped:
+			response.Skipped++
+		case ImportStatusFailed:
+			response.Failed++
+		}
+	}
+	return response, nil
+}
+
+// maxImportUpload caps the size of a CSV file uploaded through the portal.
+const maxImportUpload = 1 << 20
+
+// htmxImportHandler imports links from a CSV file uploaded through the portal
+// and renders the outcome.
+func (s *Server) htmxImportHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.allowCreate(w, r) {
+		return
+	}
+
+	data := struct {
+		Response ImportResponse
+		Error    string
+	}{}
+	render := func() {
+		if err := s.executeTemplate(w, "import-result", data); err != nil {
+			logRequestError(r, "Template execution error in import", err)
+			http.Error(w, "Template rendering error", http.StatusInternalServerError)
+		}
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportUpload)
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		data.Error = "Choose a CSV file of at most 1 MB to import"
+		render()
+		return
+	}
+	defer file.Close()
+
+	links, lines, err := parseImportCSV(file)
+	if err == nil && len(links) > maxImportRows {
+		err = fmt.Errorf("At most %d links can be imported at once", maxImportRows)
+	}
+	if err != nil {
+		data.Error = err.Error()
+		render()
+		return
+	}
+
+	opts := ImportOptions{}
+	opts.DryRun, _ = strconv.ParseBool(r.FormValue("dry_run"))
+	data.Response, err = s.importLinks(r, links, lines, opts)
+	if err != nil {
+		logRequestError(r, "Error importing links", err)
+		data.Error = "Failed to import links"
+	}
+	render()
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+// exportLinks returns every link as GET /api/links lists them.
+func exportLinks(t *testing.T, handler http.Handler) []Link {
+	t.Helper()
+	w := serve(t, handler, http.MethodGet, "/api/links?limit=500", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("listing links: status = %d: %s", w.Code, w.Body.String())
+	}
+	var links []Link
+	if err := json.Unmarshal(w.Body.Bytes(), &links); err != nil {
+		t.Fatalf("decoding links: %v", err)
+	}
+	return links
+}
+
+func importLinks(t *testing.T, handler http.Handler, query string, links []Link) ImportResponse {
+	t.Helper()
+	w := serve(t, handler, http.MethodPost, "/api/links/import"+query, links)
+	if w.Code != http.StatusOK {
+		t.Fatalf("importing: status = %d: %s", w.Code, w.Body.String())
+	}
+	var response ImportResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("decoding import response: %v", err)
+	}
+	return response
+}
+
+func TestImportPreservesIDs(t *testing.T) {
+	source, sourceHandler := newTestServer(t, nil)
+	for _, path := range []string{"wiki", "docs", "deploy"} {
+		createLink(t, source, sourceHandler, Link{Path: path, URL: "https://" + path + ".example.com"})
+	}
+	// Leave a gap in the ids, as deleted links do
+	gap := createLink(t, source, sourceHandler, Link{Path: "gone", URL: "https://gone.example.com"})
+	if err := source.store.DeleteLink(context.Background(), gap.ID); err != nil {
+		t.Fatalf("DeleteLink: %v", err)
+	}
+	createLink(t, source, sourceHandler, Link{Path: "jira", URL: "https://jira.example.com"})
+	exported := exportLinks(t, sourceHandler)
+
+	target, targetHandler := newTestServer(t, nil)
+	response := importLinks(t, targetHandler, "?preserve_ids=true", exported)
+	if response.Created != len(exported) || response.Failed != 0 {
+		t.Fatalf("import = %+v, want %d created", response, len(exported))
+	}
+
+	var maxID int64
+	for _, want := range exported {
+		got, err := target.store.GetLinkByPath(context.Background(), want.Path)
+		if err != nil {
+			t.Fatalf("GetLinkByPath(%q): %v", want.Path, err)
+		}
+		if got.ID != want.ID || got.URL != want.URL {
+			t.Errorf("%s: imported as id %d -> %s, want id %d -> %s", want.Path, got.ID, got.URL, want.ID, want.URL)
+		}
+		maxID = max(maxID, want.ID)
+	}
+
+	// New links continue after the highest imported id
+	added := createLink(t, target, targetHandler, Link{Path: "new", URL: "https://new.example.com"})
+	if added.ID <= maxID {
+		t.Errorf("new link id = %d, want above %d", added.ID, maxID)
+	}
+}
+
+func TestImportIDCollisions(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		created int
+		failed  int
+		wantURL string
+	}{
+		{"rejected", "?preserve_ids=true", 0, 1, "https://old.example.com"},
+		{"overwritten", "?preserve_ids=true&overwrite=true", 1, 0, "https://new.example.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, handler := newTestServer(t, nil)
+			existing := createLink(t, server, handler, Link{Path: "old", URL: "https://old.example.com"})
+
+			incoming := []Link{{ID: existing.ID, Path: "new", URL: "https://new.example.com"}}
+			response := importLinks(t, handler, tt.query, incoming)
+			if response.Created != tt.created || response.Failed != tt.failed {
+				t.Fatalf("import = %+v, want %d created, %d failed", response, tt.created, tt.failed)
+			}
+
+			link, err := server.store.GetLinkByID(context.Background(), existing.ID)
+			if err != nil {
+				t.Fatalf("GetLinkByID: %v", err)
+			}
+			if link.URL != tt.wantURL {
+				t.Errorf("link %d URL = %q, want %q", existing.ID, link.URL, tt.wantURL)
+			}
+		})
+	}
+}
+
+func TestImportPreserveIDsRequiresID(t *testing.T) {
+	_, handler := newTestServer(t, nil)
+	response := importLinks(t, handler, "?preserve_ids=true", []Link{{Path: "wiki", URL: "https://wiki.example.com"}})
+	if response.Failed != 1 || response.Results[0].Error == "" {
+		t.Errorf("import = %+v, want the row without an id to fail", response)
+	}
+}
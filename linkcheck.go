@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// linkCheckTimeout bounds a single request to a link target.
+const linkCheckTimeout = 10 * time.Second
+
+// SaveLinkCheck records the outcome of the latest check of a link's target,
+// replacing the previous one. status is 0 when no response was received.
+func (s *Store) SaveLinkCheck(ctx context.Context, linkID int64, status int, checkErr string, broken bool) error {
+	saveSQL := `INSERT OR REPLACE INTO link_checks(link_id, status, error, broken, checked_at) VALUES(?, ?, ?, ?, ` + sqliteNowMilli + `)`
+	_, err := s.db.ExecContext(ctx, saveSQL, linkID, status, checkErr, broken)
+	return err
+}
+
+// CountBrokenLinks counts links whose latest check failed. A check older than
+// the link's last update describes a previous target and is ignored.
+func (s *Store) CountBrokenLinks(ctx context.Context) (int64, error) {
+	var count int64
+	err := s.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM link_checks c
+		JOIN links l ON l.id = c.link_id
+		WHERE c.broken AND c.checked_at >= l.updated_at`).Scan(&count)
+	return count, err
+}
+
+// linkChecker requests the target of every link and records which ones fail.
+type linkChecker struct {
+	store  *Store
+	client *http.Client
+}
+
+// checkAll checks every enabled, unexpired link once. Templated links are
+// skipped since their target is only complete once a redirect fills it in.
+func (c *linkChecker) checkAll(ctx context.Context) (checked, broken int, err error) {
+	links, err := c.store.GetAllLinks(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	now := time.Now()
+	for _, link := range links {
+		if ctx.Err() != nil {
+			break
+		}
+		if !link.Enabled || link.Templated || link.isExpired(now) {
+			continue
+		}
+		status, checkErr := c.check(ctx, link.URL)
+		failed := linkCheckFailed(status, checkErr)
+		message := ""
+		if checkErr != nil {
+			message = checkErr.Error()
+		}
+		if err := c.store.SaveLinkCheck(ctx, link.ID, status, message, failed); err != nil {
+			return checked, broken, err
+		}
+		checked++
+		if failed {
+			broken++
+		}
+	}
+	return checked, broken, nil
+}
+
+// check requests target with HEAD, falling back to GET for servers that
+// refuse HEAD, and returns the final status after redirects.
+func (c *linkChecker) check(ctx context.Context, target string) (int, error) {
+	status, err := c.request(ctx, http.MethodHead, target)
+	if err == nil && (status == http.StatusMethodNotAllowed || status == http.StatusNotImplemented) {
+		status, err = c.request(ctx, http.MethodGet, target)
+	}
+	return status, err
+}
+
+func (c *linkChecker) request(ctx context.Context, method, target string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, target, nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+// linkCheckFailed reports whether a check result marks a link broken: the
+// target could not be reached or answered with an error. 401, 403 and 429
+// mean the target exists but turned the checker away, so they count as fine.
+func linkCheckFailed(status int, err error) bool {
+	if err != nil {
+		return true
+	}
+	switch status {
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusTooManyRequests:
+		return false
+	}
+	return status >= 400
+}
+
+// startLinkChecker periodically checks every link target when
+// LINK_CHECK_INTERVAL is set; the results feed the dashboard's broken count.
+// The checker stops when ctx is cancelled.
+func startLinkChecker(ctx context.Context, wg *sync.WaitGroup, store *Store, config *Config) {
+	if config.LinkCheckInterval <= 0 {
+		return
+	}
+
+	slog.Info("Checking link targets on a schedule", "interval", config.LinkCheckInterval)
+	checker := &linkChecker{store: store, client: &http.Client{Timeout: linkCheckTimeout}}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(config.LinkCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			checked, broken, err := checker.checkAll(ctx)
+			if err != nil && ctx.Err() == nil {
+				slog.Error("Link check failed", "error", err)
+				continue
+			}
+			slog.Info("Checked link targets", "checked", checked, "broken", broken)
+		}
+	}()
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLinkCheckFailed(t *testing.T) {
+	tests := []struct {
+		status int
+		err    error
+		want   bool
+	}{
+		{http.StatusOK, nil, false},
+		{http.StatusNoContent, nil, false},
+		{http.StatusUnauthorized, nil, false},
+		{http.StatusForbidden, nil, false},
+		{http.StatusTooManyRequests, nil, false},
+		{http.StatusNotFound, nil, true},
+		{http.StatusGone, nil, true},
+		{http.StatusInternalServerError, nil, true},
+		{0, errors.New("connection refused"), true},
+	}
+	for _, tt := range tests {
+		if got := linkCheckFailed(tt.status, tt.err); got != tt.want {
+			t.Errorf("linkCheckFailed(%d, %v) = %v, want %v", tt.status, tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestLinkCheckerCountsBrokenLinks(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok":
+		case "/get-only":
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			}
+		case "/private":
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer target.Close()
+
+	server, handler := newTestServer(t, nil)
+	createLink(t, server, handler, Link{Path: "ok", URL: target.URL + "/ok"})
+	createLink(t, server, handler, Link{Path: "get-only", URL: target.URL + "/get-only"})
+	createLink(t, server, handler, Link{Path: "private", URL: target.URL + "/private"})
+	createLink(t, server, handler, Link{Path: "gone", URL: target.URL + "/gone"})
+	createLink(t, server, handler, Link{Path: "search/{*}", URL: target.URL + "/missing/{*}"})
+
+	store := server.store.(*Store)
+	checker := &linkChecker{store: store, client: target.Client()}
+	checked, broken, err := checker.checkAll(context.Background())
+	if err != nil {
+		t.Fatalf("checkAll: %v", err)
+	}
+	if checked != 4 || broken != 1 {
+		t.Errorf("checked %d, broken %d; want 4 checked, 1 broken", checked, broken)
+	}
+
+	count, err := store.CountBrokenLinks(context.Background())
+	if err != nil {
+		t.Fatalf("CountBrokenLinks: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("CountBrokenLinks = %d, want 1", count)
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"time"
+)
+
+// LinkStore is the storage the server depends on. Store, backed by SQLite, is
+// the production implementation; tests wrap it to inject failures.
+// Implementations report a missing link with sql.ErrNoRows and a taken path
+// with an "already exists" error, which the handlers map to 404 and 409.
+type LinkStore interface {
+	// Links
+	GetLinkByPath(ctx context.Context, path string) (*Link, error)
+	GetLinkByID(ctx context.Context, id int64) (*Link, error)
+	GetLinkByAlias(ctx context.Context, alias string) (*Link, error)
+	GetAllLinks(ctx context.Context) ([]Link, error)
+	FindLinksByURL(ctx context.Context, url string) ([]Link, error)
+	FindSimilarPaths(ctx context.Context, path string, limit int) ([]Link, error)
+	GetLinksPaged(ctx context.Context, sort LinkSort, limit, offset int) ([]Link, error)
+	GetLinksByTag(ctx context.Context, tag string, sort LinkSort) ([]Link, error)
+	GetLinksByOwner(ctx context.Context, owner string, sort LinkSort) ([]Link, error)
+	GetLinksByDomain(ctx context.Context) ([]DomainGroup, error)
+	GetRecentLinks(ctx context.Context, limit int) ([]Link, error)
+	GetChangedSince(ctx context.Context, since, until time.Time) ([]Link, []int64, error)
+	SearchLinks(ctx context.Context, query string, sort LinkSort) ([]Link, error)
+	SearchLinksFTS(ctx context.Context, query string) ([]Link, error)
+	ResolveLink(ctx context.Context, path string) (*Link, string, error)
+	EachLink(ctx context.Context, fn func(id int64, path, url string) error) error
+	CountLinks(ctx context.Context) (int64, error)
+	CountBrokenLinks(ctx context.Context) (int64, error)
+	LinkExists(ctx context.Context, id int64) (bool, error)
+	CreateLink(ctx context.Context, link Link) error
+	CreateLinksBulk(ctx context.Context, links []Link) error
+	ImportLinks(ctx context.Context, links []Link, opts ImportOptions) ([]error, error)
+	UpdateLink(ctx context.Context, id int64, link Link) error
+	DeleteLink(ctx context.Context, id int64) error
+	ToggleLink(ctx context.Context, id int64) (bool, error)
+	AddAlias(ctx context.Context, id int64, alias string) error
+	RemoveAlias(ctx context.Context, id int64, alias string) error
+
+	// Bulk edits
+	MoveGroup(ctx context.Context, from string, ids []int64, to string) (int, error)
+	RenameTag(ctx context.Context, from, to string) (int, error)
+	TransferOwnership(ctx context.Context, from, to string, ids []int64) (int, error)
+	SetExpiryByFilter(ctx context.Context, filter ExpiryFilter, createdBefore time.Time, expiresAt *time.Time) (int, error)
+	NormalizePaths(ctx context.Context, dryRun bool) (*NormalizePathsReport, error)
+
+	// Trash
+	ListTrashed(ctx context.Context) ([]TrashedLink, error)
+	RestoreLink(ctx context.Context, id int64) error
+	PurgeLink(ctx context.Context, id int64) error
+	IsDeletedPath(ctx context.Context, path string) (bool, error)
+
+	// Icons
+	SetLinkIcon(ctx context.Context, id int64, emoji string) error
+	SetLinkIconImage(ctx context.Context, id int64, contentType string, data []byte) error
+	GetLinkIconImage(ctx context.Context, link Link) (string, []byte, error)
+
+	// Usage
+	RecordClick(ctx context.Context, linkID int64, referrer, ua string) error
+	GetClickEvents(ctx context.Context, linkID int64, since time.Time) ([]ClickEvent, error)
+	RecordMiss(ctx context.Context, path string) error
+	CountVisitsSince(ctx context.Context, since time.Time) (int64, error)
+	GetVisitCounts(ctx context.Context, ids []int64) (map[int64]int64, error)
+	GetTopLinks(ctx context.Context, limit int) ([]LinkVisitCount, error)
+	GetMostClickedLinks(ctx context.Context, limit int) ([]Link, error)
+	TotalClicks(ctx context.Context) (int64, error)
+	GetTopMisses(ctx context.Context, limit int) ([]RedirectMiss, error)
+	GetNeverUsedLinks(ctx context.Context, createdBefore time.Time) ([]Link, error)
+	GetStaleRanked(ctx context.Context, now time.Time, limit, offset int) ([]StaleLink, int, error)
+	GetAudit(ctx context.Context, linkID int64, limit, offset int) ([]AuditEntry, int, error)
+
+	// Maintenance
+	Ping(ctx context.Context) error
+	CheckWritable(ctx context.Context) error
+	Snapshot(ctx context.Context) (*Snapshot, error)
+	Backup(ctx context.Context) (*LinkBackup, error)
+	BackupTo(ctx context.Context, path string) error
+	RestoreLinks(ctx context.Context, links []Link, mode string) (*RestoreReport, error)
+	VerifySchema(ctx context.Context) (*SchemaReport, error)
+	RepairSchema(ctx context.Context) (*SchemaReport, error)
+}
+
+var _ LinkStore = (*Store)(nil)
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+// failingStore is a real Store whose GetLinkByID fails, to exercise the
+// handlers' storage error paths.
+type failingStore struct {
+	*Store
+}
+
+var errStoreUnavailable = errors.New("database is unavailable")
+
+func (failingStore) GetLinkByID(ctx context.Context, id int64) (*Link, error) {
+	return nil, errStoreUnavailable
+}
+
+func TestHandlerStoreError(t *testing.T) {
+	server, handler := newTestServer(t, nil)
+	wiki := createLink(t, server, handler, Link{Path: "wiki", URL: "https://wiki.example.com"})
+	server.store = failingStore{server.store.(*Store)}
+
+	tests := []struct {
+		target string
+		status int
+	}{
+		{linkTarget(wiki.ID), http.StatusInternalServerError},
+		// Other methods still reach the database
+		{"/api/links", http.StatusOK},
+	}
+	for _, tt := range tests {
+		w := serve(t, handler, http.MethodGet, tt.target, nil)
+		if w.Code != tt.status {
+			t.Errorf("GET %s = %d, want %d: %s", tt.target, w.Code, tt.status, w.Body.String())
+			continue
+		}
+		if w.Code == http.StatusInternalServerError {
+			var response ErrorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil || response.Error == "" {
+				t.Errorf("GET %s body = %s, want an error message", tt.target, w.Body.String())
+			}
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// Log output formats accepted by LOG_FORMAT.
+const (
+	logFormatText = "text"
+	logFormatJSON = "json"
+)
+
+// parseLogLevel parses a LOG_LEVEL value: debug, info, warn or error.
+func parseLogLevel(value string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(strings.ToLower(value))); err != nil {
+		return 0, fmt.Errorf("invalid log level '%s': must be debug, info, warn or error", value)
+	}
+	return level, nil
+}
+
+// newLogger builds the logger configured by LOG_FORMAT and LOG_LEVEL,
+// writing to stderr. The configuration has been validated, so an unknown
+// level cannot occur here.
+func newLogger(config *Config) *slog.Logger {
+	level, _ := parseLogLevel(config.LogLevel)
+	options := &slog.HandlerOptions{Level: level}
+	if config.LogFormat == logFormatJSON {
+		return slog.New(slog.NewJSONHandler(os.Stderr, options))
+	}
+	return slog.New(slog.NewTextHandler(os.Stderr, options))
+}
+
+// logRequestError logs a failed request with its method and URL path. Extra
+// attributes, such as the link id, follow the error.
+func logRequestError(r *http.Request, msg string, err error, attrs ...any) {
+	args := append([]any{"method", r.Method, "url", r.URL.Path, "error", err}, attrs...)
+	slog.Error(msg, args...)
+}
+
+// accessLogWriter records the status code and size of a response.
+type accessLogWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (w *accessLogWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *accessLogWriter) Write(data []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(data)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *accessLogWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// accessLog logs every request with its status, response size and duration.
+// Health probes are skipped, since load balancers send them constantly.
+func accessLog(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/healthz" || r.URL.Path == "/readyz" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		start := time.Now()
+		recorder := &accessLogWriter{ResponseWriter: w}
+		next.ServeHTTP(recorder, r)
+		if recorder.status == 0 {
+			// Nothing was written, so net/http answers 200 with an empty body
+			recorder.status = http.StatusOK
+		}
+		slog.Info("Request",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", recorder.status,
+			"bytes", recorder.bytes,
+			"duration", time.Since(start),
+			"client", clientIP(r))
+	})
+}
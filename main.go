@@ -1,9 +1,15 @@
 package main
 
 import (
-	"log"
+	"context"
+	"log/slog"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
 	"strconv"
+	"sync"
+	"syscall"
 
 	restfulspec "github.com/emicklei/go-restful-openapi/v2"
 	restful "github.com/emicklei/go-restful/v3"
@@ -16,24 +22,226 @@ func setupAPI(server *Server) *restful.Container {
 	ws := new(restful.WebService)
 	ws.Path("/api").Consumes(restful.MIME_JSON).Produces(restful.MIME_JSON)
 
+	// With API_PUBLIC_READ, anyone may read but only token holders may write
+	if server.config.APIPublicRead {
+		ws.Filter(server.publicReadFilter)
+	}
+
 	// GET /api/links
 	ws.Route(ws.GET("/links").
 		To(func(req *restful.Request, resp *restful.Response) {
 			server.apiLinksHandler(resp.ResponseWriter, req.Request)
 		}).
-		Doc("List links").
+		Doc("List links, a page at a time").
+		Param(ws.QueryParameter("limit", "Page size (default 50, max 500)").DataType("integer")).
+		Param(ws.QueryParameter("offset", "Number of links to skip").DataType("integer")).
+		Param(ws.QueryParameter("sort", "path, clicks or created (default path)").DataType("string")).
+		Param(ws.QueryParameter("order", "asc or desc (default asc)").DataType("string")).
+		Param(ws.QueryParameter("tag", "Only links with this tag").DataType("string")).
+		Param(ws.QueryParameter("owner", "Only links owned or created by this user").DataType("string")).
 		Writes([]Link{}).
 		Metadata(restfulspec.KeyOpenAPITags, []string{"links"}))
 
+	// GET /api/links/changes
+	ws.Route(ws.GET("/links/changes").
+		To(func(req *restful.Request, resp *restful.Response) {
+			server.handleGetLinkChanges(resp.ResponseWriter, req.Request)
+		}).
+		Doc("List links changed or deleted since a timestamp").
+		Param(ws.QueryParameter("since", "RFC 3339 timestamp").DataType("string").Required(true)).
+		Writes(LinkChanges{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{"links"}))
+
+	// GET /api/links/visits
+	ws.Route(ws.GET("/links/visits").
+		To(func(req *restful.Request, resp *restful.Response) {
+			server.handleGetVisitCounts(resp.ResponseWriter, req.Request)
+		}).
+		Doc("Visit counts for many links at once").
+		Param(ws.QueryParameter("ids", "Comma-separated link IDs (all links when omitted)").DataType("string")).
+		Writes(map[string]int64{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{"stats"}))
+
+	// GET /api/links/misses
+	ws.Route(ws.GET("/links/misses").
+		To(func(req *restful.Request, resp *restful.Response) {
+			server.handleGetMisses(resp.ResponseWriter, req.Request)
+		}).
+		Doc("Most requested paths that have no link").
+		Param(ws.QueryParameter("limit", "Maximum number of paths (default 20, max 100)").DataType("integer")).
+		Writes([]RedirectMiss{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{"stats"}))
+
+	// GET /api/links/never-used
+	ws.Route(ws.GET("/links/never-used").
+		To(func(req *restful.Request, resp *restful.Response) {
+			server.handleGetNeverUsedLinks(resp.ResponseWriter, req.Request)
+		}).
+		Doc("Links that have never served a redirect, oldest first").
+		Param(ws.QueryParameter("older_than", "Minimum link age, e.g. 30d or 12h").DataType("string")).
+		Writes([]Link{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{"stats"}))
+
+	// GET /api/links/stale
+	ws.Route(ws.GET("/links/stale").
+		To(func(req *restful.Request, resp *restful.Response) {
+			server.handleGetStaleLinks(resp.ResponseWriter, req.Request)
+		}).
+		Doc("Links ranked by staleness score, stalest first").
+		Param(ws.QueryParameter("limit", "Page size (default 20, max 100)").DataType("integer")).
+		Param(ws.QueryParameter("offset", "Number of links to skip").DataType("integer")).
+		Writes([]StaleLink{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{"stats"}))
+
+	// GET /api/links/resolve
+	ws.Route(ws.GET("/links/resolve").
+		To(func(req *restful.Request, resp *restful.Response) {
+			server.handleResolveLink(resp.ResponseWriter, req.Request)
+		}).
+		Doc("Get the link stored under an exact path without redirecting").
+		Param(ws.QueryParameter("path", "Link path").DataType("string").Required(true)).
+		Writes(Link{}).
+		Returns(http.StatusOK, "OK", Link{}).
+		Returns(http.StatusNotFound, "Not Found", nil).
+		Metadata(restfulspec.KeyOpenAPITags, []string{"links"}))
+
+	// GET /api/links/available
+	ws.Route(ws.GET("/links/available").
+		To(func(req *restful.Request, resp *restful.Response) {
+			server.handleCheckAvailability(resp.ResponseWriter, req.Request)
+		}).
+		Doc("Check whether a path can be used for a new link").
+		Param(ws.QueryParameter("path", "Link path").DataType("string").Required(true)).
+		Writes(PathAvailability{}).
+		Returns(http.StatusOK, "OK", PathAvailability{}).
+		Returns(http.StatusBadRequest, "Bad Request", ErrorResponse{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{"links"}))
+
+	// GET /api/links/feed.xml
+	ws.Route(ws.GET("/links/feed.xml").
+		To(func(req *restful.Request, resp *restful.Response) {
+			server.handleLinksFeed(resp.ResponseWriter, req.Request)
+		}).
+		Doc("Atom feed of the most recently created links").
+		Produces("application/atom+xml").
+		Returns(http.StatusOK, "OK", nil).
+		Metadata(restfulspec.KeyOpenAPITags, []string{"links"}))
+
+	// GET /api/links/export.csv
+	ws.Route(ws.GET("/links/export.csv").
+		To(func(req *restful.Request, resp *restful.Response) {
+			server.handleExportCSV(resp.ResponseWriter, req.Request)
+		}).
+		Doc("Download all links as CSV (id,path,url)").
+		Produces("text/csv").
+		Returns(http.StatusOK, "OK", nil).
+		Metadata(restfulspec.KeyOpenAPITags, []string{"links"}))
+
+	// GET /api/links/by-domain
+	ws.Route(ws.GET("/links/by-domain").
+		To(func(req *restful.Request, resp *restful.Response) {
+			server.handleGetLinksByDomain(resp.ResponseWriter, req.Request)
+		}).
+		Doc("Links grouped by target host, largest groups first").
+		Writes([]DomainGroup{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{"links"}))
+
 	// POST /api/links
 	ws.Route(ws.POST("/links").
 		To(func(req *restful.Request, resp *restful.Response) {
 			server.apiLinksHandler(resp.ResponseWriter, req.Request)
 		}).
 		Doc("Create link").
+		Consumes(restful.MIME_JSON, "application/x-www-form-urlencoded").
+		Param(ws.QueryParameter("force", "Allow a soft-reserved path").DataType("boolean")).
 		Reads(Link{}).
 		Metadata(restfulspec.KeyOpenAPITags, []string{"links"}))
 
+	// POST /api/links/import
+	ws.Route(ws.POST("/links/import").
+		To(func(req *restful.Request, resp *restful.Response) {
+			server.handleImportLinks(resp.ResponseWriter, req.Request)
+		}).
+		Doc("Import links from a JSON array or CSV").
+		Consumes(restful.MIME_JSON, "text/csv").
+		Param(ws.QueryParameter("dry_run", "Report per-row outcomes without saving").DataType("boolean")).
+		Param(ws.QueryParameter("force", "Allow soft-reserved paths").DataType("boolean")).
+		Param(ws.QueryParameter("preserve_ids", "Store each link under its own id").DataType("boolean")).
+		Param(ws.QueryParameter("overwrite", "With preserve_ids, replace links already using the id").DataType("boolean")).
+		Reads([]Link{}).
+		Writes(ImportResponse{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{"links"}))
+
+	// POST /api/links/bulk
+	ws.Route(ws.POST("/links/bulk").
+		To(func(req *restful.Request, resp *restful.Response) {
+			server.handleBulkCreateLinks(resp.ResponseWriter, req.Request)
+		}).
+		Doc("Create links from a JSON array in one transaction, all or nothing").
+		Consumes(restful.MIME_JSON).
+		Param(ws.QueryParameter("force", "Allow soft-reserved paths").DataType("boolean")).
+		Reads([]Link{}).
+		Writes(BulkCreateResponse{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{"links"}))
+
+	// POST /api/links/transfer
+	ws.Route(ws.POST("/links/transfer").
+		To(func(req *restful.Request, resp *restful.Response) {
+			server.handleTransferOwnership(resp.ResponseWriter, req.Request)
+		}).
+		Doc("Transfer ownership of links (admin only)").
+		Reads(TransferRequest{}).
+		Writes(TransferResponse{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{"admin"}))
+
+	// POST /api/links/move-group
+	ws.Route(ws.POST("/links/move-group").
+		To(func(req *restful.Request, resp *restful.Response) {
+			server.handleMoveGroup(resp.ResponseWriter, req.Request)
+		}).
+		Doc("Move links between groups (admin only)").
+		Reads(MoveGroupRequest{}).
+		Writes(MoveGroupResponse{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{"admin"}))
+
+	// POST /api/links/set-expiry
+	ws.Route(ws.POST("/links/set-expiry").
+		To(func(req *restful.Request, resp *restful.Response) {
+			server.handleSetExpiry(resp.ResponseWriter, req.Request)
+		}).
+		Doc("Set or clear the expiration of links matching a filter (admin only)").
+		Reads(SetExpiryRequest{}).
+		Writes(SetExpiryResponse{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{"admin"}))
+
+	// POST /api/tags/rename
+	ws.Route(ws.POST("/tags/rename").
+		To(func(req *restful.Request, resp *restful.Response) {
+			server.handleRenameTag(resp.ResponseWriter, req.Request)
+		}).
+		Doc("Rename a tag across all links (admin only)").
+		Reads(TagRenameRequest{}).
+		Writes(TagRenameResponse{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{"admin"}))
+
+	// GET /api/links/{id}
+	ws.Route(ws.GET("/links/{id}").
+		To(func(req *restful.Request, resp *restful.Response) {
+			idStr := req.PathParameter("id")
+			id, err := strconv.ParseInt(idStr, 10, 64)
+			if err != nil {
+				resp.WriteErrorString(http.StatusBadRequest, "invalid id")
+				return
+			}
+			server.apiLinkIDHandler(resp.ResponseWriter, req.Request, id)
+		}).
+		Doc("Get link").
+		Param(ws.PathParameter("id", "Link ID").DataType("integer")).
+		Writes(Link{}).
+		Returns(http.StatusOK, "OK", Link{}).
+		Returns(http.StatusNotFound, "Not Found", nil).
+		Metadata(restfulspec.KeyOpenAPITags, []string{"links"}))
+
 	// PUT /api/links/{id}
 	ws.Route(ws.PUT("/links/{id}").
 		To(func(req *restful.Request, resp *restful.Response) {
@@ -46,7 +254,9 @@ func setupAPI(server *Server) *restful.Container {
 			server.apiLinkIDHandler(resp.ResponseWriter, req.Request, id)
 		}).
 		Doc("Update link").
+		Consumes(restful.MIME_JSON, "application/x-www-form-urlencoded").
 		Param(ws.PathParameter("id", "Link ID").DataType("integer")).
+		Param(ws.QueryParameter("force", "Allow a soft-reserved path").DataType("boolean")).
 		Reads(Link{}).
 		Metadata(restfulspec.KeyOpenAPITags, []string{"links"}))
 
@@ -65,9 +275,350 @@ func setupAPI(server *Server) *restful.Container {
 		Param(ws.PathParameter("id", "Link ID").DataType("integer")).
 		Metadata(restfulspec.KeyOpenAPITags, []string{"links"}))
 
+	// GET /api/links/{id}/test-redirect
+	ws.Route(ws.GET("/links/{id}/test-redirect").
+		To(func(req *restful.Request, resp *restful.Response) {
+			idStr := req.PathParameter("id")
+			id, err := strconv.ParseInt(idStr, 10, 64)
+			if err != nil {
+				resp.WriteErrorString(http.StatusBadRequest, "invalid id")
+				return
+			}
+			server.handleTestRedirect(resp.ResponseWriter, req.Request, id)
+		}).
+		Doc("Redirect to a link's target without counting a visit").
+		Param(ws.PathParameter("id", "Link ID").DataType("integer")).
+		Returns(http.StatusFound, "Found (Location is the link target)", nil).
+		Metadata(restfulspec.KeyOpenAPITags, []string{"links"}))
+
+	// POST /api/links/{id}/restore
+	ws.Route(ws.POST("/links/{id}/restore").
+		To(func(req *restful.Request, resp *restful.Response) {
+			idStr := req.PathParameter("id")
+			id, err := strconv.ParseInt(idStr, 10, 64)
+			if err != nil {
+				resp.WriteErrorString(http.StatusBadRequest, "invalid id")
+				return
+			}
+			server.handleRestoreLink(resp.ResponseWriter, req.Request, id)
+		}).
+		Doc("Restore a deleted link from the trash").
+		Param(ws.PathParameter("id", "Link ID").DataType("integer")).
+		AllowedMethodsWithoutContentType([]string{http.MethodPost}).
+		Writes(Link{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{"links"}))
+
+	// GET /api/links/{id}/clicks
+	ws.Route(ws.GET("/links/{id}/clicks").
+		To(func(req *restful.Request, resp *restful.Response) {
+			idStr := req.PathParameter("id")
+			id, err := strconv.ParseInt(idStr, 10, 64)
+			if err != nil {
+				resp.WriteErrorString(http.StatusBadRequest, "invalid id")
+				return
+			}
+			server.handleGetClickEvents(resp.ResponseWriter, req.Request, id)
+		}).
+		Doc("List a link's click events since a timestamp, oldest first").
+		Param(ws.PathParameter("id", "Link ID").DataType("integer")).
+		Param(ws.QueryParameter("since", "RFC 3339 timestamp").DataType("string").Required(true)).
+		Writes([]ClickEvent{}).
+		Returns(http.StatusBadRequest, "Bad Request", ErrorResponse{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{"stats"}))
+
+	// POST /api/links/{id}/aliases
+	ws.Route(ws.POST("/links/{id}/aliases").
+		To(func(req *restful.Request, resp *restful.Response) {
+			idStr := req.PathParameter("id")
+			id, err := strconv.ParseInt(idStr, 10, 64)
+			if err != nil {
+				resp.WriteErrorString(http.StatusBadRequest, "invalid id")
+				return
+			}
+			server.handleAddAlias(resp.ResponseWriter, req.Request, id)
+		}).
+		Doc("Add an alias path that redirects to the link's URL").
+		Param(ws.PathParameter("id", "Link ID").DataType("integer")).
+		Param(ws.QueryParameter("force", "Allow a soft-reserved path").DataType("boolean")).
+		Reads(AliasRequest{}).
+		Writes(Link{}).
+		Returns(http.StatusCreated, "Created", Link{}).
+		Returns(http.StatusConflict, "Path already in use", ErrorResponse{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{"links"}))
+
+	// DELETE /api/links/{id}/aliases/{alias}
+	ws.Route(ws.DELETE("/links/{id}/aliases/{alias:*}").
+		To(func(req *restful.Request, resp *restful.Response) {
+			idStr := req.PathParameter("id")
+			id, err := strconv.ParseInt(idStr, 10, 64)
+			if err != nil {
+				resp.WriteErrorString(http.StatusBadRequest, "invalid id")
+				return
+			}
+			server.handleRemoveAlias(resp.ResponseWriter, req.Request, id, req.PathParameter("alias"))
+		}).
+		Doc("Remove an alias path from a link").
+		Param(ws.PathParameter("id", "Link ID").DataType("integer")).
+		Param(ws.PathParameter("alias", "Alias path")).
+		Returns(http.StatusNoContent, "No Content", nil).
+		Metadata(restfulspec.KeyOpenAPITags, []string{"links"}))
+
+	// GET /api/trash
+	ws.Route(ws.GET("/trash").
+		To(func(req *restful.Request, resp *restful.Response) {
+			server.handleListTrash(resp.ResponseWriter, req.Request)
+		}).
+		Doc("List deleted links, most recently deleted first").
+		Writes([]TrashedLink{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{"links"}))
+
+	// DELETE /api/trash/{id}
+	ws.Route(ws.DELETE("/trash/{id}").
+		To(func(req *restful.Request, resp *restful.Response) {
+			idStr := req.PathParameter("id")
+			id, err := strconv.ParseInt(idStr, 10, 64)
+			if err != nil {
+				resp.WriteErrorString(http.StatusBadRequest, "invalid id")
+				return
+			}
+			server.handlePurgeLink(resp.ResponseWriter, req.Request, id)
+		}).
+		Doc("Permanently delete a link from the trash").
+		Param(ws.PathParameter("id", "Link ID").DataType("integer")).
+		Returns(http.StatusNoContent, "No Content", nil).
+		Metadata(restfulspec.KeyOpenAPITags, []string{"links"}))
+
+	// GET /api/links/{id}/history
+	ws.Route(ws.GET("/links/{id}/history").
+		To(func(req *restful.Request, resp *restful.Response) {
+			idStr := req.PathParameter("id")
+			id, err := strconv.ParseInt(idStr, 10, 64)
+			if err != nil {
+				resp.WriteErrorString(http.StatusBadRequest, "invalid id")
+				return
+			}
+			server.handleGetLinkHistory(resp.ResponseWriter, req.Request, id)
+		}).
+		Doc("List changes made to a link").
+		Param(ws.PathParameter("id", "Link ID").DataType("integer")).
+		Param(ws.QueryParameter("limit", "Page size").DataType("integer")).
+		Param(ws.QueryParameter("offset", "Number of entries to skip").DataType("integer")).
+		Writes([]AuditEntry{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{"links"}))
+
+	// POST /api/links/{id}/icon
+	ws.Route(ws.POST("/links/{id}/icon").
+		To(func(req *restful.Request, resp *restful.Response) {
+			idStr := req.PathParameter("id")
+			id, err := strconv.ParseInt(idStr, 10, 64)
+			if err != nil {
+				resp.WriteErrorString(http.StatusBadRequest, "invalid id")
+				return
+			}
+			server.handleSetIcon(resp.ResponseWriter, req.Request, id)
+		}).
+		Doc("Set a link icon from an emoji or an uploaded image (multipart field \"icon\")").
+		Consumes(restful.MIME_JSON, "multipart/form-data").
+		Param(ws.PathParameter("id", "Link ID").DataType("integer")).
+		Reads(IconRequest{}).
+		Writes(Link{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{"links"}))
+
+	// POST /api/links/{id}/toggle
+	ws.Route(ws.POST("/links/{id}/toggle").
+		To(func(req *restful.Request, resp *restful.Response) {
+			idStr := req.PathParameter("id")
+			id, err := strconv.ParseInt(idStr, 10, 64)
+			if err != nil {
+				resp.WriteErrorString(http.StatusBadRequest, "invalid id")
+				return
+			}
+			server.handleToggleLink(resp.ResponseWriter, req.Request, id)
+		}).
+		Doc("Enable a disabled link or disable an enabled one").
+		Param(ws.PathParameter("id", "Link ID").DataType("integer")).
+		AllowedMethodsWithoutContentType([]string{http.MethodPost}).
+		Writes(Link{}).
+		Returns(http.StatusOK, "OK", Link{}).
+		Returns(http.StatusNotFound, "Not Found", nil).
+		Metadata(restfulspec.KeyOpenAPITags, []string{"links"}))
+
+	// GET /api/links/{id}/icon
+	ws.Route(ws.GET("/links/{id}/icon").
+		To(func(req *restful.Request, resp *restful.Response) {
+			idStr := req.PathParameter("id")
+			id, err := strconv.ParseInt(idStr, 10, 64)
+			if err != nil {
+				resp.WriteErrorString(http.StatusBadRequest, "invalid id")
+				return
+			}
+			server.handleGetIcon(resp.ResponseWriter, req.Request, id)
+		}).
+		Doc("Get a link icon: the uploaded image, or the emoji as JSON").
+		Produces(restful.MIME_JSON, "image/png", "image/gif", "image/jpeg", "image/webp").
+		Param(ws.PathParameter("id", "Link ID").DataType("integer")).
+		Writes(IconRequest{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{"links"}))
+
+	// GET /api/audit
+	ws.Route(ws.GET("/audit").
+		To(func(req *restful.Request, resp *restful.Response) {
+			server.handleGetAudit(resp.ResponseWriter, req.Request)
+		}).
+		Doc("List changes made to all links, newest first").
+		Param(ws.QueryParameter("limit", "Page size").DataType("integer")).
+		Param(ws.QueryParameter("offset", "Number of entries to skip").DataType("integer")).
+		Writes([]AuditEntry{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{"links"}))
+
+	// GET /api/links/{id}/unfurl
+	ws.Route(ws.GET("/links/{id}/unfurl").
+		To(func(req *restful.Request, resp *restful.Response) {
+			idStr := req.PathParameter("id")
+			id, err := strconv.ParseInt(idStr, 10, 64)
+			if err != nil {
+				resp.WriteErrorString(http.StatusBadRequest, "invalid id")
+				return
+			}
+			server.handleGetUnfurl(resp.ResponseWriter, req.Request, id)
+		}).
+		Doc("Unfurl preview of a link for chat tools").
+		Param(ws.PathParameter("id", "Link ID").DataType("integer")).
+		Writes(Unfurl{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{"links"}))
+
+	// GET /api/snapshot
+	ws.Route(ws.GET("/snapshot").
+		To(func(req *restful.Request, resp *restful.Response) {
+			server.handleGetSnapshot(resp.ResponseWriter, req.Request)
+		}).
+		Doc("Snapshot of all links with a checksum for comparing environments").
+		Writes(Snapshot{}).
+		Returns(http.StatusOK, "OK", Snapshot{}).
+		Returns(http.StatusNotModified, "Not Modified (checksum matches If-None-Match)", nil).
+		Metadata(restfulspec.KeyOpenAPITags, []string{"links"}))
+
+	// GET /api/resolve
+	ws.Route(ws.GET("/resolve").
+		To(func(req *restful.Request, resp *restful.Response) {
+			server.handleResolve(resp.ResponseWriter, req.Request)
+		}).
+		Doc("Resolve a path to its link without redirecting").
+		Param(ws.QueryParameter("path", "Link path").DataType("string").Required(true)).
+		Param(ws.QueryParameter("suggest", "Include similar existing paths on a miss").DataType("boolean")).
+		Writes(ResolveResult{}).
+		Returns(http.StatusOK, "OK", ResolveResult{}).
+		Returns(http.StatusNotFound, "Not Found", ResolveResult{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{"links"}))
+
+	// GET /api/trace
+	ws.Route(ws.GET("/trace").
+		To(func(req *restful.Request, resp *restful.Response) {
+			server.handleTrace(resp.ResponseWriter, req.Request)
+		}).
+		Doc("Trace the chain of links a path redirects through").
+		Param(ws.QueryParameter("path", "Link path").DataType("string").Required(true)).
+		Writes(TraceResult{}).
+		Returns(http.StatusOK, "OK", TraceResult{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{"links"}))
+
+	// GET /api/analytics/top
+	ws.Route(ws.GET("/analytics/top").
+		To(func(req *restful.Request, resp *restful.Response) {
+			server.handleGetTopClicked(resp.ResponseWriter, req.Request)
+		}).
+		Doc("Most clicked links with click totals").
+		Param(ws.QueryParameter("limit", "Maximum number of links (default 10, max 100)").DataType("integer")).
+		Writes(TopClickedResponse{}).
+		Returns(http.StatusOK, "OK", TopClickedResponse{}).
+		Returns(http.StatusBadRequest, "Bad Request", ErrorResponse{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{"stats"}))
+
+	// GET /api/dashboard
+	ws.Route(ws.GET("/dashboard").
+		To(func(req *restful.Request, resp *restful.Response) {
+			server.handleGetDashboard(resp.ResponseWriter, req.Request)
+		}).
+		Doc("Dashboard statistics for status pages").
+		Writes(DashboardStats{}).
+		Returns(http.StatusOK, "OK", DashboardStats{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{"stats"}))
+
+	// GET /api/config
+	ws.Route(ws.GET("/config").
+		To(func(req *restful.Request, resp *restful.Response) {
+			server.handleGetConfig(resp.ResponseWriter, req.Request)
+		}).
+		Doc("Effective server configuration with secrets redacted (admin only)").
+		Writes(RedactedConfig{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{"admin"}))
+
+	// GET /api/backup
+	ws.Route(ws.GET("/backup").
+		To(func(req *restful.Request, resp *restful.Response) {
+			server.handleBackup(resp.ResponseWriter, req.Request)
+		}).
+		Doc("Download every link as a JSON backup document (admin only)").
+		Writes(LinkBackup{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{"admin"}))
+
+	// POST /api/restore
+	ws.Route(ws.POST("/restore").
+		To(func(req *restful.Request, resp *restful.Response) {
+			server.handleRestore(resp.ResponseWriter, req.Request)
+		}).
+		Doc("Load links from a JSON backup document (admin only)").
+		Consumes(restful.MIME_JSON).
+		Param(ws.QueryParameter("mode", "replace (default) swaps out all links, merge upserts by path").DataType("string")).
+		Reads(LinkBackup{}).
+		Writes(RestoreReport{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{"admin"}))
+
+	// POST /api/maintenance/backup
+	ws.Route(ws.POST("/maintenance/backup").
+		To(func(req *restful.Request, resp *restful.Response) {
+			server.handleCreateBackup(resp.ResponseWriter, req.Request)
+		}).
+		Doc("Write an on-demand database backup (admin only)").
+		AllowedMethodsWithoutContentType([]string{http.MethodPost}).
+		Writes(BackupResponse{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{"admin"}))
+
+	// POST /api/maintenance/verify-schema
+	ws.Route(ws.POST("/maintenance/verify-schema").
+		To(func(req *restful.Request, resp *restful.Response) {
+			server.handleVerifySchema(resp.ResponseWriter, req.Request)
+		}).
+		Doc("Report missing tables, columns and indexes (admin only)").
+		AllowedMethodsWithoutContentType([]string{http.MethodPost}).
+		Writes(SchemaReport{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{"admin"}))
+
+	// POST /api/maintenance/repair-schema
+	ws.Route(ws.POST("/maintenance/repair-schema").
+		To(func(req *restful.Request, resp *restful.Response) {
+			server.handleRepairSchema(resp.ResponseWriter, req.Request)
+		}).
+		Doc("Recreate missing indexes (admin only)").
+		AllowedMethodsWithoutContentType([]string{http.MethodPost}).
+		Writes(SchemaReport{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{"admin"}))
+
+	// POST /api/maintenance/normalize-paths
+	ws.Route(ws.POST("/maintenance/normalize-paths").
+		To(func(req *restful.Request, resp *restful.Response) {
+			server.handleNormalizePaths(resp.ResponseWriter, req.Request)
+		}).
+		Doc("Lowercase mixed-case paths and report collisions (admin only)").
+		Param(ws.QueryParameter("dry_run", "Report changes and conflicts without saving").DataType("boolean")).
+		AllowedMethodsWithoutContentType([]string{http.MethodPost}).
+		Writes(NormalizePathsReport{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{"admin"}))
+
 	container.Add(ws)
 
-	// OpenAPI service mounted at /api/swagger/openapi.json
+	// OpenAPI service mounted at /api/swagger/openapi.json (supports ?tags= filtering),
+	// unless SWAGGER_ENABLED is off
 	cfg := restfulspec.Config{
 		WebServices: []*restful.WebService{ws},
 		APIPath:     "/api/swagger/openapi.json",
@@ -84,7 +635,9 @@ func setupAPI(server *Server) *restful.Container {
 			sw.Schemes = []string{"https"}
 		},
 	}
-	container.Add(restfulspec.NewOpenAPIService(cfg))
+	if server.config.SwaggerEnabled {
+		container.Add(newOpenAPIService(cfg))
+	}
 	return container
 }
 
@@ -104,34 +657,121 @@ func main() {
 	// Load configuration from environment variables and command line flags
 	config, err := LoadConfig()
 	if err != nil {
-		log.Fatalf("Failed to load configuration: %v", err)
+		slog.Error("Failed to load configuration", "error", err)
+		os.Exit(1)
 	}
 
-	// Log the configuration being used
-	log.Printf("Starting Go Links server with configuration: %s", config)
+	// Log with the configured format and level from here on; the standard
+	// logger used by dependencies goes through it too
+	slog.SetDefault(newLogger(config))
+
+	// Log the build and the configuration being used
+	info := buildInfo()
+	slog.Info("Starting Go Links server", "version", info.Version, "commit", info.Commit, "build_date", info.BuildDate, "go_version", info.GoVersion, "config", config.Redacted())
 
 	// Initialize the database store.
-	store, err := NewStore(config.DBPath)
+	store, err := NewStore(config)
 	if err != nil {
-		log.Fatalf("Failed to create store: %v", err)
+		slog.Error("Failed to create store", "error", err)
+		os.Exit(1)
 	}
-	defer store.Close()
+
+	// Background workers stop when ctx is cancelled on SIGINT/SIGTERM and
+	// report completion through workers, so the store outlives them.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	var workers sync.WaitGroup
+
+	// Start scheduled backups if configured.
+	startBackupScheduler(ctx, &workers, store, config)
+
+	// Start deleting expired links if configured.
+	startExpiryPurge(ctx, &workers, store, config)
+
+	// Check link targets for the dashboard's broken count if configured.
+	startLinkChecker(ctx, &workers, store, config)
 
 	// Initialize the server with the store.
-	server, err := NewServer(store)
+	server, err := NewServer(store, config)
+	if err != nil {
+		slog.Error("Failed to create server", "error", err)
+		os.Exit(1)
+	}
+
+	// Write click events in the background so redirects stay fast.
+	server.clicks.start(ctx, &workers)
+	server.webhooks.start(ctx, &workers)
+
+	// Bind first so an OS-assigned port ("auto" or 0) can be logged
+	listener, err := net.Listen("tcp", config.Address())
 	if err != nil {
-		log.Fatalf("Failed to create server: %v", err)
+		slog.Error("Server failed to start", "error", err)
+		os.Exit(1)
+	}
+	httpServer := &http.Server{Handler: newHandler(server, config), TLSConfig: config.TLSConfig()}
+	serverErr := make(chan error, 1)
+	go func() {
+		if config.TLSEnabled() {
+			slog.Info("Server starting", "addr", listener.Addr().String(), "tls_min_version", config.TLSMinVersion)
+			serverErr <- httpServer.ServeTLS(listener, config.TLSCertFile, config.TLSKeyFile)
+			return
+		}
+		slog.Info("Server starting", "addr", listener.Addr().String())
+		serverErr <- httpServer.Serve(listener)
+	}()
+
+	select {
+	case err := <-serverErr:
+		slog.Error("Server failed to start", "error", err)
+		os.Exit(1)
+	case <-ctx.Done():
+	}
+	stop()
+
+	slog.Info("Shutting down", "timeout", config.ShutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), config.ShutdownTimeout)
+	defer cancel()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		slog.Warn("HTTP shutdown did not complete", "error", err)
+	} else {
+		slog.Info("HTTP connections drained")
+	}
+
+	drained := make(chan struct{})
+	go func() {
+		workers.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+		slog.Info("Background workers stopped")
+	case <-shutdownCtx.Done():
+		slog.Warn("Background workers did not stop in time", "timeout", config.ShutdownTimeout)
+	}
+
+	slog.Info("Closing database")
+	if err := store.Close(); err != nil {
+		slog.Error("Failed to close database", "error", err)
 	}
+	slog.Info("Server stopped")
+}
 
+// newHandler wires the routes and middleware that serve every request.
+func newHandler(server *Server, config *Config) http.Handler {
 	// Routes: /api via go-restful (auto OpenAPI), others via net/http
 	apiContainer := setupAPI(server)
 	mux := http.NewServeMux()
 	mux.Handle("/api/", apiContainer)
-	mux.HandleFunc("/swagger", swaggerUIHandler)
+	if config.SwaggerEnabled {
+		mux.HandleFunc("/swagger", swaggerUIHandler)
+	} else {
+		// Answer 404 rather than falling through to link redirects
+		mux.Handle("/swagger", http.NotFoundHandler())
+	}
 	mux.HandleFunc("/", server.rootHandler)
 
-	log.Printf("Server starting on %s...", config.Address())
-	if err := http.ListenAndServe(config.Address(), mux); err != nil {
-		log.Fatalf("Server failed to start: %v", err)
-	}
+	// Basic auth, when configured, covers the portal and API but not redirects;
+	// the access log sits outside it so rejected requests are logged too, and
+	// outside compression so it records the bytes actually sent
+	return accessLog(gzipResponses(server.basicAuth(mux), config))
 }
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+)
+
+// schemaExecer runs statements against either the database or a transaction.
+type schemaExecer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+}
+
+// migration is one numbered schema change. Databases created before
+// schema_migrations existed already have some of these changes, so every
+// migration must succeed on a schema that already contains it.
+type migration struct {
+	version int
+	name    string
+	apply   func(tx *sql.Tx) error
+}
+
+// migrations lists the schema changes in the order they are applied. Append
+// new migrations with the next version number; never edit or reorder applied
+// ones. Columns and tables added here belong in schemaColumns too.
+var migrations = []migration{
+	{1, "create links table", execSQL(`CREATE TABLE IF NOT EXISTS links (
+		"id" INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+		"path" TEXT NOT NULL UNIQUE,
+		"url" TEXT NOT NULL
+	);`)},
+	{2, "add links.rate_limit", addColumn("links", "rate_limit", "INTEGER NOT NULL DEFAULT 0")},
+	{3, "add links.owner", addColumn("links", "owner", "TEXT NOT NULL DEFAULT ''")},
+	{4, "add links.icon", addColumn("links", "icon", "TEXT NOT NULL DEFAULT ''")},
+	{5, "add links.updated_at", func(tx *sql.Tx) error {
+		if err := addColumnIfMissing(tx, "links", "updated_at", "DATETIME"); err != nil {
+			return err
+		}
+		_, err := tx.Exec("UPDATE links SET updated_at = " + sqliteNowMilli + " WHERE updated_at IS NULL")
+		return err
+	}},
+	{6, "add links.created_at", func(tx *sql.Tx) error {
+		if err := addColumnIfMissing(tx, "links", "created_at", "DATETIME"); err != nil {
+			return err
+		}
+		_, err := tx.Exec("UPDATE links SET created_at = updated_at WHERE created_at IS NULL")
+		return err
+	}},
+	{7, "add links.prefix", addColumn("links", "prefix", "BOOLEAN NOT NULL DEFAULT 0")},
+	{8, "add links.link_group", addColumn("links", "link_group", "TEXT NOT NULL DEFAULT ''")},
+	{9, "add links.description", addColumn("links", "description", "TEXT NOT NULL DEFAULT ''")},
+	{10, "add links.created_by", addColumn("links", "created_by", "TEXT NOT NULL DEFAULT ''")},
+	{11, "add links.templated", addColumn("links", "templated", "BOOLEAN NOT NULL DEFAULT 0")},
+	{12, "add links.clicks", addColumn("links", "clicks", "INTEGER NOT NULL DEFAULT 0")},
+	{13, "add links.last_accessed_at", addColumn("links", "last_accessed_at", "DATETIME")},
+	{14, "add links.expires_at", addColumn("links", "expires_at", "DATETIME")},
+	{15, "add links.host", func(tx *sql.Tx) error {
+		if err := addColumnIfMissing(tx, "links", "host", "TEXT NOT NULL DEFAULT ''"); err != nil {
+			return err
+		}
+		return backfillHosts(tx)
+	}},
+	// Used to lowercase paths in place, silently skipping collisions. Mixed-case
+	// paths are now reported at startup and fixed with NormalizePaths, which
+	// audits every rename and lists the conflicts
+	{16, "lowercase link paths", func(tx *sql.Tx) error { return nil }},
+	{17, "create deleted_links table", execSQL(`CREATE TABLE IF NOT EXISTS deleted_links (
+		"link_id" INTEGER NOT NULL PRIMARY KEY,
+		"deleted_at" DATETIME NOT NULL
+	);`)},
+	{18, "add deleted_links.path", addColumn("deleted_links", "path", "TEXT NOT NULL DEFAULT ''")},
+	{19, "add deleted_links.data", addColumn("deleted_links", "data", "TEXT")},
+	{20, "create link_audit table", execSQL(`CREATE TABLE IF NOT EXISTS link_audit (
+		"id" INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+		"link_id" INTEGER NOT NULL,
+		"action" TEXT NOT NULL,
+		"changes" TEXT NOT NULL DEFAULT '{}',
+		"created_at" DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);`)},
+	{21, "create link_icons table", execSQL(`CREATE TABLE IF NOT EXISTS link_icons (
+		"id" INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+		"link_id" INTEGER NOT NULL,
+		"content_type" TEXT NOT NULL,
+		"data" BLOB NOT NULL,
+		"created_at" DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);`)},
+	{22, "create link_tags table", execSQL(`CREATE TABLE IF NOT EXISTS link_tags (
+		"link_id" INTEGER NOT NULL,
+		"tag" TEXT NOT NULL,
+		PRIMARY KEY (link_id, tag)
+	);`)},
+	{23, "create redirect_misses table", execSQL(`CREATE TABLE IF NOT EXISTS redirect_misses (
+		"path" TEXT NOT NULL PRIMARY KEY,
+		"count" INTEGER NOT NULL DEFAULT 0,
+		"last_seen" DATETIME NOT NULL
+	);`)},
+	{24, "create link_visits table", execSQL(`CREATE TABLE IF NOT EXISTS link_visits (
+		"id" INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+		"link_id" INTEGER NOT NULL,
+		"visited_at" DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);`)},
+	// Written (and rolled back) by deep health checks
+	{25, "create health_checks table", execSQL(`CREATE TABLE IF NOT EXISTS health_checks (
+		"id" INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+		"checked_at" DATETIME NOT NULL
+	);`)},
+	{26, "create link_aliases table", execSQL(`CREATE TABLE IF NOT EXISTS link_aliases (
+		"alias" TEXT NOT NULL PRIMARY KEY,
+		"link_id" INTEGER NOT NULL,
+		"created_at" DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);`)},
+	{27, "create click_events table", execSQL(`CREATE TABLE IF NOT EXISTS click_events (
+		"id" INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+		"link_id" INTEGER NOT NULL,
+		"referrer" TEXT NOT NULL DEFAULT '',
+		"user_agent" TEXT NOT NULL DEFAULT '',
+		"clicked_at" DATETIME NOT NULL
+	);`)},
+	{28, "add links.enabled", addColumn("links", "enabled", "BOOLEAN NOT NULL DEFAULT 1")},
+	{29, "create link_checks table", execSQL(`CREATE TABLE IF NOT EXISTS link_checks (
+		"link_id" INTEGER NOT NULL PRIMARY KEY,
+		"status" INTEGER NOT NULL DEFAULT 0,
+		"error" TEXT NOT NULL DEFAULT '',
+		"broken" BOOLEAN NOT NULL DEFAULT 0,
+		"checked_at" DATETIME NOT NULL
+	);`)},
+	// Click totals live in links.clicks and timestamps in click_events, so
+	// visits from before click events existed move there and link_visits goes
+	{30, "move link_visits into click_events", func(tx *sql.Tx) error {
+		return moveVisitsToClickEvents(tx)
+	}},
+}
+
+// execSQL returns a migration step running a single statement.
+func execSQL(statement string) func(tx *sql.Tx) error {
+	return func(tx *sql.Tx) error {
+		_, err := tx.Exec(statement)
+		return err
+	}
+}
+
+// addColumn returns a migration step adding a column unless it exists.
+func addColumn(table, column, definition string) func(tx *sql.Tx) error {
+	return func(tx *sql.Tx) error {
+		return addColumnIfMissing(tx, table, column, definition)
+	}
+}
+
+// migrate applies the migrations not yet recorded in schema_migrations, each
+// in its own transaction together with its record, so a failed migration
+// leaves the database at the previous version.
+func migrate(db *sql.DB) error {
+	createSQL := `CREATE TABLE IF NOT EXISTS schema_migrations (
+		"version" INTEGER NOT NULL PRIMARY KEY,
+		"name" TEXT NOT NULL,
+		"applied_at" DATETIME NOT NULL
+	);`
+	if _, err := db.Exec(createSQL); err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		if applied[m.version] {
+			continue
+		}
+		slog.Info("Applying migration", "version", m.version, "name", m.name)
+		if err := applyMigration(db, m); err != nil {
+			return fmt.Errorf("migration %d (%s) failed: %w", m.version, m.name, err)
+		}
+	}
+	return nil
+}
+
+// appliedMigrations returns the set of versions recorded in schema_migrations.
+func appliedMigrations(db *sql.DB) (map[int]bool, error) {
+	rows, err := db.Query(`SELECT version FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int]bool)
+	for rows.Next() {
+		var version int
+		if err := rows.Scan(&version); err != nil {
+			return nil, err
+		}
+		applied[version] = true
+	}
+	return applied, rows.Err()
+}
+
+// applyMigration runs one migration and records it.
+func applyMigration(db *sql.DB, m migration) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := m.apply(tx); err != nil {
+		return err
+	}
+	recordSQL := `INSERT INTO schema_migrations(version, name, applied_at) VALUES(?, ?, ` + sqliteNowMilli + `)`
+	if _, err := tx.Exec(recordSQL, m.version, m.name); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
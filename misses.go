@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Limits for the number of missed paths returned at once.
+const (
+	defaultMissesLimit = 20
+	maxMissesLimit     = 100
+)
+
+// RedirectMiss counts requests for a path that has no link.
+type RedirectMiss struct {
+	Path     string    `json:"path"`
+	Count    int64     `json:"count"`
+	LastSeen time.Time `json:"last_seen"`
+}
+
+// RecordMiss counts a request for a path that has no link.
+func (s *Store) RecordMiss(ctx context.Context, path string) error {
+	upsertSQL := `INSERT INTO redirect_misses(path, count, last_seen) VALUES(?, 1, ` + sqliteNowMilli + `)
+		ON CONFLICT(path) DO UPDATE SET count = count + 1, last_seen = excluded.last_seen`
+	_, err := s.db.ExecContext(ctx, upsertSQL, path)
+	return err
+}
+
+// GetTopMisses retrieves the most requested paths that still have no link.
+func (s *Store) GetTopMisses(ctx context.Context, limit int) ([]RedirectMiss, error) {
+	query := `SELECT m.path, m.count, m.last_seen FROM redirect_misses m
+		WHERE NOT EXISTS (SELECT 1 FROM links l WHERE l.path = m.path)
+		ORDER BY m.count DESC, m.last_seen DESC
+		LIMIT ?`
+	rows, err := s.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	misses := []RedirectMiss{}
+	for rows.Next() {
+		var miss RedirectMiss
+		if err := rows.Scan(&miss.Path, &miss.Count, &miss.LastSeen); err != nil {
+			return nil, err
+		}
+		misses = append(misses, miss)
+	}
+	return misses, rows.Err()
+}
+
+// recordMiss counts a missed path if it could become a link; probes for
+// malformed or reserved paths are not worth surfacing.
+func (s *Server) recordMiss(ctx context.Context, path string) {
+	if validatePath(path) != nil {
+		return
+	}
+	if err := s.store.RecordMiss(ctx, path); err != nil {
+		slog.Error("Error recording miss", "path", path, "error", err)
+	}
+}
+
+// parseMissesLimit reads the limit query parameter of the misses views.
+func parseMissesLimit(r *http.Request) (int, error) {
+	value := r.URL.Query().Get("limit")
+	if value == "" {
+		return defaultMissesLimit, nil
+	}
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 1 || limit > maxMissesLimit {
+		return 0, fmt.Errorf("limit must be a number between 1 and %d", maxMissesLimit)
+	}
+	return limit, nil
+}
+
+// handleGetMisses returns the most requested paths that have no link.
+// GetMisses godoc
+// @Summary      Missed paths
+// @Description  Most requested paths without a link, with request counts
+// @Tags         stats
+// @Produce      json
+// @Param        limit  query  int  false  "Maximum number of paths (default 20, max 100)"
+// @Success      200  {array}   RedirectMiss
+// @Failure      400  {object}  ErrorResponse
+// @Router       /links/misses [get]
+func (s *Server) handleGetMisses(w http.ResponseWriter, r *http.Request) {
+	limit, err := parseMissesLimit(r)
+	if err != nil {
+		writeErrorJSON(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	misses, err := s.store.GetTopMisses(r.Context(), limit)
+	if err != nil {
+		logRequestError(r, "API GetMisses error", err)
+		writeErrorJSON(w, "Failed to retrieve missed paths", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(misses)
+}
+
+// htmxMissesHandler renders the missed paths panel of the portal.
+func (s *Server) htmxMissesHandler(w http.ResponseWriter, r *http.Request) {
+	limit, err := parseMissesLimit(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	misses, err := s.store.GetTopMisses(r.Context(), limit)
+	if err != nil {
+		logRequestError(r, "Error fetching missed paths", err)
+		http.Error(w, "Failed to load missed paths", http.StatusInternalServerError)
+		return
+	}
+
+	data := struct {
+		Misses []RedirectMiss
+	}{
+		Misses: misses,
+	}
+
+	err = s.executeTemplate(w, "missed-paths", data)
+	if err != nil {
+		logRequestError(r, "Template execution error in misses", err)
+		http.Error(w, "Template rendering error", http.StatusInternalServerError)
+		return
+	}
+}
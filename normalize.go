@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// PathRename is a link whose path was, or in a dry run would be, lowercased.
+type PathRename struct {
+	ID   int64  `json:"id"`
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// PathConflict is a mixed-case path whose lowercase form belongs to another
+// link. One of the two links has to be renamed or deleted by hand.
+type PathConflict struct {
+	ID            int64  `json:"id"`
+	Path          string `json:"path"`
+	Normalized    string `json:"normalized"`
+	ConflictsWith int64  `json:"conflicts_with"`
+}
+
+// NormalizePathsReport is the result of normalizing stored paths. With DryRun
+// set nothing was changed.
+type NormalizePathsReport struct {
+	DryRun     bool           `json:"dry_run"`
+	Normalized []PathRename   `json:"normalized"`
+	Conflicts  []PathConflict `json:"conflicts"`
+}
+
+// NormalizePaths lowercases every mixed-case path in one transaction,
+// recording an audit entry per renamed link. A path whose lowercase form is
+// already taken, by a lowercase link, an alias or a mixed-case link with a
+// lower ID, is reported as a conflict and left unchanged. With dryRun set the report is
+// built the same way and the transaction is rolled back.
+func (s *Store) NormalizePaths(ctx context.Context, dryRun bool) (*NormalizePathsReport, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `SELECT id, path FROM links WHERE path != lower(path) ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	var candidates []PathRename
+	for rows.Next() {
+		var rename PathRename
+		if err := rows.Scan(&rename.ID, &rename.From); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		rename.To = strings.ToLower(rename.From)
+		candidates = append(candidates, rename)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	report := &NormalizePathsReport{DryRun: dryRun, Normalized: []PathRename{}, Conflicts: []PathConflict{}}
+	claimed := make(map[string]int64)
+	for _, rename := range candidates {
+		owner, ok := claimed[rename.To]
+		if !ok {
+			query := `SELECT id FROM links WHERE path = ? UNION ALL SELECT link_id FROM link_aliases WHERE alias = ?`
+			err := tx.QueryRowContext(ctx, query, rename.To, rename.To).Scan(&owner)
+			if err != nil && err != sql.ErrNoRows {
+				return nil, err
+			}
+			ok = err == nil
+		}
+		if ok {
+			report.Conflicts = append(report.Conflicts, PathConflict{
+				ID:            rename.ID,
+				Path:          rename.From,
+				Normalized:    rename.To,
+				ConflictsWith: owner,
+			})
+			continue
+		}
+		claimed[rename.To] = rename.ID
+
+		if _, err := tx.ExecContext(ctx, `UPDATE links SET path = ?, updated_at = `+sqliteNowMilli+` WHERE id = ?`, rename.To, rename.ID); err != nil {
+			return nil, err
+		}
+		changes := map[string]FieldChange{"path": {Old: rename.From, New: rename.To}}
+		if err := insertAuditEntry(tx, rename.ID, AuditActionNormalizePath, changes); err != nil {
+			return nil, err
+		}
+		report.Normalized = append(report.Normalized, rename)
+	}
+
+	if dryRun {
+		return report, nil
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// warnMixedCasePaths logs how many links still have mixed-case paths. Such
+// paths are stored from before paths were lowercased and cannot be reached,
+// since lookups are lowercased, until NormalizePaths renames them.
+func warnMixedCasePaths(db *sql.DB) error {
+	var count int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM links WHERE path != lower(path)`).Scan(&count); err != nil {
+		return fmt.Errorf("failed to count mixed-case paths: %w", err)
+	}
+	if count > 0 {
+		slog.Warn("Links with mixed-case paths cannot be reached until they are normalized; see POST /api/maintenance/normalize-paths", "count", count)
+	}
+	return nil
+}
+
+// handleNormalizePaths lowercases the stored mixed-case paths and lists those
+// that collide with another link.
+// NormalizePaths godoc
+// @Summary      Normalize stored paths
+// @Description  Lowercase mixed-case paths and report the ones whose lowercase form is taken; dry_run reports without changing anything (admin only)
+// @Tags         admin
+// @Produce      json
+// @Param        dry_run  query  boolean  false  "Report changes and conflicts without saving"
+// @Success      200  {object}  NormalizePathsReport
+// @Failure      401  {object}  ErrorResponse
+// @Router       /maintenance/normalize-paths [post]
+func (s *Server) handleNormalizePaths(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+	dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dry_run"))
+
+	report, err := s.store.NormalizePaths(r.Context(), dryRun)
+	if err != nil {
+		logRequestError(r, "API NormalizePaths error", err)
+		writeErrorJSON(w, "Failed to normalize paths", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
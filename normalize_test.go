@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// setMixedCasePaths stores paths verbatim, as databases from before paths
+// were lowercased may hold them.
+func setMixedCasePaths(t *testing.T, store *Store, paths map[int64]string) {
+	t.Helper()
+	for id, path := range paths {
+		if _, err := store.db.Exec(`UPDATE links SET path = ? WHERE id = ?`, path, id); err != nil {
+			t.Fatalf("setting path %q: %v", path, err)
+		}
+	}
+}
+
+func TestStartupLeavesMixedCasePaths(t *testing.T) {
+	config := testConfig(t)
+	store, err := NewStore(config)
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	ctx := context.Background()
+	if err := store.CreateLink(ctx, Link{Path: "deploy", URL: "https://deploy.example.com"}); err != nil {
+		t.Fatalf("CreateLink: %v", err)
+	}
+	link, _ := store.GetLinkByPath(ctx, "deploy")
+	setMixedCasePaths(t, store, map[int64]string{link.ID: "Deploy"})
+	store.Close()
+
+	store, err = NewStore(config)
+	if err != nil {
+		t.Fatalf("reopening: %v", err)
+	}
+	defer store.Close()
+	var path string
+	if err := store.db.QueryRow(`SELECT path FROM links WHERE id = ?`, link.ID).Scan(&path); err != nil {
+		t.Fatalf("reading path: %v", err)
+	}
+	if path != "Deploy" {
+		t.Errorf("path = %q after restart, want it left as %q", path, "Deploy")
+	}
+}
+
+func TestNormalizePaths(t *testing.T) {
+	for _, dryRun := range []bool{true, false} {
+		server, handler := newTestServer(t, nil)
+		store := server.store.(*Store)
+		ctx := context.Background()
+
+		deploy := createLink(t, server, handler, Link{Path: "deploy", URL: "https://deploy.example.com"})
+		mixed := createLink(t, server, handler, Link{Path: "deploy-old", URL: "https://old.example.com"})
+		wiki := createLink(t, server, handler, Link{Path: "wiki", URL: "https://wiki.example.com"})
+		wikiTwin := createLink(t, server, handler, Link{Path: "wiki-twin", URL: "https://wiki2.example.com"})
+		docs := createLink(t, server, handler, Link{Path: "docs", URL: "https://docs.example.com"})
+		setMixedCasePaths(t, store, map[int64]string{
+			mixed.ID:    "Deploy",    // collides with the lowercase link
+			wiki.ID:     "Team/Wiki", // renamed
+			wikiTwin.ID: "TEAM/WIKI", // collides with the rename above
+			docs.ID:     "Docs",      // renamed
+		})
+
+		report, err := store.NormalizePaths(ctx, dryRun)
+		if err != nil {
+			t.Fatalf("NormalizePaths(dryRun=%v): %v", dryRun, err)
+		}
+		wantRenamed := []PathRename{{wiki.ID, "Team/Wiki", "team/wiki"}, {docs.ID, "Docs", "docs"}}
+		if !equalRenames(report.Normalized, wantRenamed) {
+			t.Errorf("dryRun=%v: normalized = %+v, want %+v", dryRun, report.Normalized, wantRenamed)
+		}
+		wantConflicts := map[int64]int64{mixed.ID: deploy.ID, wikiTwin.ID: wiki.ID}
+		if len(report.Conflicts) != len(wantConflicts) {
+			t.Errorf("dryRun=%v: conflicts = %+v, want %v", dryRun, report.Conflicts, wantConflicts)
+		}
+		for _, conflict := range report.Conflicts {
+			if wantConflicts[conflict.ID] != conflict.ConflictsWith {
+				t.Errorf("dryRun=%v: conflict %+v, want it to conflict with %d", dryRun, conflict, wantConflicts[conflict.ID])
+			}
+		}
+
+		_, err = store.GetLinkByPath(ctx, "team/wiki")
+		if reachable := err == nil; reachable == dryRun {
+			t.Errorf("dryRun=%v: team/wiki reachable = %v", dryRun, reachable)
+		}
+		entries, _, err := store.GetAudit(ctx, wiki.ID, 10, 0)
+		if err != nil {
+			t.Fatalf("GetAudit: %v", err)
+		}
+		hasRename := len(entries) > 0 && entries[0].Action == AuditActionNormalizePath
+		if hasRename == dryRun {
+			t.Errorf("dryRun=%v: latest audit entry = %+v", dryRun, entries)
+		}
+		var path string
+		store.db.QueryRow(`SELECT path FROM links WHERE id = ?`, mixed.ID).Scan(&path)
+		if path != "Deploy" {
+			t.Errorf("dryRun=%v: conflicting path changed to %q", dryRun, path)
+		}
+	}
+}
+
+func equalRenames(got, want []PathRename) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestNormalizePathsRequiresAdmin(t *testing.T) {
+	tests := []struct {
+		name   string
+		token  string
+		header string
+		status int
+	}{
+		{"admin disabled", "", "", http.StatusForbidden},
+		{"no token", "secret", "", http.StatusUnauthorized},
+		{"wrong token", "secret", "Bearer wrong", http.StatusUnauthorized},
+		{"admin token", "secret", "Bearer secret", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, handler := newTestServer(t, func(c *Config) { c.AdminToken = tt.token })
+			r := httptest.NewRequest(http.MethodPost, "/api/maintenance/normalize-paths?dry_run=true", nil)
+			if tt.header != "" {
+				r.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body.String())
+			}
+			if w.Code == http.StatusOK {
+				var report NormalizePathsReport
+				if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil || !report.DryRun {
+					t.Errorf("report = %s, err = %v", w.Body.String(), err)
+				}
+			}
+		})
+	}
+}
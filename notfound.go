@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// NotFoundPage is the data of the page shown when no link matches a path.
+type NotFoundPage struct {
+	Path        string
+	Suggestions []Link
+	Brand       BrandData
+}
+
+// wantsJSON reports whether the request's Accept header asks for JSON before
+// HTML.
+func wantsJSON(r *http.Request) bool {
+	for _, accepted := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, err := mime.ParseMediaType(accepted)
+		if err != nil {
+			continue
+		}
+		switch mediaType {
+		case "application/json":
+			return true
+		case "text/html":
+			return false
+		}
+	}
+	return false
+}
+
+// renderNotFound answers 404 for a path no link matches, listing links with
+// similar paths. Browsers get a page offering to create the link; clients
+// accepting JSON get a ResolveResult like the resolve endpoint's. Failing to
+// find suggestions only leaves them out.
+func (s *Server) renderNotFound(w http.ResponseWriter, r *http.Request, path string) {
+	suggestions := []Link{}
+	if path != "" && !isReservedPath(strings.SplitN(path, "/", 2)[0]) {
+		var err error
+		suggestions, err = s.store.FindSimilarPaths(r.Context(), path, maxSuggestions)
+		if err != nil {
+			logRequestError(r, "Error finding similar paths", err)
+		}
+	}
+
+	if wantsJSON(r) {
+		result := ResolveResult{Path: path}
+		for _, link := range suggestions {
+			result.Suggestions = append(result.Suggestions, link.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(result)
+		return
+	}
+
+	page := NotFoundPage{Path: path, Suggestions: suggestions, Brand: s.config.Brand}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusNotFound)
+	if err := s.executeTemplate(w, "notfound.html", page); err != nil {
+		slog.Error("Template execution error in not found page", "path", path, "error", err)
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	restfulspec "github.com/emicklei/go-restful-openapi/v2"
+	restful "github.com/emicklei/go-restful/v3"
+	"github.com/go-openapi/spec"
+)
+
+// definitionRefPattern extracts model names from JSON references.
+var definitionRefPattern = regexp.MustCompile(`#/definitions/([^"]+)`)
+
+// newOpenAPIService serves the generated OpenAPI spec at config.APIPath. It
+// behaves like restfulspec.NewOpenAPIService but accepts an optional
+// ?tags=a,b query parameter that narrows the spec to operations with those tags.
+func newOpenAPIService(config restfulspec.Config) *restful.WebService {
+	swagger := restfulspec.BuildSwagger(config)
+
+	ws := new(restful.WebService)
+	ws.Path(config.APIPath)
+	ws.Produces(restful.MIME_JSON)
+	ws.Filter(openAPICORSFilter)
+	ws.Route(ws.GET("/").To(func(req *restful.Request, resp *restful.Response) {
+		tags := parseTagsParam(req.Request.URL.Query()["tags"])
+		if len(tags) == 0 {
+			resp.WriteAsJson(swagger)
+			return
+		}
+		resp.WriteAsJson(filterSwaggerByTags(swagger, tags))
+	}))
+	return ws
+}
+
+// openAPICORSFilter mirrors the CORS handling of the stock OpenAPI service.
+func openAPICORSFilter(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
+	if origin := req.HeaderParameter(restful.HEADER_Origin); origin != "" {
+		if len(resp.Header().Get(restful.HEADER_AccessControlAllowOrigin)) == 0 {
+			resp.AddHeader(restful.HEADER_AccessControlAllowOrigin, origin)
+		}
+	}
+	chain.ProcessFilter(req, resp)
+}
+
+// parseTagsParam collects tags from repeated and comma-separated values.
+func parseTagsParam(values []string) map[string]bool {
+	tags := make(map[string]bool)
+	for _, value := range values {
+		for _, tag := range strings.Split(value, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				tags[tag] = true
+			}
+		}
+	}
+	return tags
+}
+
+// filterSwaggerByTags returns a copy of the spec containing only operations
+// tagged with one of the given tags and the model definitions they reference.
+func filterSwaggerByTags(swagger *spec.Swagger, tags map[string]bool) *spec.Swagger {
+	keep := func(op *spec.Operation) *spec.Operation {
+		if op == nil {
+			return nil
+		}
+		for _, tag := range op.Tags {
+			if tags[tag] {
+				return op
+			}
+		}
+		return nil
+	}
+
+	paths := &spec.Paths{Paths: map[string]spec.PathItem{}}
+	if swagger.Paths != nil {
+		for path, item := range swagger.Paths.Paths {
+			item.Get = keep(item.Get)
+			item.Put = keep(item.Put)
+			item.Post = keep(item.Post)
+			item.Delete = keep(item.Delete)
+			item.Options = keep(item.Options)
+			item.Head = keep(item.Head)
+			item.Patch = keep(item.Patch)
+			if item.Get != nil || item.Put != nil || item.Post != nil || item.Delete != nil ||
+				item.Options != nil || item.Head != nil || item.Patch != nil {
+				paths.Paths[path] = item
+			}
+		}
+	}
+
+	filtered := *swagger
+	filtered.Paths = paths
+	filtered.Definitions = referencedDefinitions(paths, swagger.Definitions)
+
+	var specTags []spec.Tag
+	for _, tag := range swagger.Tags {
+		if tags[tag.Name] {
+			specTags = append(specTags, tag)
+		}
+	}
+	filtered.Tags = specTags
+
+	return &filtered
+}
+
+// referencedDefinitions returns the subset of definitions reachable from the
+// given paths, following references between definitions transitively.
+func referencedDefinitions(paths *spec.Paths, definitions spec.Definitions) spec.Definitions {
+	result := spec.Definitions{}
+	pending := []interface{}{paths}
+	for len(pending) > 0 {
+		data, err := json.Marshal(pending[0])
+		pending = pending[1:]
+		if err != nil {
+			continue
+		}
+		for _, match := range definitionRefPattern.FindAllStringSubmatch(string(data), -1) {
+			name := match[1]
+			if _, seen := result[name]; seen {
+				continue
+			}
+			if def, ok := definitions[name]; ok {
+				result[name] = def
+				pending = append(pending, def)
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestSwaggerRoutes(t *testing.T) {
+	tests := []struct {
+		enabled bool
+		status  int
+	}{
+		{true, http.StatusOK},
+		{false, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		_, handler := newTestServer(t, func(c *Config) { c.SwaggerEnabled = tt.enabled })
+		for _, target := range []string{"/swagger", "/api/swagger/openapi.json"} {
+			w := serve(t, handler, http.MethodGet, target, nil)
+			if w.Code != tt.status {
+				t.Errorf("SwaggerEnabled=%v: GET %s = %d, want %d", tt.enabled, target, w.Code, tt.status)
+			}
+		}
+	}
+}
+
+func TestSwaggerDisabledKeepsAPI(t *testing.T) {
+	_, handler := newTestServer(t, func(c *Config) { c.SwaggerEnabled = false })
+	if w := serve(t, handler, http.MethodGet, "/api/links", nil); w.Code != http.StatusOK {
+		t.Errorf("GET /api/links = %d with swagger disabled, want %d", w.Code, http.StatusOK)
+	}
+}
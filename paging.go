@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// Limits for the number of links returned at once by GET /api/links and
+// shown per portal page.
+const (
+	defaultLinksLimit = 50
+	maxLinksLimit     = 500
+)
+
+// parsePage reads the limit and offset query parameters of a paginated
+// endpoint.
+func parsePage(r *http.Request, defaultLimit, maxLimit int) (int, int, error) {
+	limit := defaultLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > maxLimit {
+			return 0, 0, fmt.Errorf("limit must be a number between 1 and %d", maxLimit)
+		}
+		limit = n
+	}
+	offset := 0
+	if value := r.URL.Query().Get("offset"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return 0, 0, fmt.Errorf("offset must be a non-negative number")
+		}
+		offset = n
+	}
+	return limit, offset, nil
+}
+
+// GetLinksPaged retrieves one page of links in the given order.
+func (s *Store) GetLinksPaged(ctx context.Context, sort LinkSort, limit, offset int) ([]Link, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT "+linkColumns+" FROM links"+sort.orderBy()+" LIMIT ? OFFSET ?", limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	links := []Link{}
+	for rows.Next() {
+		link, err := scanLink(rows)
+		if err != nil {
+			return nil, err
+		}
+		links = append(links, link)
+	}
+	return links, rows.Err()
+}
+
+// LinkPage describes the page of links shown in the portal and their order.
+type LinkPage struct {
+	Offset int
+	Limit  int
+	Total  int
+	Sort   LinkSort
+}
+
+// HasPrev reports whether links precede this page.
+func (p LinkPage) HasPrev() bool { return p.Offset > 0 }
+
+// HasNext reports whether links follow this page.
+func (p LinkPage) HasNext() bool { return p.Offset+p.Limit < p.Total }
+
+// PrevOffset is the offset of the previous page.
+func (p LinkPage) PrevOffset() int {
+	if p.Offset < p.Limit {
+		return 0
+	}
+	return p.Offset - p.Limit
+}
+
+// NextOffset is the offset of the next page.
+func (p LinkPage) NextOffset() int { return p.Offset + p.Limit }
+
+// First is the 1-based position of the first link on the page.
+func (p LinkPage) First() int { return p.Offset + 1 }
+
+// Last is the 1-based position of the last link on the page.
+func (p LinkPage) Last() int {
+	if p.Offset+p.Limit > p.Total {
+		return p.Total
+	}
+	return p.Offset + p.Limit
+}
+
+// portalLinks returns the page of links the portal shows at offset in the
+// given order, limited to full-text matches of search when it is set.
+// Matches sorted by relevance keep the ranking of SearchLinksFTS.
+func (s *Server) portalLinks(ctx context.Context, search string, sort LinkSort, offset int) ([]Link, LinkPage, error) {
+	page := LinkPage{Offset: offset, Limit: defaultLinksLimit, Sort: sort}
+	if search == "" {
+		total, err := s.store.CountLinks(ctx)
+		if err != nil {
+			return nil, page, err
+		}
+		links, err := s.store.GetLinksPaged(ctx, sort, page.Limit, page.Offset)
+		page.Total = int(total)
+		return links, page, err
+	}
+
+	links, err := s.store.SearchLinksFTS(ctx, search)
+	if err != nil {
+		return nil, page, err
+	}
+	if sort.By != sortByRelevance {
+		sortLinks(links, sort)
+	}
+	page.Total = len(links)
+	if offset >= len(links) {
+		return []Link{}, page, nil
+	}
+	return links[offset:page.Last()], page, nil
+}
+
+// portalSort reads the portal's sort and order query parameters. A search
+// without a sort, or sorted by relevance, is ranked best match first.
+func portalSort(r *http.Request) LinkSort {
+	query := r.URL.Query()
+	if sortBy := query.Get("sort"); query.Get("search") != "" && (sortBy == "" || sortBy == sortByRelevance) {
+		return LinkSort{By: sortByRelevance, Order: SortDesc}
+	}
+	return parseSortQuery(r)
+}
+
+// portalOffset reads the portal's offset query parameter, treating a missing
+// or invalid value as the first page.
+func portalOffset(r *http.Request) int {
+	offset, err := strconv.Atoi(r.URL.Query().Get("offset"))
+	if err != nil || offset < 0 {
+		return 0
+	}
+	return offset
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"net/url"
+	"strings"
+)
+
+// GetPrefixLink finds the link for a path without an exact match by
+// stripping trailing segments until a link matches. It returns that link and
+// the stripped suffix when the link is a prefix link, and sql.ErrNoRows when
+// no link matches or the closest match is not a prefix link.
+func (s *Store) GetPrefixLink(ctx context.Context, path string) (*Link, string, error) {
+	segments := strings.Split(path, "/")
+	for i := candidateSegments(segments); i >= 1; i-- {
+		link, err := s.GetLinkByPath(ctx, strings.Join(segments[:i], "/"))
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return nil, "", err
+		}
+		if !link.Prefix {
+			return nil, "", sql.ErrNoRows
+		}
+		return link, strings.Join(segments[i:], "/"), nil
+	}
+	return nil, "", sql.ErrNoRows
+}
+
+// candidateSegments returns how many leading segments of a request path the
+// longest stored path it could match has: at most one fewer than the request,
+// to leave a suffix, and at most maxPathSegments, as no stored path is deeper.
+// Lookups that strip segments start there, so a request path of any depth
+// costs a bounded number of queries.
+func candidateSegments(segments []string) int {
+	return min(len(segments)-1, maxPathSegments)
+}
+
+// appendSuffix appends the escaped segments of suffix to the path of a
+// target URL, before any query or fragment, so "https://jira/browse" with
+// "PROJ-123" becomes "https://jira/browse/PROJ-123".
+func appendSuffix(target, suffix string) string {
+	segments := strings.Split(suffix, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+
+	rest := ""
+	if i := strings.IndexAny(target, "?#"); i != -1 {
+		target, rest = target[:i], target[i:]
+	}
+	return strings.TrimSuffix(target, "/") + "/" + strings.Join(segments, "/") + rest
+}
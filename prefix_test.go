@@ -0,0 +1,102 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestAppendSuffix(t *testing.T) {
+	tests := []struct {
+		target, suffix, want string
+	}{
+		{"https://jira.example.com/browse", "PROJ-123", "https://jira.example.com/browse/PROJ-123"},
+		{"https://jira.example.com/browse/", "PROJ-123", "https://jira.example.com/browse/PROJ-123"},
+		{"https://example.com", "a/b", "https://example.com/a/b"},
+		{"https://example.com/docs?lang=en", "intro", "https://example.com/docs/intro?lang=en"},
+		{"https://example.com/docs#top", "intro", "https://example.com/docs/intro#top"},
+		{"https://example.com/files", "a b/c?d", "https://example.com/files/a%20b/c%3Fd"},
+	}
+	for _, tt := range tests {
+		if got := appendSuffix(tt.target, tt.suffix); got != tt.want {
+			t.Errorf("appendSuffix(%q, %q) = %q, want %q", tt.target, tt.suffix, got, tt.want)
+		}
+	}
+}
+
+func TestPrefixLinkRedirects(t *testing.T) {
+	server, handler := newTestServer(t, nil)
+	createLink(t, server, handler, Link{Path: "jira", URL: "https://jira.example.com/browse", Prefix: true})
+	createLink(t, server, handler, Link{Path: "jira/board", URL: "https://jira.example.com/board"})
+	createLink(t, server, handler, Link{Path: "wiki", URL: "https://wiki.example.com"})
+	createLink(t, server, handler, Link{Path: "docs", URL: "https://docs.example.com", Prefix: true})
+	createLink(t, server, handler, Link{Path: "docs/api", URL: "https://api.example.com"})
+
+	tests := []struct {
+		path     string
+		status   int
+		location string
+	}{
+		{"/jira", http.StatusFound, "https://jira.example.com/browse"},
+		{"/jira/PROJ-123", http.StatusFound, "https://jira.example.com/browse/PROJ-123"},
+		{"/jira/PROJ-123/comments", http.StatusFound, "https://jira.example.com/browse/PROJ-123/comments"},
+		{"/JIRA/Proj-7", http.StatusFound, "https://jira.example.com/browse/Proj-7"},
+		{"/jira/board", http.StatusFound, "https://jira.example.com/board"},
+		{"/jira/PROJ-1?focus=1", http.StatusFound, "https://jira.example.com/browse/PROJ-1?focus=1"},
+		{"/wiki/page", http.StatusNotFound, ""},
+		// The closest parent decides: docs/api is not a prefix link, so the
+		// prefix link docs is not consulted
+		{"/docs/api/v2", http.StatusNotFound, ""},
+		{"/docs/guide", http.StatusFound, "https://docs.example.com/guide"},
+	}
+	for _, tt := range tests {
+		w := serve(t, handler, http.MethodGet, tt.path, nil)
+		if w.Code != tt.status {
+			t.Errorf("GET %s: status = %d, want %d", tt.path, w.Code, tt.status)
+			continue
+		}
+		if got := w.Header().Get("Location"); got != tt.location {
+			t.Errorf("GET %s: Location = %q, want %q", tt.path, got, tt.location)
+		}
+	}
+}
+
+func TestCandidateSegments(t *testing.T) {
+	tests := []struct {
+		path string
+		want int
+	}{
+		{"jira", 0},
+		{"jira/PROJ-1", 1},
+		{"a/b/c/d/e", 4},
+		{"a/b/c/d/e/f", maxPathSegments},
+		{strings.Repeat("a/", 5000) + "a", maxPathSegments},
+	}
+	for _, tt := range tests {
+		if got := candidateSegments(strings.Split(tt.path, "/")); got != tt.want {
+			t.Errorf("candidateSegments(%.20q) = %d, want %d", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestPrefixLinkDeepRequestPath(t *testing.T) {
+	server, handler := newTestServer(t, nil)
+	createLink(t, server, handler, Link{Path: "jira", URL: "https://jira.example.com/browse", Prefix: true})
+	createLink(t, server, handler, Link{Path: "a/b/c/d/e", URL: "https://deep.example.com", Prefix: true})
+
+	tests := []struct {
+		path     string
+		status   int
+		location string
+	}{
+		{"/jira/" + strings.Repeat("x/", 4999) + "x", http.StatusFound, "https://jira.example.com/browse/" + strings.Repeat("x/", 4999) + "x"},
+		{"/a/b/c/d/e/" + strings.Repeat("x/", 4999) + "x", http.StatusFound, "https://deep.example.com/" + strings.Repeat("x/", 4999) + "x"},
+		{"/" + strings.Repeat("a/", 5000) + "a", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		w := serve(t, handler, http.MethodGet, tt.path, nil)
+		if w.Code != tt.status || w.Header().Get("Location") != tt.location {
+			t.Errorf("GET %.30s...: status = %d, Location = %.40q, want %d %.40q", tt.path, w.Code, w.Header().Get("Location"), tt.status, tt.location)
+		}
+	}
+}
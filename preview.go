@@ -0,0 +1,79 @@
+package main
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// previewParam is the query parameter that asks for the preview page
+// (?preview=1) or skips it when PREVIEW_DEFAULT is on (?preview=0). It is
+// never forwarded to the target.
+const previewParam = "preview"
+
+// PreviewPage is the data of the interstitial page shown before a redirect.
+type PreviewPage struct {
+	Title       string
+	Path        string
+	Description string
+	Target      string
+	Brand       BrandData
+}
+
+// wantsPreview reports whether a redirect should show the preview page: when
+// the request's preview parameter is true, or PREVIEW_DEFAULT is on and the
+// parameter is absent or not a boolean.
+func (s *Server) wantsPreview(r *http.Request) bool {
+	value, err := strconv.ParseBool(r.URL.Query().Get(previewParam))
+	if err != nil {
+		return s.config.PreviewDefault
+	}
+	return value
+}
+
+// redirectTarget returns where a request for link is sent: the link's URL
+// with the request's query string appended unless FORWARD_QUERY is off. The
+// preview parameter is dropped from the forwarded query.
+func (s *Server) redirectTarget(r *http.Request, link *Link) string {
+	if !s.config.ForwardQuery {
+		return link.URL
+	}
+	return appendQuery(link.URL, withoutQueryParam(r.URL.RawQuery, previewParam))
+}
+
+// withoutQueryParam removes every occurrence of name from a raw query string,
+// leaving the other parameters exactly as they were sent.
+func withoutQueryParam(rawQuery, name string) string {
+	if rawQuery == "" {
+		return ""
+	}
+	var kept []string
+	for _, pair := range strings.Split(rawQuery, "&") {
+		key, _, _ := strings.Cut(pair, "=")
+		if key != name {
+			kept = append(kept, pair)
+		}
+	}
+	return strings.Join(kept, "&")
+}
+
+// renderPreview serves a page showing where the link goes, with a Continue
+// button to the target, instead of redirecting.
+func (s *Server) renderPreview(w http.ResponseWriter, r *http.Request, link *Link) {
+	page := PreviewPage{
+		Title:       "go/" + link.Path,
+		Path:        link.Path,
+		Description: link.Description,
+		Target:      s.redirectTarget(r, link),
+		Brand:       s.config.Brand,
+	}
+
+	// The target may change at any time, so the page must not be cached
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.executeTemplate(w, "preview.html", page); err != nil {
+		slog.Error("Template execution error in preview", "path", link.Path, "error", err)
+		http.Error(w, "Template rendering error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// linkLimiterMaxEntries bounds the number of links tracked by the limiter.
+const linkLimiterMaxEntries = 10000
+
+// linkRateLimiter enforces per-link requests-per-minute limits using fixed
+// one-minute windows kept in memory.
+type linkRateLimiter struct {
+	mu         sync.Mutex
+	windows    map[int64]*rateWindow
+	maxEntries int
+}
+
+// rateWindow counts requests for a single link within the current minute.
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+// newLinkRateLimiter creates a limiter tracking at most maxEntries links.
+func newLinkRateLimiter(maxEntries int) *linkRateLimiter {
+	return &linkRateLimiter{
+		windows:    make(map[int64]*rateWindow),
+		maxEntries: maxEntries,
+	}
+}
+
+// Allow reports whether another request for the link fits within limit
+// requests per minute, counting the request if it does.
+func (l *linkRateLimiter) Allow(linkID int64, limit int) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	window, ok := l.windows[linkID]
+	if !ok || now.Sub(window.start) >= time.Minute {
+		if !ok && len(l.windows) >= l.maxEntries {
+			l.evict(now)
+		}
+		window = &rateWindow{start: now}
+		l.windows[linkID] = window
+	}
+
+	if window.count >= limit {
+		return false
+	}
+	window.count++
+	return true
+}
+
+// Reset forgets the window for a link, e.g. after its limit was changed.
+func (l *linkRateLimiter) Reset(linkID int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.windows, linkID)
+}
+
+// evict drops expired windows, falling back to the oldest one when every
+// tracked window is still active. Callers must hold l.mu.
+func (l *linkRateLimiter) evict(now time.Time) {
+	var oldestID int64
+	var oldest time.Time
+	for id, window := range l.windows {
+		if now.Sub(window.start) >= time.Minute {
+			delete(l.windows, id)
+			continue
+		}
+		if oldest.IsZero() || window.start.Before(oldest) {
+			oldestID, oldest = id, window.start
+		}
+	}
+	if len(l.windows) >= l.maxEntries {
+		delete(l.windows, oldestID)
+	}
+}
+
+// clientLimiterMaxEntries bounds the number of clients tracked by the
+// creation limiter.
+const clientLimiterMaxEntries = 10000
+
+// clientRateLimiter is a token bucket per client IP. Each bucket holds up to
+// a minute's worth of requests and refills continuously, so a client may
+// burst up to the limit and then continues at the per-minute rate.
+type clientRateLimiter struct {
+	mu         sync.Mutex
+	buckets    map[string]*tokenBucket
+	perMinute  int
+	maxEntries int
+}
+
+// tokenBucket holds the tokens left for one client as of last.
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// newClientRateLimiter creates a limiter allowing perMinute requests per
+// client and tracking at most maxEntries clients.
+func newClientRateLimiter(perMinute, maxEntries int) *clientRateLimiter {
+	return &clientRateLimiter{
+		buckets:    make(map[string]*tokenBucket),
+		perMinute:  perMinute,
+		maxEntries: maxEntries,
+	}
+}
+
+// Allow reports whether the client may make another request, taking a token
+// if it may. Otherwise it returns how long until the next token is available.
+func (l *clientRateLimiter) Allow(client string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	rate := float64(l.perMinute) / time.Minute.Seconds()
+	bucket, ok := l.buckets[client]
+	if !ok {
+		if len(l.buckets) >= l.maxEntries {
+			l.evict(now, rate)
+		}
+		bucket = &tokenBucket{tokens: float64(l.perMinute), last: now}
+		l.buckets[client] = bucket
+	}
+
+	bucket.tokens = min(float64(l.perMinute), bucket.tokens+now.Sub(bucket.last).Seconds()*rate)
+	bucket.last = now
+	if bucket.tokens < 1 {
+		return false, time.Duration((1 - bucket.tokens) / rate * float64(time.Second))
+	}
+	bucket.tokens--
+	return true, 0
+}
+
+// evict drops buckets that have refilled completely, as those clients are
+// indistinguishable from new ones, falling back to the least recently used
+// bucket. Callers must hold l.mu.
+func (l *clientRateLimiter) evict(now time.Time, rate float64) {
+	var oldestClient string
+	var oldest time.Time
+	for client, bucket := range l.buckets {
+		if bucket.tokens+now.Sub(bucket.last).Seconds()*rate >= float64(l.perMinute) {
+			delete(l.buckets, client)
+			continue
+		}
+		if oldest.IsZero() || bucket.last.Before(oldest) {
+			oldestClient, oldest = client, bucket.last
+		}
+	}
+	if len(l.buckets) >= l.maxEntries {
+		delete(l.buckets, oldestClient)
+	}
+}
+
+// clientIP returns the IP address of the client that sent the request.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// allowCreate enforces CREATE_RATE_LIMIT on a link creation request. When
+// the client is over the limit it writes a 429 response with Retry-After and
+// returns false.
+func (s *Server) allowCreate(w http.ResponseWriter, r *http.Request) bool {
+	if s.createLimiter == nil {
+		return true
+	}
+	allowed, retryAfter := s.createLimiter.Allow(clientIP(r))
+	if allowed {
+		return true
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	writeErrorJSON(w, "Too many links created; try again later", http.StatusTooManyRequests)
+	return false
+}
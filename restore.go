@@ -0,0 +1,287 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// linkBackupVersion is the format version of LinkBackup documents.
+const linkBackupVersion = 1
+
+// Modes of POST /api/restore.
+const (
+	RestoreModeReplace = "replace"
+	RestoreModeMerge   = "merge"
+)
+
+// LinkBackup is a JSON document holding every link, for moving links between
+// instances with GET /api/backup and POST /api/restore.
+type LinkBackup struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	Links     []Link    `json:"links"`
+}
+
+// RestoreConflict is a backup record that could not be restored. Index is its
+// position in the links array, starting at 0.
+type RestoreConflict struct {
+	Index int    `json:"index"`
+	Path  string `json:"path"`
+	Error string `json:"error"`
+}
+
+// RestoreReport is the outcome of a restore. Restores are all or nothing:
+// with any conflicts nothing was changed, and the counts say what the
+// restore would have done.
+type RestoreReport struct {
+	Mode      string            `json:"mode"`
+	Created   int               `json:"created"`
+	Updated   int               `json:"updated"`
+	Removed   int               `json:"removed"`
+	Conflicts []RestoreConflict `json:"conflicts"`
+}
+
+// Backup returns every link, ordered by ID, as a backup document.
+func (s *Store) Backup(ctx context.Context) (*LinkBackup, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT "+linkColumns+" FROM links ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	backup := &LinkBackup{Version: linkBackupVersion, CreatedAt: time.Now().UTC(), Links: []Link{}}
+	for rows.Next() {
+		link, err := scanLink(rows)
+		if err != nil {
+			return nil, err
+		}
+		backup.Links = append(backup.Links, link)
+	}
+	return backup, rows.Err()
+}
+
+// RestoreLinks loads links from a backup in one transaction. In replace mode
+// every current link is moved to the trash first and the backup's links are
+// stored under their own IDs with their clicks and timestamps. In merge mode a
+// link whose path exists updates that link and any other link is added with a
+// new ID. Records that fail to store are reported as conflicts, and if there
+// are any the transaction is rolled back.
+func (s *Store) RestoreLinks(ctx context.Context, links []Link, mode string) (*RestoreReport, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	report := &RestoreReport{Mode: mode, Conflicts: []RestoreConflict{}}
+	removed := make(map[int64]bool)
+	if mode == RestoreModeReplace {
+		rows, err := tx.QueryContext(ctx, `SELECT id FROM links`)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var id int64
+			if err := rows.Scan(&id); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			removed[id] = true
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		for id := range removed {
+			if err := deleteLinkTx(tx, id); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for i, link := range links {
+		link.Path = strings.ToLower(link.Path)
+		link.URL = s.normalizeTarget(link.URL)
+		link.Templated = isTemplatedPath(link.Path)
+		if link.CreatedAt.IsZero() {
+			link.CreatedAt = time.Now().UTC()
+		}
+		if err := dropMissingIcon(tx, &link); err != nil {
+			return nil, err
+		}
+
+		if _, err := tx.ExecContext(ctx, `SAVEPOINT restore_row`); err != nil {
+			return nil, err
+		}
+		var existingID int64
+		if mode == RestoreModeMerge {
+			err = tx.QueryRowContext(ctx, `SELECT id FROM links WHERE path = ?`, link.Path).Scan(&existingID)
+			if err != nil && err != sql.ErrNoRows {
+				return nil, err
+			}
+		}
+		switch {
+		case existingID != 0:
+			err = s.updateLinkTx(tx, existingID, link)
+		case mode == RestoreModeMerge:
+			link.ID = 0
+			_, err = insertStoredLink(tx, link)
+		default:
+			_, err = insertStoredLink(tx, link)
+			if err == nil {
+				// The link is live again, so it leaves the trash
+				_, err = tx.ExecContext(ctx, `DELETE FROM deleted_links WHERE link_id = ?`, link.ID)
+				delete(removed, link.ID)
+			}
+		}
+		if err != nil {
+			if _, rollbackErr := tx.ExecContext(ctx, `ROLLBACK TO restore_row`); rollbackErr != nil {
+				return nil, rollbackErr
+			}
+			report.Conflicts = append(report.Conflicts, RestoreConflict{Index: i, Path: link.Path, Error: err.Error()})
+		} else if existingID != 0 {
+			report.Updated++
+		} else {
+			report.Created++
+		}
+		if _, err := tx.ExecContext(ctx, `RELEASE restore_row`); err != nil {
+			return nil, err
+		}
+	}
+	report.Removed = len(removed)
+
+	if len(report.Conflicts) > 0 {
+		return report, nil
+	}
+	return report, tx.Commit()
+}
+
+// dropMissingIcon clears a restored link's reference to an uploaded icon that
+// does not exist in this database, e.g. when the backup comes from another
+// instance. Emoji icons are kept.
+func dropMissingIcon(tx *sql.Tx, link *Link) error {
+	blobID, ok := strings.CutPrefix(link.Icon, iconBlobPrefix)
+	if !ok {
+		return nil
+	}
+	var exists bool
+	err := tx.QueryRow(`SELECT EXISTS(SELECT 1 FROM link_icons WHERE id = ? AND link_id = ?)`, blobID, link.ID).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		link.Icon = ""
+	}
+	return nil
+}
+
+// handleBackup returns every link as a JSON backup document.
+// Backup godoc
+// @Summary      Download a JSON backup
+// @Description  All links with their tags, clicks and timestamps as one JSON document for POST /restore (admin only)
+// @Tags         admin
+// @Produce      json
+// @Success      200  {object}  LinkBackup
+// @Failure      401  {object}  ErrorResponse
+// @Router       /backup [get]
+func (s *Server) handleBackup(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+
+	backup, err := s.store.Backup(r.Context())
+	if err != nil {
+		logRequestError(r, "API Backup error", err)
+		writeErrorJSON(w, "Failed to back up links", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="links-%s.json"`, backup.CreatedAt.Format("20060102-150405")))
+	json.NewEncoder(w).Encode(backup)
+}
+
+// handleRestore loads links from a JSON backup document. Every record is
+// validated first; invalid records answer 422 and conflicting ones 409, and
+// in both cases nothing changes.
+// Restore godoc
+// @Summary      Restore a JSON backup
+// @Description  Load links from a GET /backup document; mode=replace (default) swaps out all links, mode=merge upserts by path (admin only)
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        mode    query  string      false  "replace or merge"
+// @Param        backup  body   LinkBackup  true   "Backup document"
+// @Success      200  {object}  RestoreReport
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      409  {object}  RestoreReport
+// @Failure      422  {object}  RestoreReport
+// @Router       /restore [post]
+func (s *Server) handleRestore(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+
+	mode := r.URL.Query().Get("mode")
+	if mode == "" {
+		mode = RestoreModeReplace
+	}
+	if mode != RestoreModeReplace && mode != RestoreModeMerge {
+		writeErrorJSON(w, "mode must be 'replace' or 'merge'", http.StatusBadRequest)
+		return
+	}
+
+	var backup LinkBackup
+	if err := json.NewDecoder(r.Body).Decode(&backup); err != nil {
+		writeErrorJSON(w, "Invalid request body: expected a backup document from GET /api/backup", http.StatusBadRequest)
+		return
+	}
+	if backup.Version != linkBackupVersion {
+		writeErrorJSON(w, fmt.Sprintf("Unsupported backup version %d", backup.Version), http.StatusBadRequest)
+		return
+	}
+
+	report := &RestoreReport{Mode: mode, Conflicts: []RestoreConflict{}}
+	for i := range backup.Links {
+		link := &backup.Links[i]
+		link.Path = normalizePath(link.Path)
+		link.Group = normalizeGroup(link.Group)
+		link.Tags = normalizeTags(link.Tags)
+		if mode == RestoreModeReplace && link.ID <= 0 {
+			report.Conflicts = append(report.Conflicts, RestoreConflict{Index: i, Path: link.Path, Error: "id is required when replacing"})
+			continue
+		}
+		if err := s.validateLink(r.Context(), *link); err != nil {
+			report.Conflicts = append(report.Conflicts, RestoreConflict{Index: i, Path: link.Path, Error: err.Error()})
+		}
+	}
+	if len(report.Conflicts) > 0 {
+		writeRestoreReport(w, http.StatusUnprocessableEntity, report)
+		return
+	}
+
+	report, err := s.store.RestoreLinks(r.Context(), backup.Links, mode)
+	if err != nil {
+		logRequestError(r, "API Restore error", err)
+		writeErrorJSON(w, "Failed to restore links", http.StatusInternalServerError)
+		return
+	}
+	if len(report.Conflicts) > 0 {
+		writeRestoreReport(w, http.StatusConflict, report)
+		return
+	}
+	writeRestoreReport(w, http.StatusOK, report)
+}
+
+// writeRestoreReport writes the outcome of a restore with the given status.
+func writeRestoreReport(w http.ResponseWriter, status int, report *RestoreReport) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(report)
+}
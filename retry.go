@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+// busyRetryBaseDelay is the wait before the first retry of a busy write; each
+// further retry waits twice as long.
+const busyRetryBaseDelay = 25 * time.Millisecond
+
+// sqliteBusy is SQLite's primary result code for a locked database. Extended
+// codes such as SQLITE_BUSY_SNAPSHOT keep it in their low byte.
+const sqliteBusy = 5
+
+// isBusyError reports whether err means the database was locked by another
+// connection, so the same operation may succeed when tried again. Constraint
+// violations and other errors are never busy errors.
+func isBusyError(err error) bool {
+	var coded interface{ Code() int }
+	return errors.As(err, &coded) && coded.Code()&0xff == sqliteBusy
+}
+
+// withRetry runs fn, running it again up to DB_BUSY_RETRIES times with
+// exponential backoff while it fails because the database is busy. fn must
+// start its own transaction so every attempt begins afresh. Any other error,
+// or the last busy error, is returned unchanged.
+func (s *Store) withRetry(ctx context.Context, fn func() error) error {
+	delay := busyRetryBaseDelay
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || !isBusyError(err) || attempt > s.busyRetries {
+			return err
+		}
+
+		slog.Warn("Database busy, retrying", "attempt", attempt, "delay", delay)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
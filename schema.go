@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+)
+
+// schemaColumns lists the columns the migrations create for each table. Keep
+// it in sync when adding tables or columns.
+var schemaColumns = map[string][]string{
+	"schema_migrations": {"version", "name", "applied_at"},
+	"links":             {"id", "path", "url", "rate_limit", "owner", "created_by", "icon", "updated_at", "created_at", "host", "prefix", "templated", "link_group", "description", "clicks", "last_accessed_at", "expires_at", "enabled"},
+	"deleted_links":     {"link_id", "deleted_at", "path", "data"},
+	"link_audit":        {"id", "link_id", "action", "changes", "created_at"},
+	"link_icons":        {"id", "link_id", "content_type", "data", "created_at"},
+	"link_tags":         {"link_id", "tag"},
+	"redirect_misses":   {"path", "count", "last_seen"},
+	"health_checks":     {"id", "checked_at"},
+	"link_aliases":      {"alias", "link_id", "created_at"},
+	"click_events":      {"id", "link_id", "referrer", "user_agent", "clicked_at"},
+	"link_checks":       {"link_id", "status", "error", "broken", "checked_at"},
+}
+
+// schemaIndex describes a secondary index created by NewStore.
+type schemaIndex struct {
+	Name    string
+	Table   string
+	Columns string
+}
+
+// createSQL returns the statement creating the index if it is missing.
+func (i schemaIndex) createSQL() string {
+	return fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s(%s)", i.Name, i.Table, i.Columns)
+}
+
+// schemaIndexes lists the secondary indexes NewStore creates.
+var schemaIndexes = []schemaIndex{
+	{"idx_links_host", "links", "host"},
+	{"idx_links_link_group", "links", "link_group"},
+	{"idx_deleted_links_deleted_at", "deleted_links", "deleted_at"},
+	{"idx_deleted_links_path", "deleted_links", "path"},
+	{"idx_link_audit_link_id", "link_audit", "link_id"},
+	{"idx_link_icons_link_id", "link_icons", "link_id"},
+	{"idx_link_tags_tag", "link_tags", "tag"},
+	{"idx_link_aliases_link_id", "link_aliases", "link_id"},
+	{"idx_redirect_misses_count", "redirect_misses", "count"},
+	{"idx_click_events_link_id_clicked_at", "click_events", "link_id, clicked_at"},
+}
+
+// SchemaReport lists the differences between the database and the expected
+// schema. Missing tables and columns can only be reported; missing indexes
+// can be repaired.
+type SchemaReport struct {
+	OK              bool     `json:"ok"`
+	MissingTables   []string `json:"missing_tables"`
+	MissingColumns  []string `json:"missing_columns"`
+	MissingIndexes  []string `json:"missing_indexes"`
+	RepairedIndexes []string `json:"repaired_indexes,omitempty"`
+}
+
+// VerifySchema compares the database against the expected tables, columns
+// and indexes without changing anything.
+func (s *Store) VerifySchema(ctx context.Context) (*SchemaReport, error) {
+	report := &SchemaReport{
+		MissingTables:  []string{},
+		MissingColumns: []string{},
+		MissingIndexes: []string{},
+	}
+
+	tables := make([]string, 0, len(schemaColumns))
+	for table := range schemaColumns {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+
+	for _, table := range tables {
+		columns, err := tableColumns(s.db, table)
+		if err != nil {
+			return nil, err
+		}
+		if len(columns) == 0 {
+			report.MissingTables = append(report.MissingTables, table)
+			continue
+		}
+		for _, column := range schemaColumns[table] {
+			if !columns[column] {
+				report.MissingColumns = append(report.MissingColumns, table+"."+column)
+			}
+		}
+	}
+
+	for _, index := range schemaIndexes {
+		var exists bool
+		query := `SELECT EXISTS(SELECT 1 FROM sqlite_master WHERE type = 'index' AND name = ?)`
+		if err := s.db.QueryRowContext(ctx, query, index.Name).Scan(&exists); err != nil {
+			return nil, err
+		}
+		if !exists {
+			report.MissingIndexes = append(report.MissingIndexes, index.Name)
+		}
+	}
+
+	report.OK = len(report.MissingTables) == 0 && len(report.MissingColumns) == 0 && len(report.MissingIndexes) == 0
+	return report, nil
+}
+
+// RepairSchema recreates missing indexes whose table exists and returns the
+// schema report after the repair.
+func (s *Store) RepairSchema(ctx context.Context) (*SchemaReport, error) {
+	before, err := s.VerifySchema(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	missing := make(map[string]bool)
+	for _, name := range before.MissingIndexes {
+		missing[name] = true
+	}
+	missingTables := make(map[string]bool)
+	for _, table := range before.MissingTables {
+		missingTables[table] = true
+	}
+
+	var repaired []string
+	for _, index := range schemaIndexes {
+		if !missing[index.Name] || missingTables[index.Table] {
+			continue
+		}
+		if _, err := s.db.ExecContext(ctx, index.createSQL()); err != nil {
+			slog.Error("Failed to recreate index", "index", index.Name, "error", err)
+			continue
+		}
+		repaired = append(repaired, index.Name)
+	}
+
+	report, err := s.VerifySchema(ctx)
+	if err != nil {
+		return nil, err
+	}
+	report.RepairedIndexes = repaired
+	return report, nil
+}
+
+// handleVerifySchema reports differences from the expected database schema.
+// VerifySchema godoc
+// @Summary      Verify the database schema
+// @Description  Report missing tables, columns and indexes without changing anything (admin only)
+// @Tags         admin
+// @Produce      json
+// @Success      200  {object}  SchemaReport
+// @Failure      401  {object}  ErrorResponse
+// @Router       /maintenance/verify-schema [post]
+func (s *Server) handleVerifySchema(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+
+	report, err := s.store.VerifySchema(r.Context())
+	if err != nil {
+		logRequestError(r, "API VerifySchema error", err)
+		writeErrorJSON(w, "Failed to verify schema", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// handleRepairSchema recreates missing indexes and reports the result.
+// RepairSchema godoc
+// @Summary      Repair the database schema
+// @Description  Recreate missing indexes; missing tables and columns are only reported (admin only)
+// @Tags         admin
+// @Produce      json
+// @Success      200  {object}  SchemaReport
+// @Failure      401  {object}  ErrorResponse
+// @Router       /maintenance/repair-schema [post]
+func (s *Server) handleRepairSchema(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+
+	report, err := s.store.RepairSchema(r.Context())
+	if err != nil {
+		logRequestError(r, "API RepairSchema error", err)
+		writeErrorJSON(w, "Failed to repair schema", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestMain keeps migration and access logs out of the test output.
+func TestMain(m *testing.M) {
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	os.Exit(m.Run())
+}
+
+// testConfig returns the defaults LoadConfig would use, with the database in
+// a temporary directory of t.
+func testConfig(t *testing.T) *Config {
+	t.Helper()
+	return &Config{
+		Port:              "0",
+		DBPath:            filepath.Join(t.TempDir(), "links.db"),
+		DBBusyTimeout:     5 * time.Second,
+		DBBusyRetries:     3,
+		DBJournalMode:     journalModeWAL,
+		DBMaxOpenConns:    4,
+		DBMaxIdleConns:    4,
+		TLSMinVersion:     defaultTLSMinVersion,
+		RedirectStatus:    http.StatusFound,
+		ForwardQuery:      true,
+		SwaggerEnabled:    true,
+		GzipEnabled:       true,
+		GzipMinSize:       1024,
+		Brand:             BrandData{Name: defaultBrandName, Color: defaultBrandColor},
+		UnfurlBots:        defaultUnfurlBots,
+		BackupRetain:      7,
+		CreateHookTimeout: 5 * time.Second,
+		AuditPageSize:     50,
+		AuditMaxPageSize:  500,
+		ShutdownTimeout:   10 * time.Second,
+		LogFormat:         logFormatText,
+		LogLevel:          "info",
+		StateResponses:    defaultStateResponses(),
+	}
+}
+
+// newTestServer serves a fresh database through the same handler main uses.
+// configure, when not nil, adjusts the configuration before anything opens.
+func newTestServer(t *testing.T, configure func(*Config)) (*Server, http.Handler) {
+	t.Helper()
+	config := testConfig(t)
+	if configure != nil {
+		configure(config)
+	}
+	if err := config.Validate(); err != nil {
+		t.Fatalf("invalid test configuration: %v", err)
+	}
+
+	store, err := NewStore(config)
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	server, err := NewServer(store, config)
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+	return server, newHandler(server, config)
+}
+
+// serve sends a request to handler and returns the recorded response; body,
+// when not nil, is encoded as JSON.
+func serve(t *testing.T, handler http.Handler, method, target string, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	var reader *bytes.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Fatalf("encoding request body: %v", err)
+		}
+		reader = bytes.NewReader(data)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+	r := httptest.NewRequest(method, target, reader)
+	if body != nil {
+		r.Header.Set("Content-Type", "application/json")
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
+
+// createLink adds link through the API and returns it as stored.
+func createLink(t *testing.T, server *Server, handler http.Handler, link Link) *Link {
+	t.Helper()
+	w := serve(t, handler, http.MethodPost, "/api/links", link)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("creating %q: status %d: %s", link.Path, w.Code, w.Body.String())
+	}
+	created, err := server.store.GetLinkByPath(context.Background(), normalizePath(link.Path))
+	if err != nil {
+		t.Fatalf("loading created link %q: %v", link.Path, err)
+	}
+	return created
+}
+
+// linkTarget returns the API path of the link with the given ID.
+func linkTarget(id int64) string {
+	return "/api/links/" + strconv.FormatInt(id, 10)
+}
+
+// serveForm sends form as application/x-www-form-urlencoded to handler.
+func serveForm(t *testing.T, handler http.Handler, method, target string, form url.Values) *httptest.ResponseRecorder {
+	t.Helper()
+	r := httptest.NewRequest(method, target, strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// Snapshot is a deterministic summary of the link set for comparing
+// environments. Checksum covers every path and URL, so two snapshots with the
+// same checksum hold identical links.
+type Snapshot struct {
+	Links    map[string]string `json:"links"`
+	Count    int               `json:"count"`
+	Checksum string            `json:"checksum"`
+}
+
+// Snapshot returns the current path to URL mapping with its checksum. The
+// checksum is the SHA-256 of "path\turl\n" lines in path order.
+func (s *Store) Snapshot(ctx context.Context) (*Snapshot, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT path, url FROM links ORDER BY path`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	snapshot := &Snapshot{Links: make(map[string]string)}
+	hash := sha256.New()
+	for rows.Next() {
+		var path, url string
+		if err := rows.Scan(&path, &url); err != nil {
+			return nil, err
+		}
+		snapshot.Links[path] = url
+		hash.Write([]byte(path + "\t" + url + "\n"))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	snapshot.Count = len(snapshot.Links)
+	snapshot.Checksum = "sha256:" + hex.EncodeToString(hash.Sum(nil))
+	return snapshot, nil
+}
+
+// handleGetSnapshot returns a snapshot of the link set. The checksum doubles
+// as the ETag, so a client can check for drift with If-None-Match alone.
+// GetSnapshot godoc
+// @Summary      Link set snapshot
+// @Description  Path to URL map of all links with an overall checksum for comparing environments
+// @Tags         links
+// @Produce      json
+// @Success      200  {object}  Snapshot
+// @Success      304
+// @Router       /snapshot [get]
+func (s *Server) handleGetSnapshot(w http.ResponseWriter, r *http.Request) {
+	snapshot, err := s.store.Snapshot(r.Context())
+	if err != nil {
+		logRequestError(r, "API Snapshot error", err)
+		writeErrorJSON(w, "Failed to create snapshot", http.StatusInternalServerError)
+		return
+	}
+
+	etag := `"` + snapshot.Checksum + `"`
+	w.Header().Set("ETag", etag)
+	if strings.Contains(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(snapshot)
+}
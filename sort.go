@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// Sort orders of link listings.
+const (
+	SortAsc  = "asc"
+	SortDesc = "desc"
+)
+
+// linkSortColumns maps the accepted ?sort= values to the columns they order
+// by. Only these names ever reach the ORDER BY clause.
+var linkSortColumns = map[string]string{
+	"path":    "path",
+	"clicks":  "clicks",
+	"created": "created_at",
+}
+
+// sortByRelevance orders portal search results best match first. Other
+// listings treat it as an unknown sort.
+const sortByRelevance = "relevance"
+
+// LinkSort is the order of a link listing, e.g. by clicks descending.
+type LinkSort struct {
+	By    string
+	Order string
+}
+
+// defaultLinkSort lists links by path, A to Z.
+var defaultLinkSort = LinkSort{By: "path", Order: SortAsc}
+
+// parseLinkSort validates the sort and order query parameters. An unknown
+// sort falls back to the default order; an unknown order sorts ascending.
+func parseLinkSort(sortBy, order string) LinkSort {
+	sortBy = strings.ToLower(strings.TrimSpace(sortBy))
+	if _, ok := linkSortColumns[sortBy]; !ok {
+		return defaultLinkSort
+	}
+	if strings.ToLower(strings.TrimSpace(order)) == SortDesc {
+		return LinkSort{By: sortBy, Order: SortDesc}
+	}
+	return LinkSort{By: sortBy, Order: SortAsc}
+}
+
+// parseSortQuery reads the sort and order query parameters of a request.
+func parseSortQuery(r *http.Request) LinkSort {
+	return parseLinkSort(r.URL.Query().Get("sort"), r.URL.Query().Get("order"))
+}
+
+// Param is the sort query parameter that keeps this order, empty for the
+// default order so that a new search is ranked by relevance.
+func (ls LinkSort) Param() string {
+	if ls == defaultLinkSort {
+		return ""
+	}
+	return ls.By
+}
+
+// OrderParam is the order query parameter that keeps this order, empty for
+// the default order.
+func (ls LinkSort) OrderParam() string {
+	if ls == defaultLinkSort {
+		return ""
+	}
+	return ls.Order
+}
+
+// sortLinks orders links in place like orderBy orders rows, for listings
+// that are not sorted by the database.
+func sortLinks(links []Link, ls LinkSort) {
+	sort.SliceStable(links, func(i, j int) bool {
+		a, b := links[i], links[j]
+		if ls.Order == SortDesc {
+			a, b = b, a
+		}
+		switch {
+		case ls.By == "clicks" && a.Clicks != b.Clicks:
+			return a.Clicks < b.Clicks
+		case ls.By == "created" && !a.CreatedAt.Equal(b.CreatedAt):
+			return a.CreatedAt.Before(b.CreatedAt)
+		case ls.By == "path":
+			return a.Path < b.Path
+		}
+		// Ties are broken by path ascending
+		return links[i].Path < links[j].Path
+	})
+}
+
+// orderBy returns the ORDER BY clause for the sort. Ties are broken by path
+// so pages stay stable.
+func (ls LinkSort) orderBy() string {
+	column, ok := linkSortColumns[ls.By]
+	if !ok {
+		column = "path"
+	}
+	direction := "ASC"
+	if ls.Order == SortDesc {
+		direction = "DESC"
+	}
+	if column == "path" {
+		return " ORDER BY path " + direction
+	}
+	return " ORDER BY " + column + " " + direction + ", path ASC"
+}
+
+// Toggle is the order a table header for column links to: the reverse of
+// the current order when the listing is already sorted by column, otherwise
+// ascending for paths and descending for clicks and creation dates.
+func (ls LinkSort) Toggle(column string) string {
+	if ls.By == column {
+		if ls.Order == SortAsc {
+			return SortDesc
+		}
+		return SortAsc
+	}
+	if column == "path" {
+		return SortAsc
+	}
+	return SortDesc
+}
+
+// Arrow marks the table header of the column the listing is sorted by.
+func (ls LinkSort) Arrow(column string) string {
+	switch {
+	case ls.By != column:
+		return ""
+	case ls.Order == SortDesc:
+		return "▼"
+	default:
+		return "▲"
+	}
+}
+
+// GetAllLinksSorted retrieves all links ordered by sortBy ("path", "clicks"
+// or "created") and order ("asc" or "desc"), falling back to path ascending
+// for unknown values.
+func (s *Store) GetAllLinksSorted(ctx context.Context, sortBy, order string) ([]Link, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT "+linkColumns+" FROM links"+parseLinkSort(sortBy, order).orderBy())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	links := []Link{}
+	for rows.Next() {
+		link, err := scanLink(rows)
+		if err != nil {
+			return nil, err
+		}
+		links = append(links, link)
+	}
+	return links, rows.Err()
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// privateTargetLookupTimeout bounds the DNS lookup of a link's target host,
+// within whatever is left of the request's own deadline.
+const privateTargetLookupTimeout = 2 * time.Second
+
+// isPrivateAddress reports whether ip is a loopback, link-local, private
+// (RFC 1918 or IPv6 unique local) or unspecified address, which a server in
+// a cloud network must not be pointed at.
+func isPrivateAddress(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsPrivate() || ip.IsUnspecified()
+}
+
+// privateAllowed reports whether PRIVATE_TARGET_ALLOWLIST exempts host, by
+// name or, when ip is set, by one of its CIDR ranges.
+func (s *Server) privateAllowed(host string, ip net.IP) bool {
+	for _, entry := range s.config.PrivateAllowlist {
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			if ip != nil && network.Contains(ip) {
+				return true
+			}
+		} else if strings.EqualFold(entry, host) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkPrivateTarget enforces BLOCK_PRIVATE_TARGETS: it resolves the host of
+// target and fails when any of its addresses is private and not allowlisted.
+// Hosts that do not resolve are rejected too, as are templated hosts, whose
+// address is only known at redirect time. The lookup is abandoned when ctx
+// is done, e.g. because the client went away.
+func (s *Server) checkPrivateTarget(ctx context.Context, target string) error {
+	if !s.config.BlockPrivateTargets {
+		return nil
+	}
+	u, err := url.Parse(target)
+	if err != nil {
+		return fmt.Errorf("invalid url")
+	}
+	host := u.Hostname()
+	if s.privateAllowed(host, nil) {
+		return nil
+	}
+	if strings.Contains(host, templateToken) {
+		return fmt.Errorf("url host cannot contain %s while private targets are blocked", templateToken)
+	}
+
+	if ip := net.ParseIP(host); ip != nil {
+		if isPrivateAddress(ip) && !s.privateAllowed(host, ip) {
+			return fmt.Errorf("url host %s is a private address", host)
+		}
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, privateTargetLookupTimeout)
+	defer cancel()
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return fmt.Errorf("url host '%s' could not be resolved", host)
+	}
+	for _, addr := range addrs {
+		if isPrivateAddress(addr.IP) && !s.privateAllowed(host, addr.IP) {
+			return fmt.Errorf("url host '%s' resolves to private address %s", host, addr.IP)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestCheckPrivateTarget(t *testing.T) {
+	tests := []struct {
+		name    string
+		block   bool
+		target  string
+		wantErr bool
+	}{
+		{"blocking off", false, "http://127.0.0.1/admin", false},
+		{"loopback", true, "http://127.0.0.1/admin", true},
+		{"metadata service", true, "http://169.254.169.254/latest", true},
+		{"private range", true, "http://10.1.2.3", true},
+		{"IPv6 loopback", true, "http://[::1]:8080", true},
+		{"public address", true, "https://93.184.216.34", false},
+		{"allowlisted range", true, "http://192.168.1.10", false},
+		{"allowlisted host", true, "http://intranet.corp/wiki", false},
+		{"templated host", true, "https://{*}.example.com", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, _ := newTestServer(t, func(c *Config) {
+				c.BlockPrivateTargets = tt.block
+				c.PrivateAllowlist = []string{"192.168.1.0/24", "intranet.corp"}
+			})
+			err := server.checkPrivateTarget(context.Background(), tt.target)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkPrivateTarget(%q) = %v, want error %v", tt.target, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckPrivateTargetHonorsContext(t *testing.T) {
+	server, _ := newTestServer(t, func(c *Config) { c.BlockPrivateTargets = true })
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	start := time.Now()
+	if err := server.checkPrivateTarget(ctx, "https://links.example.com"); err == nil {
+		t.Errorf("lookup with a cancelled context succeeded")
+	}
+	if elapsed := time.Since(start); elapsed > privateTargetLookupTimeout/2 {
+		t.Errorf("lookup took %v after the context was cancelled", elapsed)
+	}
+}
+
+func TestCreateLinkBlocksPrivateTargets(t *testing.T) {
+	_, handler := newTestServer(t, func(c *Config) { c.BlockPrivateTargets = true })
+	w := serve(t, handler, http.MethodPost, "/api/links", Link{Path: "admin", URL: "http://127.0.0.1:8080/admin"})
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("status = %d, want %d: %s", w.Code, http.StatusUnprocessableEntity, w.Body.String())
+	}
+}
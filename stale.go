@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Limits for the number of stale links returned at once.
+const (
+	defaultStaleLimit = 20
+	maxStaleLimit     = 100
+)
+
+// StaleLink is a link ranked for cleanup. Score is IdleDays divided by
+// (1 + VisitsPer30Days): links that have not been used for long and were
+// rarely used over their lifetime rank highest.
+type StaleLink struct {
+	Link          Link       `json:"link"`
+	Score         float64    `json:"score"`
+	AgeDays       float64    `json:"age_days"`
+	Visits        int64      `json:"visits"`
+	LastVisitedAt *time.Time `json:"last_visited_at"`
+	// IdleDays counts from the last visit, or from creation if never visited.
+	IdleDays        float64 `json:"idle_days"`
+	VisitsPer30Days float64 `json:"visits_per_30_days"`
+}
+
+// GetStaleRanked ranks all links by staleness score, highest first, and
+// returns the requested page together with the total number of links.
+// Visits and the last visit are the link's click count and last access.
+func (s *Store) GetStaleRanked(ctx context.Context, now time.Time, limit, offset int) ([]StaleLink, int, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT "+linkColumns+" FROM links")
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	ranked := []StaleLink{}
+	for rows.Next() {
+		link, err := scanLink(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		ranked = append(ranked, staleScore(link, link.Clicks, link.LastAccessedAt, now))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].Score != ranked[j].Score {
+			return ranked[i].Score > ranked[j].Score
+		}
+		return ranked[i].Link.Path < ranked[j].Link.Path
+	})
+
+	total := len(ranked)
+	if offset >= total {
+		return []StaleLink{}, total, nil
+	}
+	end := offset + limit
+	if end > total {
+		end = total
+	}
+	return ranked[offset:end], total, nil
+}
+
+// staleScore computes the staleness factors of a link at the given time.
+func staleScore(link Link, visits int64, lastVisitedAt *time.Time, now time.Time) StaleLink {
+	days := func(since time.Time) float64 {
+		return math.Max(now.Sub(since).Hours()/24, 0)
+	}
+
+	ageDays := days(link.CreatedAt)
+	idleDays := ageDays
+	if lastVisitedAt != nil {
+		idleDays = days(*lastVisitedAt)
+	}
+	visitsPer30Days := float64(visits) / math.Max(ageDays, 1) * 30
+
+	round := func(value float64) float64 {
+		return math.Round(value*100) / 100
+	}
+	return StaleLink{
+		Link:            link,
+		Score:           round(idleDays / (1 + visitsPer30Days)),
+		AgeDays:         round(ageDays),
+		Visits:          visits,
+		LastVisitedAt:   lastVisitedAt,
+		IdleDays:        round(idleDays),
+		VisitsPer30Days: round(visitsPer30Days),
+	}
+}
+
+// handleGetStaleLinks returns links ranked by staleness score for cleanup.
+// GetStaleLinks godoc
+// @Summary      Links ranked by staleness
+// @Description  Links ranked by idle time and visit rate, stalest first; the total is returned in X-Total-Count
+// @Tags         stats
+// @Produce      json
+// @Param        limit   query  int  false  "Page size (default 20, max 100)"
+// @Param        offset  query  int  false  "Number of links to skip"
+// @Success      200  {array}   StaleLink
+// @Failure      400  {object}  ErrorResponse
+// @Router       /links/stale [get]
+func (s *Server) handleGetStaleLinks(w http.ResponseWriter, r *http.Request) {
+	limit, offset, err := parsePage(r, defaultStaleLimit, maxStaleLimit)
+	if err != nil {
+		writeErrorJSON(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ranked, total, err := s.store.GetStaleRanked(r.Context(), time.Now().UTC(), limit, offset)
+	if err != nil {
+		logRequestError(r, "API GetStaleLinks error", err)
+		writeErrorJSON(w, "Failed to rank stale links", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+	json.NewEncoder(w).Encode(ranked)
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// LinkState describes why a requested path cannot be redirected normally.
+type LinkState string
+
+// Link states that have a configurable response.
+const (
+	LinkStateExpired  LinkState = "expired"
+	LinkStateDeleted  LinkState = "deleted"
+	LinkStateDisabled LinkState = "disabled"
+)
+
+// linkStates lists every configurable state in a stable order.
+var linkStates = []LinkState{LinkStateExpired, LinkStateDeleted, LinkStateDisabled}
+
+// StateResponse configures how a link state is answered: either a plain
+// status code or a redirect to a fallback URL.
+type StateResponse struct {
+	Status      int    `json:"status"`
+	RedirectURL string `json:"redirect_url,omitempty"`
+}
+
+// defaultStateResponses returns the built-in response for every link state.
+func defaultStateResponses() map[LinkState]StateResponse {
+	return map[LinkState]StateResponse{
+		LinkStateExpired:  {Status: http.StatusGone},
+		LinkStateDeleted:  {Status: http.StatusGone},
+		LinkStateDisabled: {Status: http.StatusNotFound},
+	}
+}
+
+// loadStateResponses applies LINK_STATE_<STATE>_STATUS and
+// LINK_STATE_<STATE>_URL environment overrides on top of the defaults.
+func loadStateResponses() (map[LinkState]StateResponse, error) {
+	responses := defaultStateResponses()
+	for _, state := range linkStates {
+		prefix := "LINK_STATE_" + strings.ToUpper(string(state))
+		response := responses[state]
+
+		if redirectURL := os.Getenv(prefix + "_URL"); redirectURL != "" {
+			response.RedirectURL = redirectURL
+			response.Status = http.StatusFound
+		}
+		if status := os.Getenv(prefix + "_STATUS"); status != "" {
+			code, err := strconv.Atoi(status)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s_STATUS '%s': must be a number", prefix, status)
+			}
+			response.Status = code
+		}
+
+		if err := response.validate(); err != nil {
+			return nil, fmt.Errorf("invalid response for %s links: %w", state, err)
+		}
+		responses[state] = response
+	}
+	return responses, nil
+}
+
+// validate checks that the status code fits the kind of response.
+func (sr StateResponse) validate() error {
+	if sr.RedirectURL != "" {
+		if sr.Status < 300 || sr.Status > 399 {
+			return fmt.Errorf("status %d must be a 3xx code when a redirect URL is set", sr.Status)
+		}
+		if u, err := url.Parse(sr.RedirectURL); err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("redirect URL '%s' must be absolute", sr.RedirectURL)
+		}
+		return nil
+	}
+	if sr.Status < 400 || sr.Status > 599 {
+		return fmt.Errorf("status %d must be a 4xx or 5xx code without a redirect URL", sr.Status)
+	}
+	return nil
+}
+
+// respondToState answers a request for a path in a non-redirectable state.
+// A "{path}" placeholder in the fallback URL is replaced with the escaped path.
+func (s *Server) respondToState(w http.ResponseWriter, r *http.Request, state LinkState, path string) {
+	response, ok := s.config.StateResponses[state]
+	if !ok {
+		response = defaultStateResponses()[state]
+	}
+
+	if response.RedirectURL != "" {
+		target := strings.ReplaceAll(response.RedirectURL, "{path}", url.QueryEscape(path))
+		s.applyRedirectHeaders(w)
+		http.Redirect(w, r, target, response.Status)
+		return
+	}
+	http.Error(w, fmt.Sprintf("Link /%s is %s", path, state), response.Status)
+}
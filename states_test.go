@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRedirectStateResponses(t *testing.T) {
+	tests := []struct {
+		name     string
+		state    LinkState
+		response StateResponse
+		setup    func(t *testing.T, server *Server, handler http.Handler, link *Link)
+		status   int
+		location string
+	}{
+		{
+			name:   "expired default",
+			state:  LinkStateExpired,
+			setup:  expireLink,
+			status: http.StatusGone,
+		},
+		{
+			name:     "expired to fallback",
+			state:    LinkStateExpired,
+			response: StateResponse{Status: http.StatusFound, RedirectURL: "https://renew.example.com/?path={path}"},
+			setup:    expireLink,
+			status:   http.StatusFound,
+			location: "https://renew.example.com/?path=team%2Fdocs",
+		},
+		{
+			name:   "deleted default",
+			state:  LinkStateDeleted,
+			setup:  deleteLink,
+			status: http.StatusGone,
+		},
+		{
+			name:     "deleted custom status",
+			state:    LinkStateDeleted,
+			response: StateResponse{Status: http.StatusNotFound},
+			setup:    deleteLink,
+			status:   http.StatusNotFound,
+		},
+		{
+			name:   "disabled default",
+			state:  LinkStateDisabled,
+			setup:  toggleLink,
+			status: http.StatusNotFound,
+		},
+		{
+			name:     "disabled to fallback",
+			state:    LinkStateDisabled,
+			response: StateResponse{Status: http.StatusTemporaryRedirect, RedirectURL: "https://example.com/disabled"},
+			setup:    toggleLink,
+			status:   http.StatusTemporaryRedirect,
+			location: "https://example.com/disabled",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, handler := newTestServer(t, func(c *Config) {
+				if tt.response.Status != 0 {
+					c.StateResponses[tt.state] = tt.response
+				}
+			})
+			link := createLink(t, server, handler, Link{Path: "team/docs", URL: "https://docs.example.com"})
+			tt.setup(t, server, handler, link)
+
+			w := serve(t, handler, http.MethodGet, "/team/docs", nil)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body.String())
+			}
+			if got := w.Header().Get("Location"); got != tt.location {
+				t.Errorf("Location = %q, want %q", got, tt.location)
+			}
+		})
+	}
+}
+
+// expireLink moves the expiration of link into the past.
+func expireLink(t *testing.T, server *Server, handler http.Handler, link *Link) {
+	t.Helper()
+	past := time.Now().Add(-time.Hour)
+	link.ExpiresAt = &past
+	if err := server.store.UpdateLink(context.Background(), link.ID, *link); err != nil {
+		t.Fatalf("UpdateLink: %v", err)
+	}
+}
+
+// deleteLink deletes link through the API.
+func deleteLink(t *testing.T, server *Server, handler http.Handler, link *Link) {
+	t.Helper()
+	if w := serve(t, handler, http.MethodDelete, linkTarget(link.ID), nil); w.Code != http.StatusNoContent {
+		t.Fatalf("delete: status = %d: %s", w.Code, w.Body.String())
+	}
+}
+
+// toggleLink disables link through the API.
+func toggleLink(t *testing.T, server *Server, handler http.Handler, link *Link) {
+	t.Helper()
+	if w := serve(t, handler, http.MethodPost, linkTarget(link.ID)+"/toggle", nil); w.Code != http.StatusOK {
+		t.Fatalf("toggle: status = %d: %s", w.Code, w.Body.String())
+	}
+}
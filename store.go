@@ -1,9 +1,14 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
 	"strings"
+	"time"
 
 	_ "modernc.org/sqlite"
 )
@@ -11,53 +16,303 @@ import (
 // Store manages the database operations for links.
 type Store struct {
 	db *sql.DB
+
+	// canonicalizeTargets normalizes target URLs before they are stored.
+	canonicalizeTargets bool
+	// lowercaseTargetHosts lowercases the scheme and host of target URLs.
+	lowercaseTargetHosts bool
+
+	// defaultTags are applied to new links created without tags.
+	defaultTags []string
+	// defaultGroup is applied to new links created without a group.
+	defaultGroup string
+
+	// busyRetries is how often CreateLink, UpdateLink and DeleteLink are
+	// retried while the database is busy.
+	busyRetries int
+
+	// ftsEnabled reports whether the links_fts full-text index exists.
+	ftsEnabled bool
 }
 
 // Link represents a shortened URL link.
 type Link struct {
-	ID   int64  `json:"id"`
-	Path string `json:"path"`
-	URL  string `json:"url"`
+	ID             int64      `json:"id"`
+	Path           string     `json:"path"`
+	URL            string     `json:"url"`
+	RateLimit      int        `json:"rate_limit,omitempty"` // Requests per minute, 0 = unlimited
+	Owner          string     `json:"owner,omitempty"`
+	CreatedBy      string     `json:"created_by,omitempty"`  // Who created the link, from X-Created-By; never changes
+	Group          string     `json:"group,omitempty"`       // Empty when ungrouped
+	Description    string     `json:"description,omitempty"` // What the link is for, at most 500 characters
+	Icon           string     `json:"icon,omitempty"`        // Emoji, or "blob:<id>" for an uploaded image
+	Tags           []string   `json:"tags,omitempty"`
+	Aliases        []string   `json:"aliases,omitempty"`    // Other paths redirecting to URL, managed via /links/{id}/aliases
+	Prefix         bool       `json:"prefix,omitempty"`     // Forward extra path segments to the target
+	Templated      bool       `json:"templated,omitempty"`  // Path ends in {*}, substituted into the target
+	Enabled        bool       `json:"enabled"`              // Disabled links answer like LINK_STATE_DISABLED_* configures; changed via /links/{id}/toggle
+	Clicks         int64      `json:"clicks"`               // Redirects served
+	LastAccessedAt *time.Time `json:"last_accessed_at"`     // Last redirect, nil if never
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"` // Then answers like LINK_STATE_EXPIRED_* configures until EXPIRY_PURGE_INTERVAL trashes it
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// linkColumns lists the links columns read by scanLink, in order.
+const linkColumns = "id, path, url, rate_limit, owner, created_by, link_group, description, icon, prefix, templated, enabled, clicks, last_accessed_at, expires_at, created_at, updated_at, " + linkTagsColumn + ", " + linkAliasesColumn
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// prefixScanner reads extra leading columns into prefix before the columns
+// of the wrapped scanner's destination, e.g. "SELECT host, " + linkColumns.
+type prefixScanner struct {
+	rows   *sql.Rows
+	prefix []interface{}
+}
+
+// Scan reads the prefix columns followed by dest.
+func (p prefixScanner) Scan(dest ...interface{}) error {
+	return p.rows.Scan(append(append([]interface{}{}, p.prefix...), dest...)...)
+}
+
+// scanLink reads a link selected with linkColumns.
+func scanLink(row rowScanner) (Link, error) {
+	var link Link
+	var tags, aliases sql.NullString
+	var lastAccessedAt, expiresAt sql.NullTime
+	err := row.Scan(&link.ID, &link.Path, &link.URL, &link.RateLimit, &link.Owner, &link.CreatedBy, &link.Group, &link.Description, &link.Icon, &link.Prefix, &link.Templated, &link.Enabled, &link.Clicks, &lastAccessedAt, &expiresAt, &link.CreatedAt, &link.UpdatedAt, &tags, &aliases)
+	link.Tags = parseTags(tags)
+	link.Aliases = parseAliases(aliases)
+	if lastAccessedAt.Valid {
+		link.LastAccessedAt = &lastAccessedAt.Time
+	}
+	if expiresAt.Valid {
+		link.ExpiresAt = &expiresAt.Time
+	}
+	return link, err
+}
+
+// LinkVisitCount pairs a link with the number of redirects it has served.
+type LinkVisitCount struct {
+	ID     int64  `json:"id"`
+	Path   string `json:"path"`
+	URL    string `json:"url"`
+	Visits int64  `json:"visits"`
+}
+
+// sqliteTimeFormat matches the format produced by SQLite's CURRENT_TIMESTAMP.
+const sqliteTimeFormat = "2006-01-02 15:04:05"
+
+// sqliteMilliTimeFormat matches the millisecond timestamps written by sqliteNowMilli.
+const sqliteMilliTimeFormat = "2006-01-02 15:04:05.000"
+
+// sqliteTime converts an optional time to the value stored in a nullable
+// DATETIME column.
+func sqliteTime(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return t.UTC().Format(sqliteMilliTimeFormat)
+}
+
+// sqliteNowMilli is an SQL expression for the current UTC time with milliseconds.
+const sqliteNowMilli = "strftime('%Y-%m-%d %H:%M:%f', 'now')"
+
+// SQLite journal modes accepted by DB_JOURNAL_MODE.
+const (
+	journalModeWAL    = "wal"
+	journalModeDelete = "delete"
+)
+
+// sqliteDSN returns the data source name opening path with the given busy
+// timeout on every pooled connection. Redirects write from several goroutines
+// at once, e.g. the click event worker and access time updates, so writes
+// must wait for each other's locks instead of failing with SQLITE_BUSY.
+// Transactions take the write lock when they begin: one that read first
+// could not upgrade its lock while another connection writes, and would fail
+// without waiting.
+func sqliteDSN(path string, busyTimeout time.Duration) string {
+	separator := "?"
+	if strings.Contains(path, "?") {
+		separator = "&"
+	}
+	return fmt.Sprintf("%s%s_pragma=busy_timeout(%d)&_txlock=immediate", path, separator, busyTimeout.Milliseconds())
+}
+
+// setJournalMode switches the database to the given journal mode. WAL lets
+// redirects read while another connection writes; the mode is stored in the
+// database file, so it only has to be set once.
+func setJournalMode(db *sql.DB, mode string) error {
+	var actual string
+	if err := db.QueryRow("PRAGMA journal_mode = " + mode).Scan(&actual); err != nil {
+		return fmt.Errorf("failed to set journal mode: %w", err)
+	}
+	// In-memory databases cannot use WAL and keep their own mode
+	if actual != mode {
+		slog.Warn("Database journal mode unchanged", "requested", mode, "actual", actual)
+	}
+	return nil
 }
 
 // NewStore creates a new Store and initializes the database.
-func NewStore(dbPath string) (*Store, error) {
-	db, err := sql.Open("sqlite", dbPath)
+func NewStore(config *Config) (*Store, error) {
+	db, err := sql.Open("sqlite", sqliteDSN(config.DBPath, config.DBBusyTimeout))
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
+	db.SetMaxOpenConns(config.DBMaxOpenConns)
+	db.SetMaxIdleConns(config.DBMaxIdleConns)
 
-	// Create the links table if it doesn't already exist.
-	createTableSQL := `CREATE TABLE IF NOT EXISTS links (
-		"id" INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
-		"path" TEXT NOT NULL UNIQUE,
-		"url" TEXT NOT NULL
-	);`
-	if _, err := db.Exec(createTableSQL); err != nil {
-		return nil, fmt.Errorf("failed to create table: %w", err)
+	if err := setJournalMode(db, config.DBJournalMode); err != nil {
+		return nil, err
 	}
 
-	return &Store{db: db}, nil
+	// Bring the schema up to date.
+	if err := migrate(db); err != nil {
+		return nil, err
+	}
+	if err := warnMixedCasePaths(db); err != nil {
+		return nil, err
+	}
+
+	// Create the indexes listed in schemaIndexes.
+	for _, index := range schemaIndexes {
+		if _, err := db.Exec(index.createSQL()); err != nil {
+			return nil, fmt.Errorf("failed to create index %s: %w", index.Name, err)
+		}
+	}
+
+	ftsEnabled, err := setupFTS(db)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Store{
+		db:                   db,
+		canonicalizeTargets:  config.CanonicalizeTargets,
+		lowercaseTargetHosts: config.LowercaseTargetHosts,
+		defaultTags:          config.DefaultTags,
+		defaultGroup:         config.DefaultGroup,
+		busyRetries:          config.DBBusyRetries,
+		ftsEnabled:           ftsEnabled,
+	}, nil
+}
+
+// addColumnIfMissing adds a column to an existing table unless it is already present.
+func addColumnIfMissing(db schemaExecer, table, column, definition string) error {
+	columns, err := tableColumns(db, table)
+	if err != nil {
+		return err
+	}
+	if columns[column] {
+		return nil
+	}
+
+	alterSQL := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition)
+	if _, err := db.Exec(alterSQL); err != nil {
+		return fmt.Errorf("failed to add column %s.%s: %w", table, column, err)
+	}
+	return nil
+}
+
+// tableColumns returns the set of column names of a table; it is empty when
+// the table does not exist.
+func tableColumns(db schemaExecer, table string) (map[string]bool, error) {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect table %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	columns := make(map[string]bool)
+	for rows.Next() {
+		var (
+			cid       int
+			name      string
+			colType   string
+			notNull   int
+			dfltValue sql.NullString
+			pk        int
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dfltValue, &pk); err != nil {
+			return nil, fmt.Errorf("failed to inspect table %s: %w", table, err)
+		}
+		columns[name] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to inspect table %s: %w", table, err)
+	}
+	return columns, nil
+}
+
+// isUniqueViolation reports whether err is a unique constraint violation on
+// the given column of the links table. Besides SQLite's message it recognizes
+// drivers that expose the SQLSTATE, where 23505 is unique_violation and the
+// message names the constraint (links_pkey or links_<column>_key).
+func isUniqueViolation(err error, column string) bool {
+	if strings.Contains(err.Error(), "UNIQUE constraint failed: links."+column) {
+		return true
+	}
+	var state interface{ SQLState() string }
+	if !errors.As(err, &state) || state.SQLState() != "23505" {
+		return false
+	}
+	constraint := "links_" + column + "_key"
+	if column == "id" {
+		constraint = "links_pkey"
+	}
+	return strings.Contains(err.Error(), `"`+constraint+`"`)
 }
 
 // Close closes the database connection.
-func (s *Store) Close() {
-	s.db.Close()
+func (s *Store) Close() error {
+	return s.db.Close()
 }
 
 // GetLinkByPath retrieves a single link by its path.
-func (s *Store) GetLinkByPath(path string) (*Link, error) {
-	link := &Link{}
-	err := s.db.QueryRow("SELECT id, path, url FROM links WHERE path = ?", path).Scan(&link.ID, &link.Path, &link.URL)
+func (s *Store) GetLinkByPath(ctx context.Context, path string) (*Link, error) {
+	link, err := scanLink(s.db.QueryRowContext(ctx, "SELECT "+linkColumns+" FROM links WHERE path = ?", strings.ToLower(path)))
 	if err != nil {
 		return nil, err
 	}
-	return link, nil
+	return &link, nil
+}
+
+// GetLinkByID retrieves a single link by its ID.
+func (s *Store) GetLinkByID(ctx context.Context, id int64) (*Link, error) {
+	link, err := scanLink(s.db.QueryRowContext(ctx, "SELECT "+linkColumns+" FROM links WHERE id = ?", id))
+	if err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+// FindLinksByURL returns the links whose target is url after the configured
+// target normalization, ordered by path.
+func (s *Store) FindLinksByURL(ctx context.Context, url string) ([]Link, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT "+linkColumns+" FROM links WHERE url = ? ORDER BY path", s.normalizeTarget(url))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	links := []Link{}
+	for rows.Next() {
+		link, err := scanLink(rows)
+		if err != nil {
+			return nil, err
+		}
+		links = append(links, link)
+	}
+	return links, rows.Err()
 }
 
 // GetAllLinks retrieves all links from the database.
-func (s *Store) GetAllLinks() ([]Link, error) {
-	rows, err := s.db.Query("SELECT id, path, url FROM links ORDER BY path")
+func (s *Store) GetAllLinks(ctx context.Context) ([]Link, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT "+linkColumns+" FROM links ORDER BY path")
 	if err != nil {
 		return nil, err
 	}
@@ -65,8 +320,8 @@ func (s *Store) GetAllLinks() ([]Link, error) {
 
 	var links []Link
 	for rows.Next() {
-		var link Link
-		if err := rows.Scan(&link.ID, &link.Path, &link.URL); err != nil {
+		link, err := scanLink(rows)
+		if err != nil {
 			return nil, err
 		}
 		links = append(links, link)
@@ -74,56 +329,478 @@ func (s *Store) GetAllLinks() ([]Link, error) {
 	return links, nil
 }
 
-// CreateLink adds a new link to the database.
-func (s *Store) CreateLink(path, url string) error {
-	insertSQL := `INSERT INTO links(path, url) VALUES(?, ?)`
-	_, err := s.db.Exec(insertSQL, path, url)
+// likeEscaper escapes the LIKE wildcards and the escape character itself.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// SearchLinks retrieves the links whose path or URL contains query, ignoring
+// ASCII case, in the given order. Wildcards in query match literally.
+func (s *Store) SearchLinks(ctx context.Context, query string, sort LinkSort) ([]Link, error) {
+	pattern := "%" + likeEscaper.Replace(query) + "%"
+	rows, err := s.db.QueryContext(ctx, "SELECT "+linkColumns+` FROM links WHERE path LIKE ? ESCAPE '\' OR url LIKE ? ESCAPE '\'`+sort.orderBy(), pattern, pattern)
 	if err != nil {
-		if strings.Contains(err.Error(), "UNIQUE constraint failed: links.path") {
-			return fmt.Errorf("a link with path '%s' already exists", path)
+		return nil, err
+	}
+	defer rows.Close()
+
+	links := []Link{}
+	for rows.Next() {
+		link, err := scanLink(rows)
+		if err != nil {
+			return nil, err
+		}
+		links = append(links, link)
+	}
+	return links, rows.Err()
+}
+
+// CreateLink adds a new link to the database. Links created without tags
+// receive the configured default tags. A busy database is retried.
+func (s *Store) CreateLink(ctx context.Context, link Link) error {
+	return s.withRetry(ctx, func() error {
+		tx, err := s.db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		if err := s.insertLink(tx, link, false); err != nil {
+			return err
 		}
+		return tx.Commit()
+	})
+}
+
+// insertLink adds a new link as part of the given transaction. With keepID
+// the link is stored under link.ID instead of the next free ID; AUTOINCREMENT
+// then moves the ID sequence past it, so later links never reuse it.
+func (s *Store) insertLink(tx *sql.Tx, link Link, keepID bool) error {
+	link.Path = strings.ToLower(link.Path)
+	url := s.normalizeTarget(link.URL)
+	if len(link.Tags) == 0 {
+		link.Tags = s.defaultTags
+	}
+	if link.Group == "" {
+		link.Group = s.defaultGroup
+	}
+
+	var explicitID interface{}
+	if keepID {
+		explicitID = link.ID
+	}
+	if err := checkAliasFree(tx, link.Path); err != nil {
 		return err
 	}
-	return nil
+
+	insertSQL := `INSERT INTO links(id, path, url, host, rate_limit, owner, created_by, link_group, description, prefix, templated, expires_at, created_at, updated_at) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ` + sqliteNowMilli + `, ` + sqliteNowMilli + `)`
+	result, err := tx.Exec(insertSQL, explicitID, link.Path, url, targetHost(url), link.RateLimit, link.Owner, link.CreatedBy, link.Group, link.Description, link.Prefix, isTemplatedPath(link.Path), sqliteTime(link.ExpiresAt))
+	if err != nil {
+		if isUniqueViolation(err, "path") {
+			return fmt.Errorf("a link with path '%s' already exists", link.Path)
+		}
+		if isUniqueViolation(err, "id") {
+			return fmt.Errorf("id %d is already taken by another link", link.ID)
+		}
+		return err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	return setLinkTags(tx, id, link.Tags)
+}
+
+// insertStoredLink inserts a link exactly as it was stored before, keeping
+// its clicks, access and creation times, icon, tags and aliases, e.g. when it
+// comes back from the trash or a backup. A zero ID takes the next free ID; the
+// new ID is returned. Links always come back enabled, as trash entries and
+// backups written before links could be disabled do not record the state.
+func insertStoredLink(tx *sql.Tx, link Link) (int64, error) {
+	var explicitID interface{}
+	if link.ID > 0 {
+		explicitID = link.ID
+	}
+	if err := checkAliasFree(tx, link.Path); err != nil {
+		return 0, err
+	}
+
+	// updated_at is bumped so sync clients pick the link up again
+	insertSQL := `INSERT INTO links(id, path, url, host, rate_limit, owner, created_by, link_group, description, icon, prefix, templated, clicks, last_accessed_at, expires_at, created_at, updated_at)
+		VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ` + sqliteNowMilli + `)`
+	result, err := tx.Exec(insertSQL, explicitID, link.Path, link.URL, targetHost(link.URL), link.RateLimit, link.Owner, link.CreatedBy, link.Group, link.Description, link.Icon,
+		link.Prefix, link.Templated, link.Clicks, sqliteTime(link.LastAccessedAt), sqliteTime(link.ExpiresAt), sqliteTime(&link.CreatedAt))
+	if err != nil {
+		if isUniqueViolation(err, "path") {
+			return 0, fmt.Errorf("a link with path '%s' already exists", link.Path)
+		}
+		if isUniqueViolation(err, "id") {
+			return 0, fmt.Errorf("id %d is already taken by another link", link.ID)
+		}
+		return 0, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	if err := setLinkTags(tx, id, link.Tags); err != nil {
+		return 0, err
+	}
+	for _, alias := range link.Aliases {
+		if err := insertAlias(tx, id, strings.ToLower(alias)); err != nil {
+			return 0, err
+		}
+	}
+	return id, nil
+}
+
+// UpdateLink updates an existing link and records what changed in the audit
+// log. Updates that change nothing are skipped and leave no audit entry. A
+// busy database is retried.
+func (s *Store) UpdateLink(ctx context.Context, id int64, link Link) error {
+	return s.withRetry(ctx, func() error {
+		tx, err := s.db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		if err := s.updateLinkTx(tx, id, link); err != nil {
+			return err
+		}
+		return tx.Commit()
+	})
 }
 
-// UpdateLink updates an existing link.
-func (s *Store) UpdateLink(id int64, path, url string) error {
-	updateSQL := `UPDATE links SET path = ?, url = ? WHERE id = ?`
-	_, err := s.db.Exec(updateSQL, path, url, id)
+// updateLinkTx updates a link as part of the given transaction, recording an
+// audit entry when anything changed.
+func (s *Store) updateLinkTx(tx *sql.Tx, id int64, link Link) error {
+	link.ID = id
+	link.Path = strings.ToLower(link.Path)
+	link.URL = s.normalizeTarget(link.URL)
+
+	prior, err := scanLink(tx.QueryRow("SELECT "+linkColumns+" FROM links WHERE id = ?", id))
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("link with id %d not found", id)
+	}
 	if err != nil {
-		if strings.Contains(err.Error(), "UNIQUE constraint failed: links.path") {
-			return fmt.Errorf("a link with path '%s' already exists", path)
+		return err
+	}
+
+	changes := diffLinks(prior, link)
+	if len(changes) == 0 {
+		return nil
+	}
+	if link.Path != prior.Path {
+		if err := checkAliasFree(tx, link.Path); err != nil {
+			return err
+		}
+	}
+
+	updateSQL := `UPDATE links SET path = ?, url = ?, host = ?, rate_limit = ?, owner = ?, link_group = ?, description = ?, prefix = ?, templated = ?, expires_at = ?, updated_at = ` + sqliteNowMilli + ` WHERE id = ?`
+	_, err = tx.Exec(updateSQL, link.Path, link.URL, targetHost(link.URL), link.RateLimit, link.Owner, link.Group, link.Description, link.Prefix, isTemplatedPath(link.Path), sqliteTime(link.ExpiresAt), id)
+	if err != nil {
+		if isUniqueViolation(err, "path") {
+			return fmt.Errorf("a link with path '%s' already exists", link.Path)
 		}
 		return err
 	}
-	return nil
+
+	if err := setLinkTags(tx, id, link.Tags); err != nil {
+		return err
+	}
+
+	return insertAuditEntry(tx, id, AuditActionUpdate, changes)
+}
+
+// normalizeTarget applies the configured target URL canonicalization.
+func (s *Store) normalizeTarget(url string) string {
+	switch {
+	case s.canonicalizeTargets:
+		return canonicalizeURL(url)
+	case s.lowercaseTargetHosts:
+		return lowercaseTargetHost(url)
+	}
+	return url
 }
 
 // LinkExists checks if a link with the given ID exists.
-func (s *Store) LinkExists(id int64) (bool, error) {
+func (s *Store) LinkExists(ctx context.Context, id int64) (bool, error) {
 	var exists bool
 	query := `SELECT EXISTS(SELECT 1 FROM links WHERE id = ?)`
-	err := s.db.QueryRow(query, id).Scan(&exists)
+	err := s.db.QueryRowContext(ctx, query, id).Scan(&exists)
 	return exists, err
 }
 
-// DeleteLink removes a link from the database by its ID.
-func (s *Store) DeleteLink(id int64) error {
-	deleteSQL := `DELETE FROM links WHERE id = ?`
-	result, err := s.db.Exec(deleteSQL, id)
+// DeleteLink moves a link to the trash by its ID, leaving a tombstone so sync
+// clients can learn about the deletion. RestoreLink brings it back. A busy
+// database is retried.
+func (s *Store) DeleteLink(ctx context.Context, id int64) error {
+	return s.withRetry(ctx, func() error {
+		tx, err := s.db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		if err := deleteLinkTx(tx, id); err != nil {
+			return err
+		}
+		return tx.Commit()
+	})
+}
+
+// deleteLinkTx moves a link to the trash as part of the given transaction.
+// The link, its tags and its aliases are deleted, and its tombstone keeps a
+// copy of the link for RestoreLink. Uploaded icons are kept until the link is
+// purged.
+func deleteLinkTx(tx *sql.Tx, id int64) error {
+	link, err := scanLink(tx.QueryRow("SELECT "+linkColumns+" FROM links WHERE id = ?", id))
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("link with id %d not found", id)
+	}
 	if err != nil {
 		return err
 	}
-	
-	rowsAffected, err := result.RowsAffected()
+	data, err := json.Marshal(link)
 	if err != nil {
+		return fmt.Errorf("failed to encode deleted link: %w", err)
+	}
+
+	deleteSQL := `DELETE FROM links WHERE id = ?`
+	if _, err := tx.Exec(deleteSQL, id); err != nil {
 		return err
 	}
-	
-	if rowsAffected == 0 {
-		return fmt.Errorf("link with id %d not found", id)
+	if _, err := tx.Exec(`DELETE FROM link_tags WHERE link_id = ?`, id); err != nil {
+		return err
 	}
-	
-	return nil
+	if _, err := tx.Exec(`DELETE FROM link_aliases WHERE link_id = ?`, id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM link_checks WHERE link_id = ?`, id); err != nil {
+		return err
+	}
+
+	tombstoneSQL := `INSERT OR REPLACE INTO deleted_links(link_id, path, data, deleted_at) VALUES(?, ?, ?, ` + sqliteNowMilli + `)`
+	_, err = tx.Exec(tombstoneSQL, id, link.Path, string(data))
+	return err
+}
+
+// IsDeletedPath reports whether a link with the given path was deleted.
+func (s *Store) IsDeletedPath(ctx context.Context, path string) (bool, error) {
+	var deleted bool
+	query := `SELECT EXISTS(SELECT 1 FROM deleted_links WHERE path = ?)`
+	err := s.db.QueryRowContext(ctx, query, strings.ToLower(path)).Scan(&deleted)
+	return deleted, err
+}
+
+// CountLinks returns the total number of stored links.
+func (s *Store) CountLinks(ctx context.Context) (int64, error) {
+	var count int64
+	err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM links`).Scan(&count)
+	return count, err
+}
+
+// CountVisitsSince returns the number of redirects served since the given
+// time, counted from the click events.
+func (s *Store) CountVisitsSince(ctx context.Context, since time.Time) (int64, error) {
+	var count int64
+	query := `SELECT COUNT(*) FROM click_events WHERE clicked_at >= ?`
+	err := s.db.QueryRowContext(ctx, query, since.UTC().Format(sqliteMilliTimeFormat)).Scan(&count)
+	return count, err
+}
+
+// GetTopLinks returns the most visited links, ordered by their click count.
+// Links never visited are left out.
+func (s *Store) GetTopLinks(ctx context.Context, limit int) ([]LinkVisitCount, error) {
+	query := `SELECT id, path, url, clicks FROM links
+		WHERE clicks > 0
+		ORDER BY clicks DESC, path
+		LIMIT ?`
+	rows, err := s.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	links := []LinkVisitCount{}
+	for rows.Next() {
+		var link LinkVisitCount
+		if err := rows.Scan(&link.ID, &link.Path, &link.URL, &link.Visits); err != nil {
+			return nil, err
+		}
+		links = append(links, link)
+	}
+	return links, rows.Err()
+}
+
+// GetChangedSince returns links updated in the window (since, until] and the
+// IDs of links deleted in that window.
+func (s *Store) GetChangedSince(ctx context.Context, since, until time.Time) ([]Link, []int64, error) {
+	sinceStr := since.UTC().Format(sqliteMilliTimeFormat)
+	untilStr := until.UTC().Format(sqliteMilliTimeFormat)
+
+	query := "SELECT " + linkColumns + " FROM links WHERE updated_at > ? AND updated_at <= ? ORDER BY updated_at"
+	rows, err := s.db.QueryContext(ctx, query, sinceStr, untilStr)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	links := []Link{}
+	for rows.Next() {
+		link, err := scanLink(rows)
+		if err != nil {
+			return nil, nil, err
+		}
+		links = append(links, link)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	deletedQuery := `SELECT link_id FROM deleted_links WHERE deleted_at > ? AND deleted_at <= ? ORDER BY deleted_at`
+	deletedRows, err := s.db.QueryContext(ctx, deletedQuery, sinceStr, untilStr)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer deletedRows.Close()
+
+	deleted := []int64{}
+	for deletedRows.Next() {
+		var id int64
+		if err := deletedRows.Scan(&id); err != nil {
+			return nil, nil, err
+		}
+		deleted = append(deleted, id)
+	}
+	return links, deleted, deletedRows.Err()
+}
+
+// GetVisitCounts returns the click count of each given link ID, or of every
+// link when ids is empty. Unknown IDs are omitted from the result.
+func (s *Store) GetVisitCounts(ctx context.Context, ids []int64) (map[int64]int64, error) {
+	query := `SELECT id, clicks FROM links`
+	args := make([]interface{}, len(ids))
+	if len(ids) > 0 {
+		placeholders := make([]string, len(ids))
+		for i, id := range ids {
+			placeholders[i] = "?"
+			args[i] = id
+		}
+		query += " WHERE id IN (" + strings.Join(placeholders, ", ") + ")"
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[int64]int64)
+	for rows.Next() {
+		var id, visits int64
+		if err := rows.Scan(&id, &visits); err != nil {
+			return nil, err
+		}
+		counts[id] = visits
+	}
+	return counts, rows.Err()
+}
+
+// GetNeverUsedLinks retrieves links created before the given time that have
+// never served a redirect, oldest first.
+func (s *Store) GetNeverUsedLinks(ctx context.Context, createdBefore time.Time) ([]Link, error) {
+	query := `SELECT ` + linkColumns + ` FROM links
+		WHERE created_at <= ? AND clicks = 0
+		ORDER BY created_at, id`
+	rows, err := s.db.QueryContext(ctx, query, createdBefore.UTC().Format(sqliteMilliTimeFormat))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	links := []Link{}
+	for rows.Next() {
+		link, err := scanLink(rows)
+		if err != nil {
+			return nil, err
+		}
+		links = append(links, link)
+	}
+	return links, rows.Err()
+}
+
+// GetLinksByOwner retrieves the links owned or created by owner, for a "my
+// links" view, in the given order.
+func (s *Store) GetLinksByOwner(ctx context.Context, owner string, sort LinkSort) ([]Link, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT "+linkColumns+" FROM links WHERE owner = ? OR created_by = ?"+sort.orderBy(), owner, owner)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	links := []Link{}
+	for rows.Next() {
+		link, err := scanLink(rows)
+		if err != nil {
+			return nil, err
+		}
+		links = append(links, link)
+	}
+	return links, rows.Err()
+}
+
+// TransferOwnership reassigns links owned by from to the new owner in one
+// transaction, recording an audit entry per link. When ids is non-empty only
+// those links are considered. It returns the number of links transferred.
+func (s *Store) TransferOwnership(ctx context.Context, from, to string, ids []int64) (int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	query := `SELECT id FROM links WHERE owner = ?`
+	args := []interface{}{from}
+	if len(ids) > 0 {
+		placeholders := make([]string, len(ids))
+		for i, id := range ids {
+			placeholders[i] = "?"
+			args = append(args, id)
+		}
+		query += " AND id IN (" + strings.Join(placeholders, ", ") + ")"
+	}
+
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	var linkIDs []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		linkIDs = append(linkIDs, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	changes := map[string]FieldChange{"owner": {Old: from, New: to}}
+	updateSQL := `UPDATE links SET owner = ?, updated_at = ` + sqliteNowMilli + ` WHERE id = ?`
+	for _, id := range linkIDs {
+		if _, err := tx.ExecContext(ctx, updateSQL, to, id); err != nil {
+			return 0, err
+		}
+		if err := insertAuditEntry(tx, id, AuditActionTransfer, changes); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return len(linkIDs), nil
 }
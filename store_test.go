@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCreateLinkAppliesDefaultTagsAndGroup(t *testing.T) {
+	tests := []struct {
+		name      string
+		tags      []string
+		group     string
+		wantTags  []string
+		wantGroup string
+	}{
+		{"defaults when unspecified", nil, "", []string{"team:infra", "tools"}, "infra"},
+		{"explicit tags replace defaults", []string{"docs"}, "", []string{"docs"}, "infra"},
+		{"explicit tags overlapping defaults", []string{"tools", "docs"}, "", []string{"docs", "tools"}, "infra"},
+		{"explicit group replaces default", nil, "platform", []string{"team:infra", "tools"}, "platform"},
+		{"explicit tags and group", []string{"Docs"}, "Platform", []string{"docs"}, "platform"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, handler := newTestServer(t, func(c *Config) {
+				c.DefaultTags = []string{"team:infra", "tools"}
+				c.DefaultGroup = "infra"
+			})
+			link := createLink(t, server, handler, Link{Path: "docs", URL: "https://docs.example.com", Tags: tt.tags, Group: tt.group})
+			if !reflect.DeepEqual(link.Tags, tt.wantTags) {
+				t.Errorf("tags = %q, want %q", link.Tags, tt.wantTags)
+			}
+			if link.Group != tt.wantGroup {
+				t.Errorf("group = %q, want %q", link.Group, tt.wantGroup)
+			}
+		})
+	}
+}
+
+func TestCreateLinkWithoutDefaults(t *testing.T) {
+	server, handler := newTestServer(t, nil)
+	link := createLink(t, server, handler, Link{Path: "docs", URL: "https://docs.example.com"})
+	if len(link.Tags) != 0 || link.Group != "" {
+		t.Errorf("tags = %q, group = %q; want neither", link.Tags, link.Group)
+	}
+}
+
+func TestUpdateLinkKeepsDefaultsOff(t *testing.T) {
+	server, handler := newTestServer(t, func(c *Config) {
+		c.DefaultTags = []string{"tools"}
+		c.DefaultGroup = "infra"
+	})
+	link := createLink(t, server, handler, Link{Path: "docs", URL: "https://docs.example.com", Tags: []string{"docs"}, Group: "platform"})
+
+	// Clearing tags and group on update must not bring the defaults back
+	if w := serve(t, handler, http.MethodPut, linkTarget(link.ID), Link{Path: "docs", URL: "https://docs.example.com"}); w.Code != http.StatusOK {
+		t.Fatalf("update: status = %d: %s", w.Code, w.Body.String())
+	}
+	updated, err := server.store.GetLinkByID(context.Background(), link.ID)
+	if err != nil {
+		t.Fatalf("GetLinkByID: %v", err)
+	}
+	if len(updated.Tags) != 0 || updated.Group != "" {
+		t.Errorf("tags = %q, group = %q after clearing; want neither", updated.Tags, updated.Group)
+	}
+}
+
+func TestStorePragmas(t *testing.T) {
+	tests := []struct {
+		mode        string
+		busyTimeout time.Duration
+	}{
+		{journalModeWAL, 5 * time.Second},
+		{journalModeDelete, 250 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			server, _ := newTestServer(t, func(c *Config) {
+				c.DBJournalMode = tt.mode
+				c.DBBusyTimeout = tt.busyTimeout
+			})
+			db := server.store.(*Store).db
+			var mode string
+			var timeout int64
+			if err := db.QueryRow(`PRAGMA journal_mode`).Scan(&mode); err != nil {
+				t.Fatalf("reading journal_mode: %v", err)
+			}
+			if err := db.QueryRow(`PRAGMA busy_timeout`).Scan(&timeout); err != nil {
+				t.Fatalf("reading busy_timeout: %v", err)
+			}
+			if mode != tt.mode || timeout != tt.busyTimeout.Milliseconds() {
+				t.Errorf("journal_mode = %q, busy_timeout = %d, want %q, %d", mode, timeout, tt.mode, tt.busyTimeout.Milliseconds())
+			}
+		})
+	}
+}
+
+func TestConcurrentWrites(t *testing.T) {
+	server, _ := newTestServer(t, nil)
+	store := server.store.(*Store)
+	ctx := context.Background()
+	if err := store.CreateLink(ctx, Link{Path: "hot", URL: "https://hot.example.com"}); err != nil {
+		t.Fatalf("CreateLink: %v", err)
+	}
+	hot, _ := store.GetLinkByPath(ctx, "hot")
+
+	const writers, writes = 8, 25
+	var wg sync.WaitGroup
+	errs := make(chan error, writers*writes*3)
+	for i := range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range writes {
+				path := fmt.Sprintf("link-%d-%d", i, j)
+				if err := store.CreateLink(ctx, Link{Path: path, URL: "https://example.com/" + path}); err != nil {
+					errs <- fmt.Errorf("CreateLink(%s): %w", path, err)
+				}
+				if err := store.RecordClick(ctx, hot.ID, "", ""); err != nil {
+					errs <- fmt.Errorf("RecordClick: %w", err)
+				}
+				update := Link{Path: "hot", URL: fmt.Sprintf("https://hot.example.com/%d", j)}
+				if err := store.UpdateLink(ctx, hot.ID, update); err != nil {
+					errs <- fmt.Errorf("UpdateLink: %w", err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	var links int
+	if err := store.db.QueryRow(`SELECT COUNT(*) FROM links`).Scan(&links); err != nil {
+		t.Fatalf("counting links: %v", err)
+	}
+	if links != writers*writes+1 {
+		t.Errorf("links = %d, want %d", links, writers*writes+1)
+	}
+	hot, _ = store.GetLinkByPath(ctx, "hot")
+	if hot.Clicks != writers*writes {
+		t.Errorf("clicks = %d, want %d", hot.Clicks, writers*writes)
+	}
+}
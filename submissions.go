@@ -0,0 +1,99 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// submissionTTL is how long a create form's submission token is remembered.
+const submissionTTL = 10 * time.Minute
+
+// submissionWait bounds how long a repeated submit waits for the first one.
+const submissionWait = 5 * time.Second
+
+// submission tracks the outcome of the first submit of a create form.
+type submission struct {
+	done      chan struct{}
+	succeeded bool
+	expires   time.Time
+}
+
+// submissionTracker recognizes repeated submits of the same create form, such
+// as a double-clicked button, by the form's submission token.
+type submissionTracker struct {
+	mu      sync.Mutex
+	entries map[string]*submission
+}
+
+// newSubmissionTracker creates an empty tracker.
+func newSubmissionTracker() *submissionTracker {
+	return &submissionTracker{entries: make(map[string]*submission)}
+}
+
+// newSubmissionToken returns a random token embedded in each create form.
+func newSubmissionToken() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
+
+// begin claims a token for the current submit. It returns nil when this is
+// the first submit with the token, or the earlier submit otherwise. An empty
+// token is never tracked.
+func (t *submissionTracker) begin(token string) *submission {
+	if token == "" {
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	for key, entry := range t.entries {
+		if now.After(entry.expires) {
+			delete(t.entries, key)
+		}
+	}
+
+	if entry, ok := t.entries[token]; ok {
+		return entry
+	}
+	t.entries[token] = &submission{done: make(chan struct{}), expires: now.Add(submissionTTL)}
+	return nil
+}
+
+// finish records the outcome of the submit that claimed a token. A failed
+// submit releases the token so the form can be corrected and sent again.
+func (t *submissionTracker) finish(token string, succeeded bool) {
+	if token == "" {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	entry, ok := t.entries[token]
+	if !ok {
+		return
+	}
+	entry.succeeded = succeeded
+	if !succeeded {
+		delete(t.entries, token)
+	}
+	close(entry.done)
+}
+
+// wait blocks until the submit finishes, up to submissionWait, and reports
+// whether it created the link.
+func (s *submission) wait() bool {
+	select {
+	case <-s.done:
+		return s.succeeded
+	case <-time.After(submissionWait):
+		return false
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sync"
+	"testing"
+)
+
+func TestPortalDoubleSubmit(t *testing.T) {
+	tests := []struct {
+		name        string
+		firstToken  string
+		secondToken string
+		secondOK    bool
+	}{
+		{"same token", "token-1", "token-1", true},
+		{"different tokens", "token-1", "token-2", false},
+		{"no token", "", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, handler := newTestServer(t, nil)
+			form := url.Values{"path": {"docs"}, "url": {"https://docs.example.com"}}
+
+			form.Set("submit_token", tt.firstToken)
+			if w := serveForm(t, handler, http.MethodPost, "/go/links", form); w.Code != http.StatusSeeOther {
+				t.Fatalf("first submit: status = %d, want %d", w.Code, http.StatusSeeOther)
+			}
+
+			form.Set("submit_token", tt.secondToken)
+			w := serveForm(t, handler, http.MethodPost, "/go/links", form)
+			if got := w.Code == http.StatusSeeOther; got != tt.secondOK {
+				t.Errorf("second submit: status = %d, want success %v", w.Code, tt.secondOK)
+			}
+
+			links, err := server.store.GetAllLinks(context.Background())
+			if err != nil {
+				t.Fatalf("GetAllLinks: %v", err)
+			}
+			if len(links) != 1 {
+				t.Errorf("%d links stored, want 1", len(links))
+			}
+		})
+	}
+}
+
+func TestPortalConcurrentDoubleSubmit(t *testing.T) {
+	server, handler := newTestServer(t, nil)
+	form := url.Values{"path": {"docs"}, "url": {"https://docs.example.com"}, "submit_token": {"token-1"}}
+
+	const submits = 4
+	codes := make([]int, submits)
+	var wg sync.WaitGroup
+	for i := 0; i < submits; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			codes[i] = serveForm(t, handler, http.MethodPost, "/go/links", form).Code
+		}(i)
+	}
+	wg.Wait()
+
+	for i, code := range codes {
+		if code != http.StatusSeeOther {
+			t.Errorf("submit %d: status = %d, want %d", i, code, http.StatusSeeOther)
+		}
+	}
+	links, err := server.store.GetAllLinks(context.Background())
+	if err != nil {
+		t.Fatalf("GetAllLinks: %v", err)
+	}
+	if len(links) != 1 {
+		t.Errorf("%d links stored, want 1", len(links))
+	}
+}
+
+func TestPortalFailedSubmitReleasesToken(t *testing.T) {
+	server, handler := newTestServer(t, nil)
+	form := url.Values{"path": {"docs"}, "url": {"not a url"}, "submit_token": {"token-1"}}
+	if w := serveForm(t, handler, http.MethodPost, "/go/links", form); w.Code == http.StatusSeeOther {
+		t.Fatalf("invalid submit succeeded")
+	}
+
+	form.Set("url", "https://docs.example.com")
+	if w := serveForm(t, handler, http.MethodPost, "/go/links", form); w.Code != http.StatusSeeOther {
+		t.Fatalf("corrected submit: status = %d, want %d", w.Code, http.StatusSeeOther)
+	}
+	if _, err := server.store.GetLinkByPath(context.Background(), "docs"); err != nil {
+		t.Errorf("corrected submit did not create the link: %v", err)
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// maxSuggestions caps how many similar paths are offered for a missed lookup.
+const maxSuggestions = 5
+
+// ResolveResult is the response of the resolve endpoint. On a miss Link is
+// nil and, when requested, Suggestions lists similar existing paths.
+type ResolveResult struct {
+	Path        string   `json:"path"`
+	Found       bool     `json:"found"`
+	Link        *Link    `json:"link,omitempty"`
+	Suggestions []string `json:"suggestions,omitempty"`
+}
+
+// similarLinks ranks links by how closely their path matches path and
+// returns at most limit of them, closest first. A link matches when its path
+// is within edit distance of a third of the path length (minimum 2), or when
+// it extends path, e.g. team/deploy for team.
+func similarLinks(path string, links []Link, limit int) []Link {
+	path = strings.ToLower(path)
+	if path == "" {
+		return []Link{}
+	}
+	maxDistance := len(path) / 3
+	if maxDistance < 2 {
+		maxDistance = 2
+	}
+
+	type scored struct {
+		link     Link
+		distance int
+	}
+	var matches []scored
+	for _, link := range links {
+		candidate := strings.ToLower(link.Path)
+		distance := editDistance(path, candidate)
+		if distance <= maxDistance || strings.HasPrefix(candidate, path) {
+			matches = append(matches, scored{link, distance})
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].distance != matches[j].distance {
+			return matches[i].distance < matches[j].distance
+		}
+		return matches[i].link.Path < matches[j].link.Path
+	})
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+
+	similar := make([]Link, len(matches))
+	for i, match := range matches {
+		similar[i] = match.link
+	}
+	return similar
+}
+
+// FindSimilarPaths returns up to limit links whose path is similar to path,
+// closest first.
+func (s *Store) FindSimilarPaths(ctx context.Context, path string, limit int) ([]Link, error) {
+	links, err := s.GetAllLinks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return similarLinks(path, links, limit), nil
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	ar, br := []rune(a), []rune(b)
+	prev := make([]int, len(br)+1)
+	curr := make([]int, len(br)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ar); i++ {
+		curr[0] = i
+		for j := 1; j <= len(br); j++ {
+			cost := 1
+			if ar[i-1] == br[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(br)]
+}
+
+// suggestionsFor returns the existing paths most similar to path.
+func (s *Server) suggestionsFor(ctx context.Context, path string) ([]string, error) {
+	links, err := s.store.FindSimilarPaths(ctx, path, maxSuggestions)
+	if err != nil {
+		return nil, err
+	}
+	paths := make([]string, len(links))
+	for i, link := range links {
+		paths[i] = link.Path
+	}
+	return paths, nil
+}
+
+// handleResolve looks up the link for a path without redirecting.
+// Resolve godoc
+// @Summary      Resolve a path
+// @Description  Look up the link for a path; with suggest=true a miss includes similar existing paths
+// @Tags         links
+// @Produce      json
+// @Param        path     query  string   true   "Link path"
+// @Param        suggest  query  boolean  false  "Include similar paths on a miss"
+// @Success      200  {object}  ResolveResult
+// @Failure      404  {object}  ResolveResult
+// @Router       /resolve [get]
+func (s *Server) handleResolve(w http.ResponseWriter, r *http.Request) {
+	path := normalizePath(r.URL.Query().Get("path"))
+	if path == "" {
+		writeErrorJSON(w, "Query parameter 'path' is required", http.StatusBadRequest)
+		return
+	}
+
+	result := ResolveResult{Path: path}
+	status := http.StatusOK
+
+	link, err := s.store.GetLinkByPath(r.Context(), path)
+	switch {
+	case err == nil:
+		result.Found = true
+		result.Link = link
+	case err == sql.ErrNoRows:
+		status = http.StatusNotFound
+		if r.URL.Query().Get("suggest") == "true" {
+			result.Suggestions, err = s.suggestionsFor(r.Context(), path)
+			if err != nil {
+				logRequestError(r, "API Resolve suggestions error", err)
+				writeErrorJSON(w, "Failed to compute suggestions", http.StatusInternalServerError)
+				return
+			}
+		}
+	default:
+		logRequestError(r, "API Resolve error", err)
+		writeErrorJSON(w, "Failed to resolve path", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// maxTagsPerLink caps how many tags a single link can carry.
+const maxTagsPerLink = 10
+
+// tagPattern matches a single normalized tag such as "infra" or "team:infra".
+var tagPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9:_-]{0,31}$`)
+
+// linkTagsColumn selects the comma-separated tags of the current links row.
+const linkTagsColumn = "(SELECT group_concat(tag, ',') FROM link_tags WHERE link_tags.link_id = links.id)"
+
+// TagRenameRequest is the payload of the tag rename endpoint.
+type TagRenameRequest struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// TagRenameResponse reports how many links had the tag renamed.
+type TagRenameResponse struct {
+	Renamed int `json:"renamed"`
+}
+
+// TagList returns the tags of a link as a comma-separated string for forms.
+func (l Link) TagList() string {
+	return strings.Join(l.Tags, ", ")
+}
+
+// normalizeTags lowercases and trims tags, dropping blanks and duplicates,
+// and returns them sorted.
+func normalizeTags(tags []string) []string {
+	seen := make(map[string]bool)
+	var normalized []string
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	sort.Strings(normalized)
+	return normalized
+}
+
+// validateTag ensures a normalized tag follows the allowed format.
+func validateTag(tag string) error {
+	if !tagPattern.MatchString(tag) {
+		return fmt.Errorf("invalid tag '%s': use up to 32 lowercase letters, numbers, hyphens, underscores or colons", tag)
+	}
+	return nil
+}
+
+// validateTags checks every tag of a link.
+func validateTags(tags []string) error {
+	if len(tags) > maxTagsPerLink {
+		return fmt.Errorf("a link can have at most %d tags", maxTagsPerLink)
+	}
+	for _, tag := range tags {
+		if err := validateTag(tag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseTags splits the comma-separated tags read by linkTagsColumn.
+func parseTags(value sql.NullString) []string {
+	if !value.Valid {
+		return nil
+	}
+	return normalizeTags(splitList(value.String))
+}
+
+// setLinkTags replaces the tags of a link as part of the given transaction.
+func setLinkTags(tx *sql.Tx, linkID int64, tags []string) error {
+	if _, err := tx.Exec(`DELETE FROM link_tags WHERE link_id = ?`, linkID); err != nil {
+		return err
+	}
+	for _, tag := range tags {
+		if _, err := tx.Exec(`INSERT OR IGNORE INTO link_tags(link_id, tag) VALUES(?, ?)`, linkID, tag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetLinksByTag retrieves the links carrying tag in the given order.
+func (s *Store) GetLinksByTag(ctx context.Context, tag string, sort LinkSort) ([]Link, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT "+linkColumns+" FROM links WHERE id IN (SELECT link_id FROM link_tags WHERE tag = ?)"+sort.orderBy(), tag)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	links := []Link{}
+	for rows.Next() {
+		link, err := scanLink(rows)
+		if err != nil {
+			return nil, err
+		}
+		links = append(links, link)
+	}
+	return links, rows.Err()
+}
+
+// RenameTag renames a tag on every link carrying it, merging into the new
+// tag where a link already has it, and records an audit entry per link.
+// It returns the number of links affected.
+func (s *Store) RenameTag(ctx context.Context, from, to string) (int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `SELECT link_id FROM link_tags WHERE tag = ? ORDER BY link_id`, from)
+	if err != nil {
+		return 0, err
+	}
+	var linkIDs []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		linkIDs = append(linkIDs, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	updateSQL := `UPDATE links SET updated_at = ` + sqliteNowMilli + ` WHERE id = ?`
+	for _, id := range linkIDs {
+		var tags sql.NullString
+		if err := tx.QueryRowContext(ctx, `SELECT group_concat(tag, ',') FROM link_tags WHERE link_id = ?`, id).Scan(&tags); err != nil {
+			return 0, err
+		}
+		prior := parseTags(tags)
+		renamed := make([]string, len(prior))
+		for i, tag := range prior {
+			if tag == from {
+				tag = to
+			}
+			renamed[i] = tag
+		}
+		renamed = normalizeTags(renamed)
+
+		if err := setLinkTags(tx, id, renamed); err != nil {
+			return 0, err
+		}
+		if _, err := tx.ExecContext(ctx, updateSQL, id); err != nil {
+			return 0, err
+		}
+		changes := map[string]FieldChange{"tags": {Old: prior, New: renamed}}
+		if err := insertAuditEntry(tx, id, AuditActionTagRename, changes); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return len(linkIDs), nil
+}
+
+// handleRenameTag renames a tag across all links.
+// RenameTag godoc
+// @Summary      Rename a tag
+// @Description  Rename a tag on every link, merging into the new tag where it already exists (admin only)
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        rename  body  TagRenameRequest  true  "Tag rename"
+// @Success      200  {object}  TagRenameResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      422  {object}  ErrorResponse
+// @Router       /tags/rename [post]
+func (s *Server) handleRenameTag(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+
+	var req TagRenameRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErrorJSON(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	req.From = strings.ToLower(strings.TrimSpace(req.From))
+	req.To = strings.ToLower(strings.TrimSpace(req.To))
+	if req.From == "" || req.To == "" {
+		writeErrorJSON(w, "from and to are required", http.StatusUnprocessableEntity)
+		return
+	}
+	if req.From == req.To {
+		writeErrorJSON(w, "from and to must differ", http.StatusUnprocessableEntity)
+		return
+	}
+	if err := validateTag(req.To); err != nil {
+		writeErrorJSON(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	count, err := s.store.RenameTag(r.Context(), req.From, req.To)
+	if err != nil {
+		logRequestError(r, "API RenameTag error", err)
+		writeErrorJSON(w, "Failed to rename tag", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(TagRenameResponse{Renamed: count})
+}
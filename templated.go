@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// templateToken is the placeholder of templated links. As the last segment
+// of a path it captures the rest of the request path, which replaces every
+// occurrence of the token in the target URL: "search/{*}" pointing at
+// "https://google.com/search?q={*}" sends /search/golang to ?q=golang.
+const templateToken = "{*}"
+
+// isTemplatedPath reports whether a path ends in the placeholder segment.
+func isTemplatedPath(path string) bool {
+	return strings.HasSuffix(path, "/"+templateToken)
+}
+
+// validateTemplateURL checks that a link's URL has a placeholder exactly when
+// its path is templated.
+func validateTemplateURL(path, target string) error {
+	hasToken := strings.Contains(target, templateToken)
+	if isTemplatedPath(path) && !hasToken {
+		return fmt.Errorf("url of a templated link must contain %s", templateToken)
+	}
+	if !isTemplatedPath(path) && hasToken {
+		return fmt.Errorf("url contains %s but the path does not end in /%s", templateToken, templateToken)
+	}
+	return nil
+}
+
+// GetTemplatedLink finds the templated link for a path without an exact
+// match, preferring the longest literal prefix. It returns the link and the
+// captured remainder of the path, or sql.ErrNoRows when none matches. The
+// placeholder does not count towards maxPathSegments, so the literal prefix
+// is bounded like any stored path.
+func (s *Store) GetTemplatedLink(ctx context.Context, path string) (*Link, string, error) {
+	segments := strings.Split(path, "/")
+	for i := candidateSegments(segments); i >= 1; i-- {
+		candidate := strings.ToLower(strings.Join(segments[:i], "/")) + "/" + templateToken
+		link, err := scanLink(s.db.QueryRowContext(ctx, "SELECT "+linkColumns+" FROM links WHERE templated = 1 AND path = ?", candidate))
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return nil, "", err
+		}
+		return &link, strings.Join(segments[i:], "/"), nil
+	}
+	return nil, "", sql.ErrNoRows
+}
+
+// expandTemplate substitutes the query-escaped capture for every placeholder
+// in a target URL.
+func expandTemplate(target, capture string) string {
+	return strings.ReplaceAll(target, templateToken, url.QueryEscape(capture))
+}
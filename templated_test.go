@@ -0,0 +1,102 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestValidateTemplateURL(t *testing.T) {
+	tests := []struct {
+		path, url string
+		wantErr   bool
+	}{
+		{"search/{*}", "https://google.com/search?q={*}", false},
+		{"search/{*}", "https://google.com/search", true},
+		{"search", "https://google.com/search?q={*}", true},
+		{"search", "https://google.com/search", false},
+		{"team/search/{*}", "https://example.com/{*}/x?q={*}", false},
+	}
+	for _, tt := range tests {
+		if err := validateTemplateURL(tt.path, tt.url); (err != nil) != tt.wantErr {
+			t.Errorf("validateTemplateURL(%q, %q) error = %v, want error %v", tt.path, tt.url, err, tt.wantErr)
+		}
+	}
+}
+
+func TestTemplatedPathValidation(t *testing.T) {
+	tests := []struct {
+		path   string
+		url    string
+		status int
+	}{
+		{"search/{*}", "https://google.com/search?q={*}", http.StatusCreated},
+		{"search/{*}", "https://google.com/search", http.StatusUnprocessableEntity},
+		{"{*}/search", "https://google.com/search?q={*}", http.StatusUnprocessableEntity},
+		{"search/{*}/more", "https://google.com/search?q={*}", http.StatusUnprocessableEntity},
+		{"{*}", "https://google.com/search?q={*}", http.StatusUnprocessableEntity},
+	}
+	for _, tt := range tests {
+		_, handler := newTestServer(t, nil)
+		w := serve(t, handler, http.MethodPost, "/api/links", Link{Path: tt.path, URL: tt.url})
+		if w.Code != tt.status {
+			t.Errorf("create %q -> %q: status = %d, want %d: %s", tt.path, tt.url, w.Code, tt.status, w.Body.String())
+		}
+	}
+}
+
+func TestTemplatedLinkRedirects(t *testing.T) {
+	server, handler := newTestServer(t, nil)
+	createLink(t, server, handler, Link{Path: "search/{*}", URL: "https://google.com/search?q={*}"})
+	createLink(t, server, handler, Link{Path: "search/docs/{*}", URL: "https://docs.example.com/search?q={*}"})
+	createLink(t, server, handler, Link{Path: "search/golang", URL: "https://go.dev"})
+	createLink(t, server, handler, Link{Path: "gh/{*}", URL: "https://github.com/{*}/issues?author={*}"})
+
+	tests := []struct {
+		path     string
+		status   int
+		location string
+	}{
+		{"/search/rust", http.StatusFound, "https://google.com/search?q=rust"},
+		{"/search/Hello%20World", http.StatusFound, "https://google.com/search?q=Hello+World"},
+		{"/search/a&b=c", http.StatusFound, "https://google.com/search?q=a%26b%3Dc"},
+		{"/search/a/b", http.StatusFound, "https://google.com/search?q=a%2Fb"},
+		{"/search/docs/install", http.StatusFound, "https://docs.example.com/search?q=install"},
+		{"/search/golang", http.StatusFound, "https://go.dev"},
+		{"/gh/octocat", http.StatusFound, "https://github.com/octocat/issues?author=octocat"},
+		{"/search", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		w := serve(t, handler, http.MethodGet, tt.path, nil)
+		if w.Code != tt.status {
+			t.Errorf("GET %s: status = %d, want %d", tt.path, w.Code, tt.status)
+			continue
+		}
+		if got := w.Header().Get("Location"); got != tt.location {
+			t.Errorf("GET %s: Location = %q, want %q", tt.path, got, tt.location)
+		}
+	}
+}
+
+func TestTemplatedLinkDeepRequestPath(t *testing.T) {
+	server, handler := newTestServer(t, nil)
+	createLink(t, server, handler, Link{Path: "gh/{*}", URL: "https://github.com/{*}"})
+	createLink(t, server, handler, Link{Path: "a/b/c/d/e/{*}", URL: "https://deep.example.com/?q={*}"})
+
+	deep := strings.Repeat("x/", 4999) + "x"
+	tests := []struct {
+		path     string
+		status   int
+		location string
+	}{
+		{"/gh/" + deep, http.StatusFound, "https://github.com/" + strings.ReplaceAll(deep, "/", "%2F")},
+		{"/a/b/c/d/e/" + deep, http.StatusFound, "https://deep.example.com/?q=" + strings.ReplaceAll(deep, "/", "%2F")},
+		{"/" + strings.Repeat("a/", 5000) + "a", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		w := serve(t, handler, http.MethodGet, tt.path, nil)
+		if w.Code != tt.status || w.Header().Get("Location") != tt.location {
+			t.Errorf("GET %.30s...: status = %d, Location = %.40q, want %d %.40q", tt.path, w.Code, w.Header().Get("Location"), tt.status, tt.location)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"embed"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+)
+
+// embeddedTemplates are the portal templates built into the binary, so it
+// runs without the templates directory next to it.
+//
+//go:embed templates/*.html templates/components/*.html
+var embeddedTemplates embed.FS
+
+// templateFS returns the templates directory: dir when TEMPLATES_DIR is set,
+// otherwise the embedded copy.
+func templateFS(dir string) fs.FS {
+	if dir != "" {
+		return os.DirFS(dir)
+	}
+	templates, err := fs.Sub(embeddedTemplates, "templates")
+	if err != nil {
+		// The embedded directory always exists
+		panic(err)
+	}
+	return templates
+}
+
+// parseTemplates parses the page templates and the components of a
+// templates directory into one set.
+func parseTemplates(fsys fs.FS) (*template.Template, error) {
+	// Functions available to all templates
+	funcs := template.FuncMap{"submitToken": newSubmissionToken}
+
+	templates, err := template.New("").Funcs(funcs).ParseFS(fsys, "*.html")
+	if err != nil {
+		return nil, fmt.Errorf("error parsing templates: %w", err)
+	}
+
+	// Parse component templates
+	componentTemplates, err := template.New("").Funcs(funcs).ParseFS(fsys, "components/*.html")
+	if err != nil {
+		// Components are optional for now, just log the error
+		slog.Warn("Could not parse component templates", "error", err)
+		return templates, nil
+	}
+
+	// Add component templates to the main template
+	for _, t := range componentTemplates.Templates() {
+		templates, err = templates.AddParseTree(t.Name(), t.Tree)
+		if err != nil {
+			slog.Warn("Could not add component template", "template", t.Name(), "error", err)
+		}
+	}
+	return templates, nil
+}
+
+// executeTemplate renders the named template. With TEMPLATES_DIR set the
+// templates are parsed again first, so edits show up on the next request.
+func (s *Server) executeTemplate(w io.Writer, name string, data any) error {
+	templates := s.templates
+	if s.config.TemplatesDir != "" {
+		parsed, err := parseTemplates(templateFS(s.config.TemplatesDir))
+		if err != nil {
+			return err
+		}
+		templates = parsed
+	}
+	return templates.ExecuteTemplate(w, name, data)
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// defaultTLSMinVersion is the oldest TLS version accepted unless configured.
+const defaultTLSMinVersion = "1.2"
+
+// tlsVersions maps TLS_MIN_VERSION values to crypto/tls versions.
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// parseTLSVersion resolves a TLS_MIN_VERSION value such as "1.2".
+func parseTLSVersion(version string) (uint16, error) {
+	if v, ok := tlsVersions[strings.TrimSpace(version)]; ok {
+		return v, nil
+	}
+	names := make([]string, 0, len(tlsVersions))
+	for name := range tlsVersions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return 0, fmt.Errorf("invalid TLS version '%s': must be one of %s", version, strings.Join(names, ", "))
+}
+
+// TLSEnabled reports whether the server terminates TLS itself.
+func (c *Config) TLSEnabled() bool {
+	return c.TLSCertFile != ""
+}
+
+// TLSConfig returns the TLS settings of the server, or nil when TLS is not
+// enabled. Validate has already checked the minimum version.
+func (c *Config) TLSConfig() *tls.Config {
+	if !c.TLSEnabled() {
+		return nil
+	}
+	minVersion, _ := parseTLSVersion(c.TLSMinVersion)
+	return &tls.Config{MinVersion: minVersion}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ToggleLink flips whether a link redirects, recording the change in the
+// audit log, and returns the new state. Disabled links keep their data and
+// stay listed; only redirects to them are refused. A busy database is retried.
+func (s *Store) ToggleLink(ctx context.Context, id int64) (bool, error) {
+	var enabled bool
+	err := s.withRetry(ctx, func() error {
+		tx, err := s.db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		var prior bool
+		err = tx.QueryRowContext(ctx, `SELECT enabled FROM links WHERE id = ?`, id).Scan(&prior)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("link with id %d not found", id)
+		}
+		if err != nil {
+			return err
+		}
+
+		enabled = !prior
+		updateSQL := `UPDATE links SET enabled = ?, updated_at = ` + sqliteNowMilli + ` WHERE id = ?`
+		if _, err := tx.ExecContext(ctx, updateSQL, enabled, id); err != nil {
+			return err
+		}
+		changes := map[string]FieldChange{"enabled": {Old: prior, New: enabled}}
+		if err := insertAuditEntry(tx, id, AuditActionToggle, changes); err != nil {
+			return err
+		}
+		return tx.Commit()
+	})
+	return enabled, err
+}
+
+// handleToggleLink enables a disabled link or disables an enabled one.
+// ToggleLink godoc
+// @Summary      Enable or disable a link
+// @Description  Flip whether a link redirects; disabled links answer like LINK_STATE_DISABLED_* configures
+// @Tags         links
+// @Produce      json
+// @Param        id  path  int  true  "Link ID"
+// @Success      200  {object}  Link
+// @Failure      404  {object}  ErrorResponse
+// @Router       /links/{id}/toggle [post]
+func (s *Server) handleToggleLink(w http.ResponseWriter, r *http.Request, id int64) {
+	if _, err := s.store.ToggleLink(r.Context(), id); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			writeErrorJSON(w, fmt.Sprintf("Link with id %d not found", id), http.StatusNotFound)
+			return
+		}
+		logRequestError(r, "API ToggleLink error", err, "id", id)
+		writeErrorJSON(w, "Failed to toggle link", http.StatusInternalServerError)
+		return
+	}
+	s.notifyUpdated(r.Context(), id)
+
+	link, err := s.store.GetLinkByID(r.Context(), id)
+	if err != nil {
+		logRequestError(r, "API ToggleLink reload error", err, "id", id)
+		writeErrorJSON(w, "Failed to retrieve link", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(link)
+}
+
+// htmxToggleLink flips a link from the portal list and renders its row again.
+func (s *Server) htmxToggleLink(w http.ResponseWriter, r *http.Request, id int64) {
+	if _, err := s.store.ToggleLink(r.Context(), id); err != nil {
+		logRequestError(r, "Error toggling link", err, "id", id)
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, "Link not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Failed to toggle link", http.StatusInternalServerError)
+		}
+		return
+	}
+	s.notifyUpdated(r.Context(), id)
+
+	link, err := s.store.GetLinkByID(r.Context(), id)
+	if err != nil {
+		logRequestError(r, "Error fetching link", err, "id", id)
+		http.Error(w, "Failed to load link", http.StatusInternalServerError)
+		return
+	}
+	if err := s.executeTemplate(w, "link-row", link); err != nil {
+		logRequestError(r, "Template execution error", err)
+		http.Error(w, "Template rendering error", http.StatusInternalServerError)
+	}
+}
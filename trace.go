@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// maxTraceHops bounds how many links a redirect trace follows.
+const maxTraceHops = 10
+
+// Rules by which a path resolves to a link.
+const (
+	ResolveRuleExact     = "exact"
+	ResolveRuleTemplated = "templated"
+	ResolveRulePrefix    = "prefix"
+)
+
+// Reasons a redirect trace ends.
+const (
+	TraceEndTarget   = "target"    // the last hop leaves this server
+	TraceEndNotFound = "not_found" // a path has no link
+	TraceEndExpired  = "expired"   // a link has expired
+	TraceEndDisabled = "disabled"  // a link is disabled
+	TraceEndCycle    = "cycle"     // a path was already visited
+	TraceEndLimit    = "limit"     // maxTraceHops was reached
+)
+
+// TraceHop is one step of a redirect trace: the path looked up, the link it
+// resolved to and the URL that link redirects to.
+type TraceHop struct {
+	Path   string `json:"path"`
+	LinkID int64  `json:"link_id"`
+	Rule   string `json:"rule"`
+	URL    string `json:"url"`
+}
+
+// TraceResult is the redirect chain for a path. Target is the URL of the last
+// hop, empty when the first path has no usable link.
+type TraceResult struct {
+	Path   string     `json:"path"`
+	Hops   []TraceHop `json:"hops"`
+	Target string     `json:"target,omitempty"`
+	End    string     `json:"end"`
+}
+
+// ResolveLink finds the link serving a path the way redirects do: an exact
+// match, then an alias, then a templated link, then a prefix link. The returned link's URL
+// has the captured segments applied, and the rule names the match used.
+func (s *Store) ResolveLink(ctx context.Context, path string) (*Link, string, error) {
+	link, err := s.GetLinkByPath(ctx, path)
+	if err == nil {
+		return link, ResolveRuleExact, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, "", err
+	}
+
+	// Aliases resolve to their link, whose URL is looked up now, so they
+	// follow edits of the link
+	link, err = s.GetLinkByAlias(ctx, path)
+	if err == nil {
+		return link, ResolveRuleAlias, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, "", err
+	}
+
+	// Templated links substitute the remaining segments, e.g. search/golang
+	link, capture, err := s.GetTemplatedLink(ctx, path)
+	if err == nil {
+		link.URL = expandTemplate(link.URL, capture)
+		return link, ResolveRuleTemplated, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, "", err
+	}
+
+	// Prefix links forward the remaining segments, e.g. jira/PROJ-123
+	link, suffix, err := s.GetPrefixLink(ctx, path)
+	if err != nil {
+		return nil, "", err
+	}
+	link.URL = appendSuffix(link.URL, suffix)
+	return link, ResolveRulePrefix, nil
+}
+
+// traceRedirects follows the links for a path for as long as their targets
+// point back at this server, identified by host, recording every hop.
+func (s *Server) traceRedirects(ctx context.Context, path, host string) (TraceResult, error) {
+	result := TraceResult{Path: path, Hops: []TraceHop{}}
+	visited := make(map[string]bool)
+	now := time.Now()
+	for {
+		if visited[path] {
+			result.End = TraceEndCycle
+			return result, nil
+		}
+		if len(result.Hops) == maxTraceHops {
+			result.End = TraceEndLimit
+			return result, nil
+		}
+		visited[path] = true
+
+		link, rule, err := s.store.ResolveLink(ctx, path)
+		if err == sql.ErrNoRows {
+			result.End = TraceEndNotFound
+			return result, nil
+		}
+		if err != nil {
+			return TraceResult{}, err
+		}
+		if !link.Enabled {
+			result.End = TraceEndDisabled
+			return result, nil
+		}
+		if link.isExpired(now) {
+			result.End = TraceEndExpired
+			return result, nil
+		}
+
+		result.Hops = append(result.Hops, TraceHop{Path: path, LinkID: link.ID, Rule: rule, URL: link.URL})
+		result.Target = link.URL
+
+		next, ok := localPath(link.URL, host)
+		if !ok {
+			result.End = TraceEndTarget
+			return result, nil
+		}
+		path = next
+	}
+}
+
+// localPath returns the link path a target URL names on this server, where
+// host is the host this server was reached at, and false for other targets.
+func localPath(target, host string) (string, bool) {
+	u, err := url.Parse(target)
+	if err != nil || u.Host == "" {
+		return "", false
+	}
+	if hostname, _, err := net.SplitHostPort(host); err == nil {
+		host = hostname
+	}
+	if !strings.EqualFold(u.Hostname(), host) {
+		return "", false
+	}
+	path := normalizePath(u.Path)
+	if path == "" {
+		return "", false
+	}
+	return path, true
+}
+
+// handleTrace returns the chain of links a path redirects through.
+// Trace godoc
+// @Summary      Trace the redirect chain of a path
+// @Description  List the hops (path, link ID, match rule, URL) a redirect for the path follows, through targets on this server, until an external target, a missing or expired link, a cycle or the hop limit
+// @Tags         links
+// @Produce      json
+// @Param        path  query  string  true  "Link path"
+// @Success      200  {object}  TraceResult
+// @Failure      400  {object}  ErrorResponse
+// @Router       /trace [get]
+func (s *Server) handleTrace(w http.ResponseWriter, r *http.Request) {
+	path := normalizePath(r.URL.Query().Get("path"))
+	if path == "" {
+		writeErrorJSON(w, "Query parameter 'path' is required", http.StatusBadRequest)
+		return
+	}
+
+	result, err := s.traceRedirects(r.Context(), path, r.Host)
+	if err != nil {
+		logRequestError(r, "API Trace error", err)
+		writeErrorJSON(w, "Failed to trace path", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+func TestTransferOwnership(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        func(ids []int64) string
+		status      int
+		transferred []bool
+	}{
+		{"all links", func(ids []int64) string { return `{"from":"alice","to":"bob"}` }, http.StatusOK, []bool{true, true, false}},
+		{"listed links", func(ids []int64) string {
+			return `{"from":"alice","to":"bob","ids":[` + strconv.FormatInt(ids[0], 10) + `]}`
+		}, http.StatusOK, []bool{true, false, false}},
+		{"misspelled ids", func(ids []int64) string {
+			return `{"from":"alice","to":"bob","id":[` + strconv.FormatInt(ids[0], 10) + `]}`
+		}, http.StatusBadRequest, []bool{false, false, false}},
+		{"trailing data", func(ids []int64) string { return `{"from":"alice","to":"bob"} {}` }, http.StatusBadRequest, []bool{false, false, false}},
+		{"same owner", func(ids []int64) string { return `{"from":"alice","to":" alice "}` }, http.StatusUnprocessableEntity, []bool{false, false, false}},
+		{"missing to", func(ids []int64) string { return `{"from":"alice"}` }, http.StatusUnprocessableEntity, []bool{false, false, false}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, handler := newTestServer(t, func(c *Config) { c.AdminToken = "secret" })
+			links := []*Link{
+				createLink(t, server, handler, Link{Path: "docs", URL: "https://docs.example.com", Owner: "alice"}),
+				createLink(t, server, handler, Link{Path: "wiki", URL: "https://wiki.example.com", Owner: "alice"}),
+				createLink(t, server, handler, Link{Path: "jira", URL: "https://jira.example.com", Owner: "carol"}),
+			}
+			ids := []int64{links[0].ID, links[1].ID, links[2].ID}
+
+			w := serveAs(t, handler, http.MethodPost, "/api/links/transfer", tt.body(ids), credentials{bearer: "secret"})
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body.String())
+			}
+			for i, link := range links {
+				stored, err := server.store.GetLinkByID(context.Background(), link.ID)
+				if err != nil {
+					t.Fatalf("GetLinkByID: %v", err)
+				}
+				if moved := stored.Owner == "bob"; moved != tt.transferred[i] {
+					t.Errorf("%s: owner = %q, transferred = %v, want %v", link.Path, stored.Owner, moved, tt.transferred[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TrashedLink is a deleted link that can still be restored.
+type TrashedLink struct {
+	Link
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// ListTrashed returns the links in the trash, most recently deleted first.
+func (s *Store) ListTrashed(ctx context.Context) ([]TrashedLink, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT data, deleted_at FROM deleted_links WHERE data IS NOT NULL ORDER BY deleted_at DESC, link_id DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	trashed := []TrashedLink{}
+	for rows.Next() {
+		var data string
+		var link TrashedLink
+		if err := rows.Scan(&data, &link.DeletedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(data), &link.Link); err != nil {
+			return nil, fmt.Errorf("failed to decode deleted link: %w", err)
+		}
+		trashed = append(trashed, link)
+	}
+	return trashed, rows.Err()
+}
+
+// RestoreLink moves a link out of the trash under its original ID, with its
+// tags, clicks and creation time. It fails when the path has been reused by
+// another link in the meantime.
+func (s *Store) RestoreLink(ctx context.Context, id int64) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var data sql.NullString
+	err = tx.QueryRowContext(ctx, `SELECT data FROM deleted_links WHERE link_id = ?`, id).Scan(&data)
+	if err == sql.ErrNoRows || err == nil && !data.Valid {
+		return fmt.Errorf("link with id %d is not in the trash", id)
+	}
+	if err != nil {
+		return err
+	}
+	var link Link
+	if err := json.Unmarshal([]byte(data.String), &link); err != nil {
+		return fmt.Errorf("failed to decode deleted link: %w", err)
+	}
+
+	link.ID = id
+	if _, err := insertStoredLink(tx, link); err != nil {
+		if strings.Contains(err.Error(), "already exists") {
+			return fmt.Errorf("%s; rename or delete it first", err)
+		}
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM deleted_links WHERE link_id = ?`, id); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// PurgeLink permanently deletes a link from the trash together with its
+// uploaded icons. The tombstone stays so sync clients still see the deletion.
+func (s *Store) PurgeLink(ctx context.Context, id int64) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `UPDATE deleted_links SET data = NULL WHERE link_id = ? AND data IS NOT NULL`, id)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return fmt.Errorf("link with id %d is not in the trash", id)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM link_icons WHERE link_id = ?`, id); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// trashErrorStatus maps trash errors to HTTP status codes.
+func trashErrorStatus(err error) int {
+	switch {
+	case strings.Contains(err.Error(), "not in the trash"):
+		return http.StatusNotFound
+	case strings.Contains(err.Error(), "already"):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// handleListTrash returns the deleted links that can still be restored.
+// ListTrash godoc
+// @Summary      List deleted links
+// @Description  Links in the trash, most recently deleted first; restore them with POST /links/{id}/restore
+// @Tags         links
+// @Produce      json
+// @Success      200  {array}  TrashedLink
+// @Router       /trash [get]
+func (s *Server) handleListTrash(w http.ResponseWriter, r *http.Request) {
+	trashed, err := s.store.ListTrashed(r.Context())
+	if err != nil {
+		logRequestError(r, "API ListTrash error", err)
+		writeErrorJSON(w, "Failed to retrieve trash", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(trashed)
+}
+
+// handleRestoreLink moves a deleted link out of the trash.
+// RestoreLink godoc
+// @Summary      Restore a deleted link
+// @Description  Bring a link back from the trash under its original ID
+// @Tags         links
+// @Produce      json
+// @Param        id   path      int  true  "Link ID"
+// @Success      200  {object}  Link
+// @Failure      404  {object}  ErrorResponse
+// @Failure      409  {object}  ErrorResponse
+// @Router       /links/{id}/restore [post]
+func (s *Server) handleRestoreLink(w http.ResponseWriter, r *http.Request, id int64) {
+	if err := s.store.RestoreLink(r.Context(), id); err != nil {
+		status := trashErrorStatus(err)
+		if status == http.StatusInternalServerError {
+			logRequestError(r, "API RestoreLink error", err, "id", id)
+			writeErrorJSON(w, "Failed to restore link", status)
+			return
+		}
+		writeErrorJSON(w, err.Error(), status)
+		return
+	}
+
+	link, err := s.store.GetLinkByID(r.Context(), id)
+	if err != nil {
+		logRequestError(r, "API RestoreLink error", err, "id", id)
+		writeErrorJSON(w, "Failed to retrieve link", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(link)
+}
+
+// handlePurgeLink permanently deletes a link from the trash.
+// PurgeLink godoc
+// @Summary      Permanently delete a link
+// @Description  Remove a link from the trash for good; it can no longer be restored
+// @Tags         links
+// @Param        id   path      int  true  "Link ID"
+// @Success      204  "No Content"
+// @Failure      404  {object}  ErrorResponse
+// @Router       /trash/{id} [delete]
+func (s *Server) handlePurgeLink(w http.ResponseWriter, r *http.Request, id int64) {
+	if err := s.store.PurgeLink(r.Context(), id); err != nil {
+		status := trashErrorStatus(err)
+		if status == http.StatusInternalServerError {
+			logRequestError(r, "API PurgeLink error", err, "id", id)
+			writeErrorJSON(w, "Failed to purge link", status)
+			return
+		}
+		writeErrorJSON(w, err.Error(), status)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// htmxTrashHandler renders the trash panel of the portal.
+func (s *Server) htmxTrashHandler(w http.ResponseWriter, r *http.Request) {
+	trashed, err := s.store.ListTrashed(r.Context())
+	if err != nil {
+		logRequestError(r, "Error fetching trash", err)
+		http.Error(w, "Failed to load trash", http.StatusInternalServerError)
+		return
+	}
+
+	data := struct {
+		Trashed []TrashedLink
+	}{
+		Trashed: trashed,
+	}
+
+	err = s.executeTemplate(w, "trash", data)
+	if err != nil {
+		logRequestError(r, "Template execution error in trash", err)
+		http.Error(w, "Template rendering error", http.StatusInternalServerError)
+		return
+	}
+}
+
+// htmxTrashRouter handles /go/htmx/trash/{id}/restore and /go/htmx/trash/{id}.
+func (s *Server) htmxTrashRouter(w http.ResponseWriter, r *http.Request, path string) {
+	parts := strings.Split(strings.TrimPrefix(path, "/trash/"), "/")
+	id, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid link ID", http.StatusBadRequest)
+		return
+	}
+
+	switch {
+	case len(parts) == 2 && parts[1] == "restore" && r.Method == http.MethodPost:
+		if err := s.store.RestoreLink(r.Context(), id); err != nil {
+			logRequestError(r, "Error restoring link", err)
+			message := "Failed to restore link"
+			if trashErrorStatus(err) != http.StatusInternalServerError {
+				message = err.Error()
+			}
+			s.htmxRenderPortalContent(w, r, "", message, "")
+			return
+		}
+		s.htmxRenderPortalContent(w, r, "Link restored successfully", "", "")
+	case len(parts) == 1 && r.Method == http.MethodDelete:
+		if err := s.store.PurgeLink(r.Context(), id); err != nil {
+			logRequestError(r, "Error purging link", err)
+		}
+		s.htmxTrashHandler(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+// listTrash returns the ids of the links in the trash, most recently deleted
+// first.
+func listTrash(t *testing.T, handler http.Handler) []int64 {
+	t.Helper()
+	w := serve(t, handler, http.MethodGet, "/api/trash", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("listing trash: status = %d: %s", w.Code, w.Body.String())
+	}
+	var trashed []TrashedLink
+	if err := json.Unmarshal(w.Body.Bytes(), &trashed); err != nil {
+		t.Fatalf("decoding trash: %v", err)
+	}
+	ids := []int64{}
+	for _, link := range trashed {
+		ids = append(ids, link.ID)
+	}
+	return ids
+}
+
+func TestRestoreLink(t *testing.T) {
+	server, handler := newTestServer(t, nil)
+	ctx := context.Background()
+	wiki := createLink(t, server, handler, Link{Path: "wiki", URL: "https://wiki.example.com", Owner: "ops", Tags: []string{"docs"}})
+	if w := serveAs(t, handler, http.MethodPost, linkTarget(wiki.ID)+"/aliases", `{"alias":"handbook"}`, credentials{}); w.Code != http.StatusCreated {
+		t.Fatalf("adding alias: status = %d: %s", w.Code, w.Body.String())
+	}
+	serve(t, handler, http.MethodGet, "/wiki", nil)
+	flushClicks(server)
+	before, _ := server.store.GetLinkByID(ctx, wiki.ID)
+	other := createLink(t, server, handler, Link{Path: "other", URL: "https://other.example.com"})
+
+	deleteLink(t, server, handler, wiki)
+	deleteLink(t, server, handler, other)
+	if got, want := listTrash(t, handler), []int64{other.ID, wiki.ID}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("trash = %v, want %v", got, want)
+	}
+	if w := serve(t, handler, http.MethodGet, "/handbook", nil); w.Code == http.StatusFound {
+		t.Errorf("alias of a deleted link still redirects")
+	}
+
+	w := serve(t, handler, http.MethodPost, linkTarget(wiki.ID)+"/restore", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("restore: status = %d: %s", w.Code, w.Body.String())
+	}
+	restored, err := server.store.GetLinkByID(ctx, wiki.ID)
+	if err != nil {
+		t.Fatalf("GetLinkByID: %v", err)
+	}
+	if restored.Path != before.Path || restored.URL != before.URL || restored.Owner != before.Owner ||
+		!reflect.DeepEqual(restored.Tags, before.Tags) || !reflect.DeepEqual(restored.Aliases, before.Aliases) ||
+		restored.Clicks != before.Clicks || !restored.CreatedAt.Equal(before.CreatedAt) {
+		t.Errorf("restored = %+v, want %+v", restored, before)
+	}
+	if got, want := listTrash(t, handler), []int64{other.ID}; !reflect.DeepEqual(got, want) {
+		t.Errorf("trash after restore = %v, want %v", got, want)
+	}
+	for _, target := range []string{"/wiki", "/handbook"} {
+		if w := serve(t, handler, http.MethodGet, target, nil); w.Code != http.StatusFound {
+			t.Errorf("GET %s after restore = %d, want %d", target, w.Code, http.StatusFound)
+		}
+	}
+}
+
+func TestRestoreLinkErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		setup  func(t *testing.T, server *Server, handler http.Handler, link *Link)
+		status int
+	}{
+		{"not deleted", func(t *testing.T, server *Server, handler http.Handler, link *Link) {}, http.StatusNotFound},
+		{"path reused", func(t *testing.T, server *Server, handler http.Handler, link *Link) {
+			deleteLink(t, server, handler, link)
+			createLink(t, server, handler, Link{Path: link.Path, URL: "https://new.example.com"})
+		}, http.StatusConflict},
+		{"purged", func(t *testing.T, server *Server, handler http.Handler, link *Link) {
+			deleteLink(t, server, handler, link)
+			if w := serve(t, handler, http.MethodDelete, "/api/trash/"+strconv.FormatInt(link.ID, 10), nil); w.Code != http.StatusNoContent {
+				t.Fatalf("purge: status = %d: %s", w.Code, w.Body.String())
+			}
+		}, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, handler := newTestServer(t, nil)
+			link := createLink(t, server, handler, Link{Path: "wiki", URL: "https://wiki.example.com"})
+			tt.setup(t, server, handler, link)
+			if w := serve(t, handler, http.MethodPost, linkTarget(link.ID)+"/restore", nil); w.Code != tt.status {
+				t.Errorf("restore: status = %d, want %d: %s", w.Code, tt.status, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestPurgeLinkTwice(t *testing.T) {
+	server, handler := newTestServer(t, nil)
+	link := createLink(t, server, handler, Link{Path: "wiki", URL: "https://wiki.example.com"})
+	deleteLink(t, server, handler, link)
+	target := "/api/trash/" + strconv.FormatInt(link.ID, 10)
+	if w := serve(t, handler, http.MethodDelete, target, nil); w.Code != http.StatusNoContent {
+		t.Fatalf("first purge: status = %d: %s", w.Code, w.Body.String())
+	}
+	if w := serve(t, handler, http.MethodDelete, target, nil); w.Code != http.StatusNotFound {
+		t.Errorf("second purge: status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+	if ids := listTrash(t, handler); len(ids) != 0 {
+		t.Errorf("trash after purge = %v, want empty", ids)
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// defaultUnfurlBots lists User-Agent substrings of common chat link unfurlers.
+var defaultUnfurlBots = []string{
+	"Slackbot",
+	"Slack-ImgProxy",
+	"Twitterbot",
+	"facebookexternalhit",
+	"Discordbot",
+	"TelegramBot",
+	"LinkedInBot",
+	"WhatsApp",
+	"SkypeUriPreview",
+	"Mattermost",
+}
+
+// Unfurl is the preview shown by chat tools for a go link.
+type Unfurl struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Target      string `json:"target"`
+}
+
+// unfurlFor builds the preview of a link, described by the link's own
+// description when it has one.
+func unfurlFor(link Link) Unfurl {
+	description := link.Description
+	if description == "" {
+		description = "Redirects to " + link.URL
+	}
+	return Unfurl{
+		Title:       "go/" + link.Path,
+		Description: description,
+		Target:      link.URL,
+	}
+}
+
+// isUnfurlBot reports whether the User-Agent matches a configured unfurler.
+func (s *Server) isUnfurlBot(userAgent string) bool {
+	if userAgent == "" {
+		return false
+	}
+	userAgent = strings.ToLower(userAgent)
+	for _, bot := range s.config.UnfurlBots {
+		if strings.Contains(userAgent, strings.ToLower(bot)) {
+			return true
+		}
+	}
+	return false
+}
+
+// renderUnfurl serves an HTML page with Open Graph tags describing the link
+// instead of redirecting, so chat tools can show a preview.
+func (s *Server) renderUnfurl(w http.ResponseWriter, link Link) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.executeTemplate(w, "unfurl.html", unfurlFor(link)); err != nil {
+		slog.Error("Template execution error in unfurl", "error", err)
+		http.Error(w, "Template rendering error", http.StatusInternalServerError)
+	}
+}
+
+// handleGetUnfurl returns the unfurl preview of a link as JSON.
+// GetUnfurl godoc
+// @Summary      Link unfurl preview
+// @Description  Title, description and target suitable for a chat unfurl preview
+// @Tags         links
+// @Produce      json
+// @Param        id  path  int  true  "Link ID"
+// @Success      200  {object}  Unfurl
+// @Failure      404  {object}  ErrorResponse
+// @Router       /links/{id}/unfurl [get]
+func (s *Server) handleGetUnfurl(w http.ResponseWriter, r *http.Request, id int64) {
+	link, err := s.store.GetLinkByID(r.Context(), id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			writeErrorJSON(w, fmt.Sprintf("Link with id %d not found", id), http.StatusNotFound)
+			return
+		}
+		logRequestError(r, "API GetUnfurl error", err, "id", id)
+		writeErrorJSON(w, "Failed to retrieve link", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(unfurlFor(*link))
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Webhook actions, sent as the action of a WebhookEvent.
+const (
+	WebhookActionCreate = "create"
+	WebhookActionUpdate = "update"
+	WebhookActionDelete = "delete"
+)
+
+const (
+	// webhookQueueSize is how many events may wait for delivery before new
+	// ones are dropped.
+	webhookQueueSize = 256
+	// webhookTimeout bounds a single delivery attempt.
+	webhookTimeout = 5 * time.Second
+	// webhookAttempts is how often delivery to a URL is tried; each retry
+	// waits twice as long as the one before, starting at webhookRetryDelay.
+	webhookAttempts   = 3
+	webhookRetryDelay = time.Second
+)
+
+// webhookSignatureHeader carries "sha256=" and the hex HMAC-SHA256 of the
+// body, keyed with WEBHOOK_SECRET.
+const webhookSignatureHeader = "X-GoLink-Signature"
+
+// WebhookEvent is the body POSTed to webhooks when a link changes. For
+// deletions Link is the link as it was before.
+type WebhookEvent struct {
+	Action    string    `json:"action"`
+	Link      Link      `json:"link"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// webhookNotifier delivers link change events to the configured webhooks
+// from a buffered queue in the background, so handlers never wait for them.
+// A nil notifier, used when no webhooks are configured, drops every event.
+type webhookNotifier struct {
+	urls   []string
+	secret string
+	client *http.Client
+	events chan WebhookEvent
+}
+
+// newWebhookNotifier returns a notifier for the configured webhooks, or nil
+// when there are none. Events are only delivered once start runs the worker.
+func newWebhookNotifier(config *Config, size int) *webhookNotifier {
+	if len(config.WebhookURLs) == 0 {
+		return nil
+	}
+	return &webhookNotifier{
+		urls:   config.WebhookURLs,
+		secret: config.WebhookSecret,
+		client: &http.Client{Timeout: webhookTimeout},
+		events: make(chan WebhookEvent, size),
+	}
+}
+
+// Notify queues an event without blocking. When the queue is full the event
+// is dropped and a warning logged.
+func (n *webhookNotifier) Notify(action string, link Link) {
+	if n == nil {
+		return
+	}
+	select {
+	case n.events <- WebhookEvent{Action: action, Link: link, Timestamp: time.Now().UTC()}:
+	default:
+		slog.Warn("Webhook queue full, dropping event", "action", action, "path", link.Path)
+	}
+}
+
+// start runs the worker until ctx is cancelled. Events still queued then are
+// delivered before the worker reports done.
+func (n *webhookNotifier) start(ctx context.Context, wg *sync.WaitGroup) {
+	if n == nil {
+		return
+	}
+	// Deliveries must not fail just because shutdown has begun
+	deliverCtx := context.WithoutCancel(ctx)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case event := <-n.events:
+				n.deliver(deliverCtx, event)
+			case <-ctx.Done():
+				for {
+					select {
+					case event := <-n.events:
+						n.deliver(deliverCtx, event)
+					default:
+						return
+					}
+				}
+			}
+		}
+	}()
+}
+
+// deliver sends an event to every webhook, logging those that still fail
+// after all attempts.
+func (n *webhookNotifier) deliver(ctx context.Context, event WebhookEvent) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		slog.Error("Error encoding webhook event", "action", event.Action, "path", event.Link.Path, "error", err)
+		return
+	}
+
+	for _, url := range n.urls {
+		delay := webhookRetryDelay
+		for attempt := 1; ; attempt++ {
+			err = n.post(ctx, url, event.Action, body)
+			if err == nil || attempt == webhookAttempts {
+				break
+			}
+			slog.Warn("Webhook delivery failed, retrying", "url", url, "attempt", attempt, "delay", delay, "error", err)
+			time.Sleep(delay)
+			delay *= 2
+		}
+		if err != nil {
+			slog.Error("Webhook delivery failed", "url", url, "action", event.Action, "path", event.Link.Path, "error", err)
+		}
+	}
+}
+
+// post sends one signed delivery attempt. Any 2xx response is a success.
+func (n *webhookNotifier) post(ctx context.Context, url, action string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-GoLink-Event", action)
+	if n.secret != "" {
+		req.Header.Set(webhookSignatureHeader, signWebhook(n.secret, body))
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// signWebhook returns the signature header value of a webhook body.
+func signWebhook(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// notifyCreated sends a create event for the link just stored under path.
+func (s *Server) notifyCreated(ctx context.Context, path string) {
+	if s.webhooks == nil {
+		return
+	}
+	link, err := s.store.GetLinkByPath(ctx, strings.ToLower(normalizePath(path)))
+	if err != nil {
+		slog.Error("Error reading link for webhook", "path", path, "error", err)
+		return
+	}
+	s.webhooks.Notify(WebhookActionCreate, *link)
+}
+
+// notifyUpdated sends an update event for the link just updated.
+func (s *Server) notifyUpdated(ctx context.Context, id int64) {
+	if s.webhooks == nil {
+		return
+	}
+	link, err := s.store.GetLinkByID(ctx, id)
+	if err != nil {
+		slog.Error("Error reading link for webhook", "id", id, "error", err)
+		return
+	}
+	s.webhooks.Notify(WebhookActionUpdate, *link)
+}
+
+// linkBeforeDelete returns the link about to be deleted, for its delete
+// event. It returns nil when no webhooks are configured or the link cannot
+// be read, in which case the delete itself reports the problem.
+func (s *Server) linkBeforeDelete(ctx context.Context, id int64) *Link {
+	if s.webhooks == nil {
+		return nil
+	}
+	link, err := s.store.GetLinkByID(ctx, id)
+	if err != nil {
+		return nil
+	}
+	return link
+}
+
+// notifyDeleted sends a delete event for a link read by linkBeforeDelete.
+func (s *Server) notifyDeleted(link *Link) {
+	if link == nil {
+		return
+	}
+	s.webhooks.Notify(WebhookActionDelete, *link)
+}